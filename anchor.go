@@ -0,0 +1,80 @@
+package matcher
+
+import (
+	"github.com/iNamik/go_lexer"
+)
+
+// anchorKind identifies a zero-width assertion about the runes around the
+// current position
+type anchorKind int
+
+const (
+	anchorBOL anchorKind = iota
+	anchorEOL
+	anchorWordBoundary
+	anchorNotWordBoundary
+	anchorBOF
+)
+
+// anchorNames are the names of the anchors, for diagnostics and panics
+var anchorNames = [...]string{
+	anchorBOL:             "start of line",
+	anchorEOL:             "end of line",
+	anchorWordBoundary:    "word boundary",
+	anchorNotWordBoundary: "non-word boundary",
+	anchorBOF:             "start of input",
+}
+
+// anchorRegexps are the anchors in regex syntax
+var anchorRegexps = [...]string{
+	anchorBOL:             `(?m:^)`,
+	anchorEOL:             `(?m:$)`,
+	anchorWordBoundary:    `\b`,
+	anchorNotWordBoundary: `\B`,
+	anchorBOF:             `\A`,
+}
+
+// lookbehind is implemented by lexers that remember the rune before the
+// current position, such as Input
+type lookbehind interface {
+	PrevRune() rune
+}
+
+// anchorMatches tests an anchor against the runes before and after the
+// current position.  prev is lexer.RuneEOF at the start of the input.
+func anchorMatches(kind anchorKind, prev rune, next rune) bool {
+	switch kind {
+	case anchorBOL:
+		return prev == lexer.RuneEOF || prev == '\n'
+	case anchorEOL:
+		return next == lexer.RuneEOF || next == '\n'
+	case anchorWordBoundary:
+		return isWordRune(prev) != isWordRune(next)
+	case anchorNotWordBoundary:
+		return isWordRune(prev) == isWordRune(next)
+	}
+
+	return prev == lexer.RuneEOF
+}
+
+// anchorMatchesLexer tests an anchor at the current position of the lexer.
+// Panics if the anchor needs the previous rune, and the lexer does not
+// remember it.
+func anchorMatchesLexer(kind anchorKind, l lexer.Lexer) bool {
+	if kind == anchorEOL {
+		return anchorMatches(kind, lexer.RuneEOF, l.PeekRune(0))
+	}
+
+	lb, ok := l.(lookbehind)
+
+	if !ok {
+		panic("Matching " + anchorNames[kind] + " needs the previous rune, use an Input as the lexer")
+	}
+
+	return anchorMatches(kind, lb.PrevRune(), l.PeekRune(0))
+}
+
+// isWordRune returns true for the ASCII word runes [0-9A-Za-z_], as \w
+func isWordRune(r rune) bool {
+	return r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
@@ -0,0 +1,83 @@
+package matcher
+
+import (
+	"testing"
+)
+
+// TestAnchors checks each anchor against the runes around the position, with
+// a Matcher and a Pattern over an Input, and a Pattern searching bytes
+func TestAnchors(t *testing.T) {
+	word := NewRuneSetFromRangeString("a-z")
+
+	runOperandTests(t, []operandTest{
+		{"BOL", func(m Matcher) MatcherOperator {
+			return m.MatchZeroOrMoreSet(word).And().MatchZeroOrOneRune('\n').And().MatchBOL().And().MatchOneRune('1')
+		}, map[string]string{"1": "1", "ab\n1": "ab\n1", "ab1": "!", "\n1": "\n1"}},
+		{"EOL", func(m Matcher) MatcherOperator {
+			return m.MatchZeroOrMoreSet(word).And().MatchEOL()
+		}, map[string]string{"ab\nc": "ab", "ab": "ab", "": "", "ab c": "!"}},
+		{"word boundary", func(m Matcher) MatcherOperator {
+			return m.MatchOneOrMoreSet(word).And().MatchWordBoundary().And().MatchZeroOrOneRune('_')
+		}, map[string]string{"ab c": "ab", "ab": "ab", "ab_": "!", "ab-": "ab"}},
+		{"word boundary at the start", func(m Matcher) MatcherOperator {
+			return m.MatchWordBoundary().And().MatchOneRune('a')
+		}, map[string]string{"a": "a", "": "!"}},
+		{"non-word boundary", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('a').And().MatchNotWordBoundary().And().MatchZeroOrOneRune('1')
+		}, map[string]string{"a1": "a1", "a-": "!", "a": "!"}},
+		{"BOF after a rune", func(m Matcher) MatcherOperator {
+			return m.MatchBOF().And().MatchOneRune('a').And().MatchZeroOrOneRune('\n').And().MatchBOF()
+		}, map[string]string{"a": "!", "a\n": "!"}},
+		{"BOF only", func(m Matcher) MatcherOperator {
+			return m.MatchBOF().And().MatchOneRune('a')
+		}, map[string]string{"a": "a", "b": "!"}},
+	})
+}
+
+// TestAnchorsLexer checks that the anchors needing the previous rune panic
+// over a lexer that is not an Input, unlike EOL, and match over an Input
+func TestAnchorsLexer(t *testing.T) {
+	tests := []struct {
+		name  string
+		fn    func(Matcher) MatcherOperator
+		panic string
+		input string
+	}{
+		{"BOL", func(m Matcher) MatcherOperator { return m.MatchBOL() }, "start of line", ""},
+		{"word boundary", func(m Matcher) MatcherOperator { return m.MatchWordBoundary() }, "word boundary", ""},
+		{"non-word boundary", func(m Matcher) MatcherOperator { return m.MatchNotWordBoundary() }, "non-word boundary", "!"},
+		{"BOF", func(m Matcher) MatcherOperator { return m.MatchBOF() }, "start of input", ""},
+		{"EOL", func(m Matcher) MatcherOperator { return m.MatchOneRune('a').And().MatchEOL() }, "", "a"},
+	}
+
+	for _, test := range tests {
+		p := Compile(test.fn)
+
+		for _, fn := range []func(Matcher) MatcherOperator{test.fn, func(m Matcher) MatcherOperator { return m.MatchPattern(p) }} {
+			func() {
+				defer func() {
+					var want interface{}
+					if test.panic != "" {
+						want = "Matching " + test.panic + " needs the previous rune, use an Input as the lexer"
+					}
+
+					if r := recover(); r != want {
+						t.Errorf("%s: panicked with %v, want %v", test.name, r, want)
+					}
+				}()
+
+				matchLexer(fn, "a")
+			}()
+		}
+
+		ok, got := matchMatcher(test.fn, "a")
+
+		if !ok {
+			got = "!"
+		}
+
+		if got != test.input {
+			t.Errorf("%s over an Input: matched %q, want %q", test.name, got, test.input)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package matcher
+
+import (
+	"github.com/iNamik/go_lexer"
+)
+
+// CompileBacktracking records the expression built by fn into a
+// Pattern that backtracks as a regular expression does: when an operand
+// fails, runs that came before it give back runes one at a time, optional
+// groupings are skipped, and later alternatives are tried, until the rest of
+// the expression matches.  Runs are greedy, or lazy if followed by Lazy(), in
+// which case they take as few runes as they can, and one more at each retry,
+// or possessive if followed by Possessive(), in which case they take as many
+// runes as they can, and give none back.
+//
+// A backtracking Pattern used as an operand of a non-backtracking expression
+// behaves atomically: the first way it matches is kept.  Likewise, a Pattern
+// compiled with Compile used as an operand of a backtracking expression is
+// matched as it would be on its own, and is never backtracked into.
+// Backtracking can take time exponential in the length of the input.
+func CompileBacktracking[F PatternFunc](fn F) *Pattern {
+	root := record(fn)
+
+	root.backtrack = true
+
+	return &Pattern{root: root, first: firstSet(root)}
+}
+
+// groupFrame identifies an attempt to match a group, for cuts
+type groupFrame struct{}
+
+// executor::backtrack matches the node, followed by the continuation k,
+// trying each way the node can match, in order of preference, until k
+// succeeds.  The executor is rewound to where it started if there is none.
+func (e *executor) backtrack(n *node, k func() bool) bool {
+	if e.cutting != nil {
+		return false
+	}
+
+	if n.skip == nil {
+		return e.backtrackNode(n, k)
+	}
+
+	// Skipping is possessive, so it is only tried one way
+	m := e.mark()
+
+	e.skip(n.skip)
+
+	if e.backtrackNode(n, k) {
+		return true
+	}
+
+	e.reset(m)
+
+	return false
+}
+
+// executor::backtrackNode matches the node, without skipping, followed by k
+func (e *executor) backtrackNode(n *node, k func() bool) bool {
+	switch n.kind {
+	case nodeEOF:
+		return e.peek() == lexer.RuneEOF && k()
+	case nodeAnchor:
+		return e.anchor(n) && k()
+	case nodeBackref, nodeUntil, nodeBalanced, nodeList, nodeRef:
+		// These match in only one way, lists and declared Patterns being
+		// possessive
+		return e.backtrackOnce(n, k)
+	case nodeGroup:
+		if n.embedded && !n.backtrack {
+			// A non-backtracking Pattern matches in only one way
+			return e.backtrackOnce(n, k)
+		}
+
+		return e.backtrackGroup(n, k)
+	}
+
+	switch {
+	case n.possessive:
+		return e.backtrackOnce(n, k)
+	case n.lazy:
+		return e.backtrackLazy(n, k)
+	}
+
+	return e.backtrackGreedy(n, k)
+}
+
+// executor::backtrackOnce matches the node in the one way a Matcher would,
+// followed by k
+func (e *executor) backtrackOnce(n *node, k func() bool) bool {
+	m := e.mark()
+
+	if e.matchNode(n) && k() {
+		return true
+	}
+
+	e.reset(m)
+
+	return false
+}
+
+// executor::backtrackGreedy matches as many runes as it can, giving them back
+// one at a time until k succeeds
+func (e *executor) backtrackGreedy(n *node, k func() bool) bool {
+	marks := []executorMarker{e.mark()}
+
+	for (n.max < 0 || len(marks) <= n.max) && n.accepts(e.peek()) {
+		e.next()
+		e.n++
+		marks = append(marks, e.mark())
+	}
+
+	for count := len(marks) - 1; count >= n.min && e.cutting == nil; count-- {
+		e.reset(marks[count])
+
+		if k() {
+			return true
+		}
+	}
+
+	e.reset(marks[0])
+
+	return false
+}
+
+// executor::backtrackLazy matches as few runes as it can, taking one more at a
+// time until k succeeds
+func (e *executor) backtrackLazy(n *node, k func() bool) bool {
+	start := e.mark()
+
+	for count := 0; e.cutting == nil; count++ {
+		if count >= n.min {
+			m := e.mark()
+
+			if k() {
+				return true
+			}
+
+			e.reset(m)
+		}
+
+		if (n.max >= 0 && count == n.max) || !n.accepts(e.peek()) {
+			break
+		}
+
+		e.next()
+		e.n++
+	}
+
+	e.reset(start)
+
+	return false
+}
+
+// executor::backtrackGroup matches the terms of a group, followed by k
+func (e *executor) backtrackGroup(n *node, k func() bool) bool {
+	start := e.mark()
+
+	// A capture is recorded once the group has matched, and removed if k fails
+	after := k
+
+	if n.capture != "" {
+		after = func() bool {
+			e.caps = append(e.caps, capture{n.capture, start.pos, e.pos})
+
+			if k() {
+				return true
+			}
+
+			e.caps = e.caps[:len(e.caps)-1]
+
+			return false
+		}
+	}
+
+	// An atomic group that has matched is not skipped, even if optional
+	committed := false
+
+	body := func() bool {
+		g := &groupFrame{}
+
+		var ok bool
+
+		if n.atomic {
+			// The first way the group matches is kept, whether k succeeds or not
+			committed = e.backtrackTerms(n, len(n.terms)-1, func() bool { return true }, g) && e.cutting == nil
+			ok = committed && after()
+		} else {
+			ok = e.backtrackTerms(n, len(n.terms)-1, after, g)
+		}
+
+		if e.cutting == g {
+			e.cutting = nil
+		}
+
+		return ok
+	}
+
+	tries := []func() bool{body}
+
+	if n.optional && n.lazy {
+		tries = []func() bool{k, body}
+	} else if n.optional {
+		tries = []func() bool{body, k}
+	}
+
+	for _, try := range tries {
+		if e.cutting != nil {
+			break
+		}
+
+		if try() {
+			return true
+		}
+
+		e.reset(start)
+
+		if committed {
+			break
+		}
+	}
+
+	return false
+}
+
+// executor::backtrackTerms matches the terms of a group up to and including
+// term i, followed by k.  As with a Matcher, And() and Or() are evaluated
+// from left to right, so an Or() offers term i as an alternative to all of
+// the terms before it.
+func (e *executor) backtrackTerms(n *node, i int, k func() bool, g *groupFrame) bool {
+	t := n.terms[i]
+
+	if t.cut {
+		k = e.cutAfter(k, g)
+	}
+
+	switch {
+	case i == 0:
+		return e.backtrack(t.node, k)
+
+	case t.op == opOr:
+		m := e.mark()
+
+		if e.backtrackTerms(n, i-1, k, g) {
+			return true
+		}
+
+		if e.cutting != nil {
+			return false
+		}
+
+		e.reset(m)
+
+		return e.backtrack(t.node, k)
+	}
+
+	return e.backtrackTerms(n, i-1, func() bool { return e.backtrack(t.node, k) }, g)
+}
+
+// executor::cutAfter returns a continuation that, if k fails, fails the rest
+// of the group without trying any other way to match it
+func (e *executor) cutAfter(k func() bool, g *groupFrame) func() bool {
+	return func() bool {
+		if k() {
+			return true
+		}
+
+		if e.cutting == nil {
+			e.cutting = g
+		}
+
+		return false
+	}
+}
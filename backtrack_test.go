@@ -0,0 +1,138 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// TestBacktrackingEmbeddedPattern checks that a Pattern compiled with Compile
+// keeps its own semantics inside a backtracking Pattern, while a backtracking
+// Pattern is backtracked into
+func TestBacktrackingEmbeddedPattern(t *testing.T) {
+	digits := func(m Matcher) MatcherOperator {
+		return m.MatchZeroOrMoreBytes([]byte("0123456789"))
+	}
+
+	// Regex: [0-9]*0
+	tens := func(p *Pattern) *Pattern {
+		return CompileBacktracking(func(m Matcher) MatcherOperator {
+			return m.MatchPattern(p).And().MatchOneRune('0')
+		})
+	}
+
+	runPatternTests(t, []patternTest{
+		{"compiled", tens(Compile(digits)), map[string]string{
+			"100": "!",
+			"0":   "!",
+		}},
+		{"backtracking", tens(CompileBacktracking(digits)), map[string]string{
+			"100": "100",
+			"0":   "0",
+			"1":   "!",
+		}},
+	})
+}
+
+// TestBacktrackingAtomic checks that atomic groupings and possessive runs
+// give nothing back, where plain ones do
+func TestBacktrackingAtomic(t *testing.T) {
+	digits := []byte("0123456789")
+
+	runPatternTests(t, []patternTest{
+		// Regex: (?:a|ab)c
+		{"group", CompileBacktracking(func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneRune('a').OrBegin().MatchOneRune('a').And().MatchOneRune('b').EndMatchOne().
+				EndMatchOne().And().MatchOneRune('c')
+		}), map[string]string{
+			"ac":  "ac",
+			"abc": "abc",
+		}},
+		// Regex: (?>a|ab)c
+		{"atomic", CompileBacktracking(func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneRune('a').OrBegin().MatchOneRune('a').And().MatchOneRune('b').EndMatchOne().
+				End().Atomic().And().MatchOneRune('c')
+		}), map[string]string{
+			"ac":  "ac",
+			"abc": "!",
+		}},
+		// Regex: [0-9]*0
+		{"greedy", CompileBacktracking(func(m Matcher) MatcherOperator {
+			return m.MatchZeroOrMoreBytes(digits).And().MatchOneRune('0')
+		}), map[string]string{
+			"100": "100",
+			"0":   "0",
+		}},
+		// Regex: [0-9]*+0
+		{"possessive run", CompileBacktracking(func(m Matcher) MatcherOperator {
+			return m.MatchZeroOrMoreBytes(digits).Possessive().And().MatchOneRune('0')
+		}), map[string]string{
+			"100": "!",
+			"x0":  "!",
+		}},
+		// Regex: [0-9]{1,2}+[0-9]
+		{"possessive bounded run", CompileBacktracking(func(m Matcher) MatcherOperator {
+			return m.MatchMinMaxBytes(digits, 1, 2).Possessive().And().MatchOneBytes(digits)
+		}), map[string]string{
+			"123": "123",
+			"12":  "!",
+		}},
+		// Regex: (?:ab)?+a
+		{"possessive grouping", CompileBacktracking(func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneRune('a').And().MatchOneRune('b').EndMatchZeroOrOne().Possessive().And().MatchOneRune('a')
+		}), map[string]string{
+			"aba": "aba",
+			"a":   "a",
+			"ab":  "!",
+		}},
+	})
+}
+
+// TestPossessivePanics checks the operands Possessive() can follow
+func TestPossessivePanics(t *testing.T) {
+	tests := map[string]func(m PatternMatcher) PatternOperator{
+		"single": func(m PatternMatcher) PatternOperator {
+			return m.MatchOneRune('a').Possessive()
+		},
+		"required grouping": func(m PatternMatcher) PatternOperator {
+			return m.Begin().MatchOneRune('a').EndMatchOne().Possessive()
+		},
+		"lazy": func(m PatternMatcher) PatternOperator {
+			return m.MatchZeroOrMoreRunes([]rune("a")).Lazy().Possessive()
+		},
+	}
+
+	for name, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Possessive() did not panic", name)
+				}
+			}()
+
+			CompileBacktracking(fn)
+		}()
+	}
+}
+
+// TestPossessiveMatcher checks that Possessive() has no effect on a Matcher,
+// whose runs never give anything back
+func TestPossessiveMatcher(t *testing.T) {
+	for _, possessive := range []bool{false, true} {
+		var result bool
+
+		start := func(l lexer.Lexer) lexer.StateFn {
+			m := New(l).MatchZeroOrMoreRunes([]rune("a"))
+			if possessive {
+				m = m.Possessive()
+			}
+			result = m.And().MatchOneRune('b').Result()
+			l.EmitTokenWithBytes(fuzzTokenMatch)
+			return nil
+		}
+
+		if got := string(lexer.NewFromBytes(start, []byte("aab"), 1).NextToken().Bytes()); !result || got != "aab" {
+			t.Errorf("possessive %v: matched %v %q, want aab", possessive, result, got)
+		}
+	}
+}
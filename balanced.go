@@ -0,0 +1,117 @@
+package matcher
+
+import (
+	"github.com/iNamik/go_lexer"
+)
+
+// BalancedOptions configures MatchBalanced
+type BalancedOptions struct {
+	Quotes []rune // Runes that begin, and end, literals in which delimiters are ignored
+	Escape rune   // Escapes the rune following it within literals, 0 for none
+}
+
+// balancedEscape returns the escape rune of the options, or noEscape
+func (o BalancedOptions) balancedEscape() rune {
+	if o.Escape == 0 {
+		return noEscape
+	}
+
+	return o.Escape
+}
+
+// checkBalanced panics if the delimiters of MatchBalanced cannot nest
+func checkBalanced(open rune, close rune) {
+	if open == close {
+		panic("MatchBalanced() open and close are the same rune")
+	}
+}
+
+// scanBalanced consumes a region beginning with open and ending with the
+// matching close, using peek and next to look at and consume runes.  Returns
+// the number of runes consumed, and false if the region is unbalanced, in
+// which case the caller rewinds.
+func scanBalanced(peek func() rune, next func(), open rune, close rune, quotes []rune, escape rune) (int, bool) {
+	if peek() != open {
+		return 0, false
+	}
+
+	next()
+
+	count, depth, quote := 1, 1, rune(lexer.RuneEOF)
+
+	for depth > 0 {
+		r := peek()
+		if r == lexer.RuneEOF {
+			return count, false
+		}
+
+		next()
+		count++
+
+		switch {
+		case quote != lexer.RuneEOF:
+			if r == quote {
+				quote = lexer.RuneEOF
+			} else if r == escape {
+				if peek() == lexer.RuneEOF {
+					return count, false
+				}
+				next()
+				count++
+			}
+
+		case containsRune(quotes, r):
+			quote = r
+
+		case r == open:
+			depth++
+
+		case r == close:
+			depth--
+		}
+	}
+
+	return count, true
+}
+
+// containsRune
+func containsRune(runes []rune, r rune) bool {
+	for _, x := range runes {
+		if x == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchBalanced consumes a balanced region from the lexer, leaving the lexer
+// unchanged if it is unbalanced
+func matchBalanced(l lexer.Lexer, open rune, close rune, opts BalancedOptions) bool {
+	m := l.Marker()
+
+	peek := func() rune { return l.PeekRune(0) }
+	next := func() { l.NextRune() }
+
+	if _, ok := scanBalanced(peek, next, open, close, opts.Quotes, opts.balancedEscape()); !ok {
+		l.Reset(m)
+		return false
+	}
+
+	return true
+}
+
+// executor::balanced matches a balanced node
+func (e *executor) balanced(n *node) bool {
+	m := e.mark()
+
+	count, ok := scanBalanced(e.peek, e.next, n.open, n.close, n.quotes, n.escape)
+	if !ok {
+		e.reset(m)
+		return false
+	}
+
+	e.n += count
+
+	return true
+}
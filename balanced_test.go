@@ -0,0 +1,87 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// TestMatchBalanced checks MatchBalanced against a Matcher and a Pattern
+func TestMatchBalanced(t *testing.T) {
+	opts := BalancedOptions{Quotes: []rune{'"', '\''}, Escape: '\\'}
+
+	tests := map[string]string{
+		"()":                     "()",
+		"(a)b":                   "(a)",
+		"(a(b)(c(d)))e)":         "(a(b)(c(d)))",
+		`(")")`:                  `(")")`,
+		`('(' ")")`:              `('(' ")")`,
+		`("\")" ')')`:            `("\")" ')')`,
+		`("'" ')'`:               "!",
+		"(a(b)":                  "!",
+		"((":                     "!",
+		`("\`:                    "!",
+		"a()":                    "!",
+		"":                       "!",
+		")(":                     "!",
+		"(ü(é))":                 "(ü(é))",
+		"(\"unterminated quote)": "!",
+	}
+
+	p := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchBalanced('(', ')', opts)
+	})
+
+	runPatternTests(t, []patternTest{{"pattern", p, tests}})
+
+	for input, want := range tests {
+		var result bool
+
+		start := func(l lexer.Lexer) lexer.StateFn {
+			result = New(l).MatchBalanced('(', ')', opts).Result()
+			l.EmitTokenWithBytes(fuzzTokenMatch)
+			return nil
+		}
+
+		got := string(lexer.NewFromBytes(start, []byte(input), 1).NextToken().Bytes())
+
+		if (want == "!") == result || (want != "!" && got != want) || (want == "!" && got != "") {
+			t.Errorf("matcher on %q: matched %v %q, want %q", input, result, got, want)
+		}
+	}
+}
+
+// TestMatchBalancedWithoutQuotes checks that quotes are only special when
+// configured, and escapes only within quotes
+func TestMatchBalancedWithoutQuotes(t *testing.T) {
+	p := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchBalanced('[', ']', BalancedOptions{})
+	})
+
+	runPatternTests(t, []patternTest{{"no quotes", p, map[string]string{
+		`["]"]`: `["]`,
+		`[\]]`:  `[\]`,
+	}}})
+
+	q := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchBalanced('[', ']', BalancedOptions{Quotes: []rune{'"'}})
+	})
+
+	runPatternTests(t, []patternTest{{"no escape", q, map[string]string{
+		`["\"]"]`: `["\"]`,
+		`[\]]`:    `[\]`,
+	}}})
+}
+
+// TestMatchBalancedPanics checks that delimiters must differ
+func TestMatchBalancedPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MatchBalanced() with the same delimiters did not panic")
+		}
+	}()
+
+	Compile(func(m Matcher) MatcherOperator {
+		return m.MatchBalanced('|', '|', BalancedOptions{})
+	})
+}
@@ -0,0 +1,112 @@
+package matcher
+
+import (
+	"sort"
+	"unicode"
+)
+
+// runeRange is an inclusive range of runes
+type runeRange struct {
+	lo rune
+	hi rune
+}
+
+// runeRanges is a sorted list of non-overlapping, non-adjacent rune ranges
+type runeRanges []runeRange
+
+// newRuneRanges creates a normalized list from the specified ranges
+func newRuneRanges(ranges []runeRange) runeRanges {
+	rs := append(runeRanges(nil), ranges...)
+
+	sort.Slice(rs, func(i, j int) bool { return rs[i].lo < rs[j].lo })
+
+	out := rs[:0]
+
+	for _, r := range rs {
+		if n := len(out); n > 0 && r.lo <= out[n-1].hi+1 {
+			if r.hi > out[n-1].hi {
+				out[n-1].hi = r.hi
+			}
+		} else {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+// runesToRanges creates a normalized list from a list of runes
+func runesToRanges(match []rune) runeRanges {
+	ranges := make([]runeRange, len(match))
+
+	for i, r := range match {
+		ranges[i] = runeRange{r, r}
+	}
+
+	return newRuneRanges(ranges)
+}
+
+// bytesToRanges creates a normalized list from a list of bytes
+func bytesToRanges(match []byte) runeRanges {
+	ranges := make([]runeRange, len(match))
+
+	for i, b := range match {
+		ranges[i] = runeRange{rune(b), rune(b)}
+	}
+
+	return newRuneRanges(ranges)
+}
+
+// runeRanges::contains
+func (rs runeRanges) contains(r rune) bool {
+	i := sort.Search(len(rs), func(i int) bool { return rs[i].hi >= r })
+
+	return i < len(rs) && rs[i].lo <= r
+}
+
+// runeRanges::negate returns the ranges of all runes not in rs
+func (rs runeRanges) negate() runeRanges {
+	out := runeRanges{}
+
+	next := rune(0)
+
+	for _, r := range rs {
+		if r.lo > next {
+			out = append(out, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+
+	if next <= unicode.MaxRune {
+		out = append(out, runeRange{next, unicode.MaxRune})
+	}
+
+	return out
+}
+
+// runeRanges::intersect returns the ranges of runes in both lists
+func (rs runeRanges) intersect(other runeRanges) runeRanges {
+	out := runeRanges{}
+
+	for i, j := 0, 0; i < len(rs) && j < len(other); {
+		lo, hi := rs[i].lo, rs[i].hi
+
+		if other[j].lo > lo {
+			lo = other[j].lo
+		}
+		if other[j].hi < hi {
+			hi = other[j].hi
+		}
+		if lo <= hi {
+			out = append(out, runeRange{lo, hi})
+		}
+
+		if rs[i].hi < other[j].hi {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,84 @@
+/*
+matcherlex generates a lexer package from a flex-style .mlex specification,
+keeping the token constants, the token names and the lexer.StateFn in sync.
+
+usage:
+
+	matcherlex [-o output.go] [-package name] spec.mlex
+
+or, from a go:generate comment:
+
+	//go:generate matcherlex json.mlex
+
+The generated package contains:
+
+	T_UNKNOWN, T_EOF    The pre-defined lexer tokens
+	T_...               A constant for each token, in order of appearance
+	Tokens              The *matcher.TokenSet naming the tokens
+	TokenTypeString()   Returns the name of a token type, see TokenSet.Name()
+	Rules               The *matcher.Rules table of the lexer
+	StateFn()           Returns a new lexer.StateFn, see matcher.Rules.StateFn()
+
+A .mlex file has a definitions section and a rules section, separated by a
+line containing only '%%'.  Blank lines, and lines starting with '#', are
+ignored.
+
+	# JSON lexer
+	%package jsonlex
+	%token   T_NIL
+
+	DIGIT    [0-9]
+	HEX      [0-9a-fA-F]
+
+	%%
+
+	[ \t\r\n]+                                skip
+	[{]                                       T_OPEN_BRACE
+	[}]                                       T_CLOSE_BRACE
+	-?(0|[1-9]{DIGIT}*)(\.{DIGIT}+)?([eE][-+]?{DIGIT}+)?  T_NUMBER
+	"true"|"false"|"null"                     T_KEYWORD    1
+	[a-zA-Z][a-zA-Z0-9]*                      T_IDENT
+	\"                                        T_OPEN_QUOTE     push(STRING)
+	<STRING>[^\x00-\x1f"\\]+                  T_TEXT
+	<STRING>\\u{HEX}{4}                       T_CHAR_HEX
+	<STRING>\\["\\/bfnrt]                     T_CHAR_ESCAPE
+	<STRING>\"                                T_CLOSE_QUOTE    pop
+
+Definitions:
+
+	%package name       The package name of the generated file
+	%token NAME...      Declares tokens that no rule produces
+	NAME regex          Defines a regex that rules can refer to as {NAME}
+
+Rules:
+
+	[<MODE>]regex (TOKEN | skip) [priority] [push(MODE) | pop | switch(MODE)]
+
+A rule's regex ends at the first space or tab that is not escaped, quoted
+or within a [...] class.  Rules without a <MODE> belong to the default mode,
+which can also be named INITIAL.  As with matcher.Rules, the longest match
+wins, then the highest priority, then the earliest rule.
+
+Regex syntax:
+
+	x                   The rune x
+	"..."               A literal string
+	\n \r \t \f \v \0   Escaped runes, also \xHH, \uHHHH and \<punctuation>
+	.                   Any rune except '\n'
+	[a-z_] [^"\\]       Classes, which may contain [:alpha:], \d, \w and \s
+	\d \w \s \D \W \S   Perl classes
+	{NAME}              A definition
+	xy  x|y  (x)        Sequence, alternation and grouping
+	x? x* x+            Quantifiers
+	x{n} x{n,} x{n,m}   Counted quantifiers
+
+Regexes are translated into matcher expressions, so they have the matcher's
+semantics rather than those of a backtracking regex engine: alternation is
+ordered, i.e. once an alternative matches the rest are never tried, so the
+"ab" of "a|ab" is never tried, and quantifiers are possessive, so "a*a"
+never matches.  Groups, strings and definitions of several runes can be made
+optional, or repeated a bounded number of times, i.e. "(ab){2,4}", but not
+without limit: "(...)*", "(...)+" and "(...){n,}" are rejected with an
+error.  A count of zero, i.e. "x{0}", is also rejected.
+*/
+package documentation
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// generate generates the Go source of a lexer package from a spec
+func generate(s *spec, file string) ([]byte, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by matcherlex from %s. DO NOT EDIT.\n\n", filepath.Base(file))
+	fmt.Fprintf(&b, "package %s\n\n", s.pkg)
+	b.WriteString("// iNamik imports\nimport (\n\t\"github.com/iNamik/go_lexer\"\n\t\"github.com/iNamik/go_lexer_matcher\"\n)\n\n")
+
+	b.WriteString("// Lexer tokens, starting from the pre-defined EOF token\n")
+	b.WriteString("const (\n")
+	b.WriteString("\tT_UNKNOWN lexer.TokenType = lexer.TokenTypeUnknown\n")
+	b.WriteString("\tT_EOF = lexer.TokenTypeEOF\n")
+	for i, name := range s.tokens {
+		if i == 0 {
+			fmt.Fprintf(&b, "\t%s = lexer.TokenTypeEOF + iota - 1\n", name)
+		} else {
+			fmt.Fprintf(&b, "\t%s\n", name)
+		}
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Tokens names the lexer tokens, in the same order as their constants\n")
+	b.WriteString("var Tokens = matcher.NewTokenSet(\n")
+	for _, name := range s.tokens {
+		fmt.Fprintf(&b, "\t%q,\n", name)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// TokenTypeString returns the name of a token type\n")
+	b.WriteString("func TokenTypeString(t lexer.TokenType) string {\n")
+	b.WriteString("\treturn Tokens.Name(t)\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("// Rules is the rules table of the lexer\n")
+	b.WriteString("var Rules = newRules()\n\n")
+
+	b.WriteString("// StateFn returns a new lexer.StateFn for the lexer.  A new StateFn should\n")
+	b.WriteString("// be created for each lexer.\n")
+	b.WriteString("func StateFn() lexer.StateFn {\n")
+	b.WriteString("\treturn Rules.StateFn()\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("// newRules creates the rules table of the lexer\n")
+	b.WriteString("func newRules() *matcher.Rules {\n")
+	b.WriteString("\trules := matcher.NewRulesWithTokenSet(Tokens)\n")
+	for _, rule := range s.rules {
+		chain := compileRegex(rule.regex)
+
+		fmt.Fprintf(&b, "\n\t// %s\n", rule.source)
+
+		target := "rules"
+		if rule.mode != "" {
+			target = fmt.Sprintf("rules.Mode(%q)", rule.mode)
+		}
+
+		pattern := fmt.Sprintf("matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {\n\t\treturn m.%s\n\t})", chain)
+
+		if rule.token == "" {
+			fmt.Fprintf(&b, "\t%s.Skip(%s, %d)", target, pattern, rule.priority)
+		} else {
+			fmt.Fprintf(&b, "\t%s.Add(%s, %s, %d)", target, pattern, rule.token, rule.priority)
+		}
+
+		switch rule.action {
+		case "push":
+			fmt.Fprintf(&b, ".Push(%q)", rule.target)
+		case "pop":
+			b.WriteString(".Pop()")
+		case "switch":
+			fmt.Fprintf(&b, ".Switch(%q)", rule.target)
+		}
+
+		b.WriteString("\n")
+	}
+	b.WriteString("\n\treturn rules\n")
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
+
+// compileRegex returns the chain of Matcher calls equivalent to a regex, i.e.
+// "MatchOneRune('a').\nAnd().MatchOneRune('b')"
+func compileRegex(n *reNode) string {
+	c := &regexCompiler{}
+
+	c.alts(n.alts)
+
+	return c.chain()
+}
+
+// regexCompiler builds the chain of Matcher calls for a regex
+type regexCompiler struct {
+	calls []string
+}
+
+// regexCompiler::call
+func (c *regexCompiler) call(format string, args ...interface{}) {
+	c.calls = append(c.calls, fmt.Sprintf(format, args...))
+}
+
+// regexCompiler::chain joins the calls, starting a new line at each operator
+func (c *regexCompiler) chain() string {
+	var b strings.Builder
+
+	for i, call := range c.calls {
+		if i > 0 {
+			b.WriteString(".")
+		}
+		if call == "And()" || call == "Or()" {
+			b.WriteString("\n\t\t")
+		}
+		b.WriteString(call)
+	}
+
+	return b.String()
+}
+
+// regexCompiler::alts compiles a list of alternatives, grouping multi-item
+// alternatives so the left-to-right evaluation of And() and Or() gives them
+// regex precedence
+func (c *regexCompiler) alts(alts [][]*reNode) {
+	for i, alt := range alts {
+		if i > 0 {
+			c.call("Or()")
+		}
+
+		grouped := len(alts) > 1 && len(alt) > 1
+		if grouped {
+			c.call("Begin()")
+		}
+
+		for j, item := range alt {
+			if j > 0 {
+				c.call("And()")
+			}
+			c.item(item, len(alts) == 1 || grouped)
+		}
+
+		if grouped {
+			c.call("EndMatchOne()")
+		}
+	}
+}
+
+// regexCompiler::item compiles a quantified atom.  inline is true when the
+// calls of a single-alternative group can be added without Begin() and End().
+func (c *regexCompiler) item(n *reNode, inline bool) {
+	if n.kind == reGroup {
+		c.group(n, inline)
+		return
+	}
+
+	if n.kind == reRune && n.max == 1 {
+		if n.min == 0 {
+			c.call("MatchZeroOrOneRune(%s)", strconv.QuoteRune(n.r))
+		} else {
+			c.call("MatchOneRune(%s)", strconv.QuoteRune(n.r))
+		}
+		return
+	}
+
+	set := n.set
+	if n.kind == reRune {
+		set = fmt.Sprintf("matcher.NewRuneSet(%s)", strconv.QuoteRune(n.r))
+	}
+
+	switch {
+	case n.min == 1 && n.max == 1:
+		c.call("MatchOneSet(%s)", set)
+	case n.min == 0 && n.max == 1:
+		c.call("MatchZeroOrOneSet(%s)", set)
+	case n.min == 0 && n.max < 0:
+		c.call("MatchZeroOrMoreSet(%s)", set)
+	case n.min == 1 && n.max < 0:
+		c.call("MatchOneOrMoreSet(%s)", set)
+	case n.max < 0:
+		// Runs are possessive, so x{m,} is x{m,m} followed by x*
+		if !inline {
+			c.call("Begin()")
+		}
+		c.call("MatchMinMaxSet(%s, %d, %d)", set, n.min, n.min)
+		c.call("And()")
+		c.call("MatchZeroOrMoreSet(%s)", set)
+		if !inline {
+			c.call("EndMatchOne()")
+		}
+	default:
+		c.call("MatchMinMaxSet(%s, %d, %d)", set, n.min, n.max)
+	}
+}
+
+// regexCompiler::group compiles a quantified group.  The Matcher cannot
+// repeat groups, so counted repeats are expanded into copies of the group,
+// i.e. x{1,3} becomes x(x(x)?)?.  The parser rejects unbounded repeats.
+func (c *regexCompiler) group(n *reNode, inline bool) {
+	switch {
+	case n.min == 1 && n.max == 1 && len(n.alts) == 1 && inline:
+		c.alts(n.alts)
+		return
+
+	case n.min == 1 && n.max == 1:
+		c.call("Begin()")
+		c.alts(n.alts)
+		c.call("EndMatchOne()")
+		return
+
+	case n.min == 0 && n.max == 1:
+		c.call("Begin()")
+		c.alts(n.alts)
+		c.call("EndMatchZeroOrOne()")
+		return
+	}
+
+	once := &reNode{kind: reGroup, alts: n.alts, min: 1, max: 1}
+
+	var seq []*reNode
+
+	for i := 0; i < n.min; i++ {
+		seq = append(seq, once)
+	}
+
+	var optional *reNode
+
+	for i := n.min; i < n.max; i++ {
+		inner := []*reNode{once}
+		if optional != nil {
+			inner = append(inner, optional)
+		}
+		optional = &reNode{kind: reGroup, alts: [][]*reNode{inner}, min: 0, max: 1}
+	}
+
+	if optional != nil {
+		seq = append(seq, optional)
+	}
+
+	c.group(&reNode{kind: reGroup, alts: [][]*reNode{seq}, min: 1, max: 1}, inline)
+}
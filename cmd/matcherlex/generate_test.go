@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the .golden files of testdata")
+
+// TestGenerate compares the source generated for each testdata/*.mlex spec
+// with its .golden file, and checks that the source compiles
+func TestGenerate(t *testing.T) {
+	specs, err := filepath.Glob(filepath.Join("testdata", "*.mlex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range specs {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := parseSpec(file, f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+
+		src, err := generate(s, file)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+
+		golden := file[:len(file)-len(".mlex")] + ".golden"
+
+		if *update {
+			if err := ioutil.WriteFile(golden, src, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(src, want) {
+			t.Errorf("%s: generated source differs from %s, run go test -update to update it", file, golden)
+		}
+
+		typeCheck(t, file, src)
+	}
+}
+
+// typeCheck type-checks generated source, importing its dependencies from
+// source
+func typeCheck(t *testing.T, file string, src []byte) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, filepath.Join(dir, file+".go"), src, 0)
+	if err != nil {
+		t.Errorf("%s: %v", file, err)
+		return
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("%s: generated source does not compile: %v", file, err)
+	}
+}
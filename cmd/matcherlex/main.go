@@ -0,0 +1,74 @@
+package main
+
+// Standard library imports
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	output := flag.String("o", "", "output file (default: <spec>.go)")
+	pkg := flag.String("package", "", "package name (default: %package, or the output directory)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: matcherlex [-o output.go] [-package name] spec.mlex\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *output, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "matcherlex: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the lexer for a spec file
+func run(file string, output string, pkg string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := parseSpec(file, f)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = strings.TrimSuffix(file, filepath.Ext(file)) + ".go"
+	}
+
+	if pkg != "" {
+		s.pkg = pkg
+	}
+
+	if s.pkg == "" {
+		abs, err := filepath.Abs(output)
+		if err != nil {
+			return err
+		}
+		s.pkg = filepath.Base(filepath.Dir(abs))
+	}
+
+	if !isIdent(s.pkg) {
+		return fmt.Errorf("bad package name %q", s.pkg)
+	}
+
+	src, err := generate(s, file)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(output, src, 0644)
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// reKind identifies the type of a regex node
+type reKind int
+
+const (
+	reRune reKind = iota
+	reSet
+	reGroup
+)
+
+// reNode is a quantified atom of a parsed regex.  Sets hold the Go
+// expression that builds their *matcher.RuneSet.  max < 0 means no limit.
+type reNode struct {
+	kind reKind
+	r    rune
+	set  string
+	alts [][]*reNode
+	min  int
+	max  int
+}
+
+// regexParser parses the regex syntax accepted in .mlex files
+type regexParser struct {
+	src  string
+	pos  int
+	defs map[string]*reNode
+}
+
+// parseRegex parses a regex, returning it as a group
+func parseRegex(src string, defs map[string]*reNode) (*reNode, error) {
+	p := &regexParser{src: src, defs: defs}
+
+	alts, err := p.alts()
+
+	if err == nil && p.pos < len(p.src) {
+		err = p.errorf("unexpected '%c'", p.src[p.pos])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reNode{kind: reGroup, alts: alts, min: 1, max: 1}, nil
+}
+
+// regexParser::errorf
+func (p *regexParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("regex %s: %s", strconv.Quote(p.src), fmt.Sprintf(format, args...))
+}
+
+// regexParser::more
+func (p *regexParser) more() bool {
+	return p.pos < len(p.src)
+}
+
+// regexParser::peek
+func (p *regexParser) peek() rune {
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return r
+}
+
+// regexParser::next
+func (p *regexParser) next() rune {
+	r, w := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += w
+	return r
+}
+
+// regexParser::alts parses alternatives up to the end of the regex or a ')'
+func (p *regexParser) alts() ([][]*reNode, error) {
+	var alts [][]*reNode
+
+	for {
+		var seq []*reNode
+
+		for p.more() && p.peek() != '|' && p.peek() != ')' {
+			n, err := p.item()
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, n)
+		}
+
+		if seq == nil {
+			return nil, p.errorf("empty alternative")
+		}
+
+		alts = append(alts, seq)
+
+		if !p.more() || p.peek() != '|' {
+			return alts, nil
+		}
+
+		p.next() // Consume '|'
+	}
+}
+
+// regexParser::item parses an atom and its quantifier
+func (p *regexParser) item() (*reNode, error) {
+	n, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+
+	n.min, n.max = 1, 1
+
+	if !p.more() {
+		return n, nil
+	}
+
+	switch p.peek() {
+	case '?':
+		p.next()
+		n.min, n.max = 0, 1
+	case '*':
+		p.next()
+		n.min, n.max = 0, -1
+	case '+':
+		p.next()
+		n.min, n.max = 1, -1
+	case '{':
+		if p.pos+1 < len(p.src) && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+			if n.min, n.max, err = p.counts(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The Matcher cannot repeat groups, see regexCompiler::group
+	if n.kind == reGroup && n.max < 0 {
+		return nil, p.errorf("a group, string or definition of several runes cannot be repeated without limit, use a count such as {0,8}")
+	}
+
+	return n, nil
+}
+
+// regexParser::counts parses {m}, {m,} or {m,n}
+func (p *regexParser) counts() (int, int, error) {
+	end := strings.IndexByte(p.src[p.pos:], '}')
+	if end < 0 {
+		return 0, 0, p.errorf("missing '}'")
+	}
+
+	body := p.src[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+
+	lo, hi := body, body
+	if i := strings.IndexByte(body, ','); i >= 0 {
+		lo, hi = body[:i], body[i+1:]
+	}
+
+	min, err := strconv.Atoi(lo)
+	if err != nil {
+		return 0, 0, p.errorf("bad repeat count {%s}", body)
+	}
+
+	if hi == "" {
+		return min, -1, nil
+	}
+
+	max, err := strconv.Atoi(hi)
+	if err != nil || max < min || max == 0 {
+		return 0, 0, p.errorf("bad repeat count {%s}", body)
+	}
+
+	return min, max, nil
+}
+
+// regexParser::atom
+func (p *regexParser) atom() (*reNode, error) {
+	switch r := p.next(); r {
+
+	case '(':
+		alts, err := p.alts()
+		if err != nil {
+			return nil, err
+		}
+		if !p.more() || p.next() != ')' {
+			return nil, p.errorf("missing ')'")
+		}
+		return &reNode{kind: reGroup, alts: alts}, nil
+
+	case '[':
+		return p.class()
+
+	case '"':
+		return p.quoted()
+
+	case '.':
+		return &reNode{kind: reSet, set: `matcher.NewRuneSet('\n').Complement()`}, nil
+
+	case '{':
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return nil, p.errorf("missing '}'")
+		}
+		name := p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+		def, ok := p.defs[name]
+		if !ok {
+			return nil, p.errorf("undefined name {%s}", name)
+		}
+		if len(def.alts) == 1 && len(def.alts[0]) == 1 && def.alts[0][0].min == 1 && def.alts[0][0].max == 1 {
+			def = def.alts[0][0] // Unwrap single atoms, so they can be quantified directly
+		}
+		atom := *def
+		return &atom, nil
+
+	case '\\':
+		if class, ok := p.perlClass(); ok {
+			return &reNode{kind: reSet, set: class}, nil
+		}
+		r, err := p.escape()
+		if err != nil {
+			return nil, err
+		}
+		return &reNode{kind: reRune, r: r}, nil
+
+	case '?', '*', '+':
+		return nil, p.errorf("missing operand for '%c'", r)
+
+	default:
+		return &reNode{kind: reRune, r: r}, nil
+	}
+}
+
+// regexParser::quoted parses a literal "string", after the opening quote
+func (p *regexParser) quoted() (*reNode, error) {
+	var seq []*reNode
+
+	for {
+		if !p.more() {
+			return nil, p.errorf("missing closing '\"'")
+		}
+
+		r := p.next()
+
+		if r == '"' {
+			break
+		}
+
+		if r == '\\' {
+			var err error
+			if r, err = p.escape(); err != nil {
+				return nil, err
+			}
+		}
+
+		seq = append(seq, &reNode{kind: reRune, r: r, min: 1, max: 1})
+	}
+
+	switch len(seq) {
+	case 0:
+		return nil, p.errorf("empty string")
+	case 1:
+		return seq[0], nil
+	}
+
+	return &reNode{kind: reGroup, alts: [][]*reNode{seq}}, nil
+}
+
+// regexParser::perlClass parses \d, \w, \s and their negations, after the '\'
+func (p *regexParser) perlClass() (string, bool) {
+	if !p.more() || !strings.ContainsRune("dDwWsS", p.peek()) {
+		return "", false
+	}
+
+	return fmt.Sprintf("matcher.NewRuneSetFromClass(`\\%c`)", p.next()), true
+}
+
+// regexParser::escape parses an escaped rune, after the '\'
+func (p *regexParser) escape() (rune, error) {
+	if !p.more() {
+		return 0, p.errorf("trailing '\\'")
+	}
+
+	switch r := p.next(); r {
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case 'f':
+		return '\f', nil
+	case 'v':
+		return '\v', nil
+	case '0':
+		return 0, nil
+	case 'x':
+		return p.hex(2)
+	case 'u':
+		return p.hex(4)
+	default:
+		return r, nil
+	}
+}
+
+// regexParser::hex parses a rune from n hex digits
+func (p *regexParser) hex(n int) (rune, error) {
+	if p.pos+n > len(p.src) {
+		return 0, p.errorf("short hex escape")
+	}
+
+	v, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+	if err != nil {
+		return 0, p.errorf("bad hex escape %q", p.src[p.pos:p.pos+n])
+	}
+
+	p.pos += n
+
+	return rune(v), nil
+}
+
+// regexParser::class parses a [...] class, after the '['
+func (p *regexParser) class() (*reNode, error) {
+	var ranges [][2]rune
+	var classes []string
+
+	negate := p.more() && p.peek() == '^'
+	if negate {
+		p.next()
+	}
+
+	for first := true; ; first = false {
+		if !p.more() {
+			return nil, p.errorf("missing ']'")
+		}
+
+		if p.peek() == ']' && !first {
+			p.next()
+			break
+		}
+
+		// POSIX class, i.e. [:alpha:]
+		if strings.HasPrefix(p.src[p.pos:], "[:") {
+			if end := strings.Index(p.src[p.pos:], ":]"); end > 0 {
+				classes = append(classes, fmt.Sprintf("matcher.NewRuneSetFromClass(%q)", p.src[p.pos:p.pos+end+2]))
+				p.pos += end + 2
+				continue
+			}
+		}
+
+		lo := p.next()
+
+		if lo == '\\' {
+			if class, ok := p.perlClass(); ok {
+				classes = append(classes, class)
+				continue
+			}
+			var err error
+			if lo, err = p.escape(); err != nil {
+				return nil, err
+			}
+		}
+
+		hi := lo
+
+		if strings.HasPrefix(p.src[p.pos:], "-") && !strings.HasPrefix(p.src[p.pos:], "-]") {
+			p.next() // Consume '-'
+			hi = p.next()
+			if hi == '\\' {
+				var err error
+				if hi, err = p.escape(); err != nil {
+					return nil, err
+				}
+			}
+			if hi < lo {
+				return nil, p.errorf("bad range %c-%c", lo, hi)
+			}
+		}
+
+		ranges = append(ranges, [2]rune{lo, hi})
+	}
+
+	if !negate && classes == nil && len(ranges) == 1 && ranges[0][0] == ranges[0][1] {
+		return &reNode{kind: reRune, r: ranges[0][0]}, nil
+	}
+
+	var set string
+
+	if ranges != nil {
+		set = fmt.Sprintf("matcher.NewRuneSetFromRangeString(%s)", strconv.Quote(rangeString(ranges)))
+	}
+
+	for _, class := range classes {
+		if set == "" {
+			set = class
+		} else {
+			set += ".Union(" + class + ")"
+		}
+	}
+
+	if negate {
+		set += ".Complement()"
+	}
+
+	return &reNode{kind: reSet, set: set}, nil
+}
+
+// rangeString formats ranges using the syntax of
+// matcher.NewRuneSetFromRangeString.  A single rune is written on its own,
+// unless it is a '-' or is followed by one, in which case it is written as
+// a range of itself.
+func rangeString(ranges [][2]rune) string {
+	var s []rune
+
+	for i, r := range ranges {
+		nextIsDash := i+1 < len(ranges) && ranges[i+1][0] == '-'
+
+		if r[0] == r[1] && r[0] != '-' && !nextIsDash {
+			s = append(s, r[0])
+		} else {
+			s = append(s, r[0], '-', r[1])
+		}
+	}
+
+	return string(s)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseRegex checks the Matcher calls each regex compiles to
+func TestParseRegex(t *testing.T) {
+	digit, err := parseRegex("[0-9]", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := parseRegex("ab", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defs := map[string]*reNode{"DIGIT": digit, "PAIR": pair}
+
+	tests := []struct {
+		regex string
+		chain string
+	}{
+		{`a`, `MatchOneRune('a')`},
+		{`ab`, `MatchOneRune('a').And().MatchOneRune('b')`},
+		{`a?`, `MatchZeroOrOneRune('a')`},
+		{`a*`, `MatchZeroOrMoreSet(matcher.NewRuneSet('a'))`},
+		{`a+`, `MatchOneOrMoreSet(matcher.NewRuneSet('a'))`},
+		{`a{2}`, `MatchMinMaxSet(matcher.NewRuneSet('a'), 2, 2)`},
+		{`a{2,3}`, `MatchMinMaxSet(matcher.NewRuneSet('a'), 2, 3)`},
+		{`a{2,}`, `MatchMinMaxSet(matcher.NewRuneSet('a'), 2, 2).And().MatchZeroOrMoreSet(matcher.NewRuneSet('a'))`},
+		{`x{2,}|y`, `Begin().MatchMinMaxSet(matcher.NewRuneSet('x'), 2, 2).And().MatchZeroOrMoreSet(matcher.NewRuneSet('x')).EndMatchOne().Or().MatchOneRune('y')`},
+		{`a|b`, `MatchOneRune('a').Or().MatchOneRune('b')`},
+		{`ab|c`, `Begin().MatchOneRune('a').And().MatchOneRune('b').EndMatchOne().Or().MatchOneRune('c')`},
+		{`(ab)?`, `Begin().MatchOneRune('a').And().MatchOneRune('b').EndMatchZeroOrOne()`},
+		{`(ab)`, `MatchOneRune('a').And().MatchOneRune('b')`},
+		{`(a|b)c`, `Begin().MatchOneRune('a').Or().MatchOneRune('b').EndMatchOne().And().MatchOneRune('c')`},
+		{`(a){1,2}`, `MatchOneRune('a').And().Begin().MatchOneRune('a').EndMatchZeroOrOne()`},
+		{`"if"`, `MatchOneRune('i').And().MatchOneRune('f')`},
+		{`"\""`, `MatchOneRune('"')`},
+		{`.`, `MatchOneSet(matcher.NewRuneSet('\n').Complement())`},
+		{`[a-z_]`, `MatchOneSet(matcher.NewRuneSetFromRangeString("a-z_"))`},
+		{`[^"\\]`, `MatchOneSet(matcher.NewRuneSetFromRangeString("\"\\").Complement())`},
+		{`[-a]`, `MatchOneSet(matcher.NewRuneSetFromRangeString("---a"))`},
+		{`[x]`, `MatchOneRune('x')`},
+		{`[[:alpha:]\d]`, "MatchOneSet(matcher.NewRuneSetFromClass(\"[:alpha:]\").Union(matcher.NewRuneSetFromClass(`\\d`)))"},
+		{`\w`, "MatchOneSet(matcher.NewRuneSetFromClass(`\\w`))"},
+		{`\t\x41\u00e9\.`, `MatchOneRune('\t').And().MatchOneRune('A').And().MatchOneRune('é').And().MatchOneRune('.')`},
+		{`{DIGIT}+`, `MatchOneOrMoreSet(matcher.NewRuneSetFromRangeString("0-9"))`},
+		{`{PAIR}?`, `Begin().MatchOneRune('a').And().MatchOneRune('b').EndMatchZeroOrOne()`},
+	}
+
+	for _, test := range tests {
+		n, err := parseRegex(test.regex, defs)
+		if err != nil {
+			t.Errorf("%s: %v", test.regex, err)
+			continue
+		}
+
+		chain := strings.Replace(compileRegex(n), "\n\t\t", "", -1)
+
+		if chain != test.chain {
+			t.Errorf("%s:\n got %s\nwant %s", test.regex, chain, test.chain)
+		}
+	}
+}
+
+// TestParseRegexErrors checks the errors of malformed or unsupported regexes
+func TestParseRegexErrors(t *testing.T) {
+	pair, err := parseRegex("ab", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defs := map[string]*reNode{"PAIR": pair}
+
+	tests := []struct {
+		regex string
+		err   string
+	}{
+		{`a|`, `empty alternative`},
+		{`()`, `empty alternative`},
+		{`(a`, `missing ')'`},
+		{`a)`, `unexpected ')'`},
+		{`*a`, `missing operand for '*'`},
+		{`[a-z`, `missing ']'`},
+		{`[z-a]`, `bad range z-a`},
+		{`"ab`, `missing closing '"'`},
+		{`""`, `empty string`},
+		{`a\`, `trailing '\'`},
+		{`\x4`, `short hex escape`},
+		{`\xzz`, `bad hex escape "zz"`},
+		{`a{2`, `missing '}'`},
+		{`a{3,2}`, `bad repeat count {3,2}`},
+		{`a{0}`, `bad repeat count {0}`},
+		{`a{1,x}`, `bad repeat count {1,x}`},
+		{`{NONE}`, `undefined name {NONE}`},
+		{`(ab)*`, `cannot be repeated without limit`},
+		{`(ab)+`, `cannot be repeated without limit`},
+		{`(ab){2,}`, `cannot be repeated without limit`},
+		{`"ab"*`, `cannot be repeated without limit`},
+		{`{PAIR}+`, `cannot be repeated without limit`},
+	}
+
+	for _, test := range tests {
+		_, err := parseRegex(test.regex, defs)
+
+		switch {
+		case err == nil:
+			t.Errorf("%s: no error, want %q", test.regex, test.err)
+		case !strings.HasPrefix(err.Error(), "regex "+`"`):
+			t.Errorf("%s: error %q does not name the regex", test.regex, err)
+		case !strings.Contains(err.Error(), test.err):
+			t.Errorf("%s: error %q, want %q", test.regex, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialMode is the name used in .mlex files for the default mode
+const initialMode = "INITIAL"
+
+// spec is a parsed .mlex file
+type spec struct {
+	pkg    string
+	tokens []string
+	rules  []*specRule
+}
+
+// specRule is a single rule of a .mlex file.  Skip rules have no token.
+type specRule struct {
+	line     int
+	mode     string
+	source   string
+	regex    *reNode
+	token    string
+	priority int
+	action   string
+	target   string
+}
+
+// specError is an error at a line of a .mlex file
+type specError struct {
+	file string
+	line int
+	err  error
+}
+
+// specError::Error
+func (e *specError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.file, e.line, e.err)
+}
+
+// parseSpec parses a .mlex file
+func parseSpec(file string, r io.Reader) (*spec, error) {
+	s := &spec{}
+
+	defs := make(map[string]*reNode)
+
+	seen := make(map[string]bool)
+
+	addToken := func(name string) {
+		if !seen[name] && name != "T_UNKNOWN" && name != "T_EOF" {
+			seen[name] = true
+			s.tokens = append(s.tokens, name)
+		}
+	}
+
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		var err error
+
+		switch {
+		case text == "%%":
+			if inRules {
+				err = fmt.Errorf("unexpected %%%%")
+			}
+			inRules = true
+
+		case inRules:
+			var rule *specRule
+			if rule, err = parseRule(text, defs); err == nil {
+				rule.line = line
+				if rule.token != "" {
+					addToken(rule.token)
+				}
+				s.rules = append(s.rules, rule)
+			}
+
+		case text[0] == '%':
+			fields := strings.Fields(text)
+			switch {
+			case fields[0] == "%package" && len(fields) == 2:
+				s.pkg = fields[1]
+			case fields[0] == "%token" && len(fields) > 1:
+				for _, name := range fields[1:] {
+					if !isIdent(name) {
+						err = fmt.Errorf("bad token name %q", name)
+						break
+					}
+					addToken(name)
+				}
+			default:
+				err = fmt.Errorf("bad directive %q", text)
+			}
+
+		default:
+			name, source := splitField(text)
+			if !isIdent(name) || source == "" {
+				err = fmt.Errorf("bad definition %q", text)
+				break
+			}
+			defs[name], err = parseRegex(source, defs)
+		}
+
+		if err != nil {
+			return nil, &specError{file, line, err}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !inRules {
+		return nil, fmt.Errorf("%s: missing %%%% before the rules", file)
+	}
+
+	return s, s.check(file)
+}
+
+// parseRule parses a rule, i.e. <MODE>regex TOKEN 10 push(MODE)
+func parseRule(text string, defs map[string]*reNode) (*specRule, error) {
+	rule := &specRule{}
+
+	if text[0] == '<' {
+		end := strings.IndexByte(text, '>')
+		if end < 0 || !isIdent(text[1:end]) {
+			return nil, fmt.Errorf("bad mode in %q", text)
+		}
+		rule.mode, text = text[1:end], text[end+1:]
+		if rule.mode == initialMode {
+			rule.mode = ""
+		}
+	}
+
+	source, rest := splitRegex(text)
+
+	fields := strings.Fields(rest)
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing token for %q", source)
+	}
+
+	if fields[0] != "skip" {
+		if !isIdent(fields[0]) {
+			return nil, fmt.Errorf("bad token name %q", fields[0])
+		}
+		rule.token = fields[0]
+	}
+
+	fields = fields[1:]
+
+	if len(fields) > 0 {
+		if priority, err := strconv.Atoi(fields[0]); err == nil {
+			rule.priority = priority
+			fields = fields[1:]
+		}
+	}
+
+	if len(fields) > 0 {
+		if err := rule.parseAction(fields[0]); err != nil {
+			return nil, err
+		}
+		fields = fields[1:]
+	}
+
+	if len(fields) > 0 {
+		return nil, fmt.Errorf("unexpected %q", fields[0])
+	}
+
+	var err error
+
+	rule.source = source
+
+	rule.regex, err = parseRegex(source, defs)
+
+	return rule, err
+}
+
+// specRule::parseAction parses push(MODE), pop or switch(MODE)
+func (rule *specRule) parseAction(action string) error {
+	if action == "pop" {
+		rule.action = action
+		return nil
+	}
+
+	open := strings.IndexByte(action, '(')
+
+	if open < 0 || !strings.HasSuffix(action, ")") {
+		return fmt.Errorf("bad action %q", action)
+	}
+
+	rule.action, rule.target = action[:open], action[open+1:len(action)-1]
+
+	if (rule.action != "push" && rule.action != "switch") || !isIdent(rule.target) {
+		return fmt.Errorf("bad action %q", action)
+	}
+
+	if rule.target == initialMode {
+		rule.target = ""
+	}
+
+	return nil
+}
+
+// spec::check verifies that every mode named by an action has rules
+func (s *spec) check(file string) error {
+	modes := map[string]bool{"": true}
+
+	for _, rule := range s.rules {
+		modes[rule.mode] = true
+	}
+
+	for _, rule := range s.rules {
+		if rule.action != "" && rule.action != "pop" && !modes[rule.target] {
+			return &specError{file, rule.line, fmt.Errorf("mode %s has no rules", rule.target)}
+		}
+	}
+
+	return nil
+}
+
+// splitField splits off the first whitespace-separated field
+func splitField(text string) (string, string) {
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		return text, ""
+	}
+
+	return text[:i], strings.TrimSpace(text[i:])
+}
+
+// splitRegex splits off a leading regex, which ends at the first whitespace
+// that is not escaped, quoted or within a [...] class
+func splitRegex(text string) (string, string) {
+	inClass, inQuote := false, false
+
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\\':
+			i++
+		case c == '"' && !inClass:
+			inQuote = !inQuote
+		case c == '[' && inClass && strings.HasPrefix(text[i:], "[:"):
+			if end := strings.Index(text[i:], ":]"); end > 0 {
+				i += end + 1
+			}
+		case c == '[' && !inQuote:
+			inClass = true
+			if strings.HasPrefix(text[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(text[i+1:], "]") {
+				i++ // A leading ']' is part of the class
+			}
+		case c == ']' && !inQuote:
+			inClass = false
+		case (c == ' ' || c == '\t') && !inClass && !inQuote:
+			return text[:i], text[i:]
+		}
+	}
+
+	return text, ""
+}
+
+// isIdent returns true if name is a valid Go identifier
+func isIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return true
+}
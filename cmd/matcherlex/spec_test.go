@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// formatRule formats the parsed fields of a rule
+func formatRule(rule *specRule) string {
+	return fmt.Sprintf("%d <%s>%s %s %d %s(%s)", rule.line, rule.mode, rule.source, rule.token, rule.priority, rule.action, rule.target)
+}
+
+// TestParseSpec checks the directives, definitions and rules of a spec
+func TestParseSpec(t *testing.T) {
+	src := `# comment
+%package lex
+%token T_NIL T_EOF T_NIL
+
+DIGIT [0-9]
+
+%%
+
+[ \t]+               skip
+{DIGIT}+             T_NUMBER
+"if"                 T_IF      1
+[a-z]+               T_IDENT   push(INNER)
+<INNER>[^ \]]+       T_TEXT    -2 switch(OTHER)
+<OTHER>"a b"         T_TEXT    pop
+<INITIAL>\           T_SPACE   switch(INITIAL)
+`
+
+	s, err := parseSpec("test.mlex", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.pkg != "lex" {
+		t.Errorf("package %q, want lex", s.pkg)
+	}
+
+	if tokens, want := strings.Join(s.tokens, " "), "T_NIL T_NUMBER T_IF T_IDENT T_TEXT T_SPACE"; tokens != want {
+		t.Errorf("tokens %q, want %q", tokens, want)
+	}
+
+	want := []string{
+		`9 <>[ \t]+  0 ()`,
+		`10 <>{DIGIT}+ T_NUMBER 0 ()`,
+		`11 <>"if" T_IF 1 ()`,
+		`12 <>[a-z]+ T_IDENT 0 push(INNER)`,
+		`13 <INNER>[^ \]]+ T_TEXT -2 switch(OTHER)`,
+		`14 <OTHER>"a b" T_TEXT 0 pop()`,
+		`15 <>\  T_SPACE 0 switch()`,
+	}
+
+	if len(s.rules) != len(want) {
+		t.Fatalf("%d rules, want %d", len(s.rules), len(want))
+	}
+
+	for i, rule := range s.rules {
+		if got := formatRule(rule); got != want[i] {
+			t.Errorf("rule %d:\n got %s\nwant %s", i, got, want[i])
+		}
+	}
+}
+
+// TestParseSpecErrors checks that errors name the file and line
+func TestParseSpecErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"a T_A\n", `test.mlex: missing %% before the rules`},
+		{"%%\n%%\n", `test.mlex:2: unexpected %%`},
+		{"%bogus\n%%\n", `test.mlex:1: bad directive "%bogus"`},
+		{"%package\n%%\n", `test.mlex:1: bad directive "%package"`},
+		{"%token 1A\n%%\n", `test.mlex:1: bad token name "1A"`},
+		{"DIGIT\n%%\n", `test.mlex:1: bad definition "DIGIT"`},
+		{"D (a\n%%\n", `test.mlex:1: regex "(a": missing ')'`},
+		{"%%\na\n", `test.mlex:2: missing token for "a"`},
+		{"%%\na 1A\n", `test.mlex:2: bad token name "1A"`},
+		{"%%\n<1>a T_A\n", `test.mlex:2: bad mode in "<1>a T_A"`},
+		{"%%\na T_A push(\n", `test.mlex:2: bad action "push("`},
+		{"%%\na T_A jump(X)\n", `test.mlex:2: bad action "jump(X)"`},
+		{"%%\na T_A pop x\n", `test.mlex:2: unexpected "x"`},
+		{"%%\n{X} T_A\n", `test.mlex:2: regex "{X}": undefined name {X}`},
+		{"%%\n(ab)* T_A\n", `test.mlex:2: regex "(ab)*": a group, string or definition of several runes cannot be repeated without limit`},
+		{"%%\na T_A\nb T_B push(X)\n", `test.mlex:3: mode X has no rules`},
+	}
+
+	for _, test := range tests {
+		_, err := parseSpec("test.mlex", strings.NewReader(test.src))
+
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%q: error %v, want %q", test.src, err, test.err)
+		}
+	}
+}
+
+// TestSplitRegex checks where the regex of a rule ends
+func TestSplitRegex(t *testing.T) {
+	tests := []struct {
+		text  string
+		regex string
+	}{
+		{`a T_A`, `a`},
+		{`a	T_A`, `a`},
+		{`\  T_A`, `\ `},
+		{`"a b" T_A`, `"a b"`},
+		{`[ ] T_A`, `[ ]`},
+		{`[] ] T_A`, `[] ]`},
+		{`[^] ] T_A`, `[^] ]`},
+		{`[[:space:] ] T_A`, `[[:space:] ]`},
+		{`["] T_A`, `["]`},
+		{`a`, `a`},
+	}
+
+	for _, test := range tests {
+		if regex, _ := splitRegex(test.text); regex != test.regex {
+			t.Errorf("%q: regex %q, want %q", test.text, regex, test.regex)
+		}
+	}
+}
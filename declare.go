@@ -0,0 +1,169 @@
+package matcher
+
+import (
+	"unicode/utf8"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// Declare returns a Pattern, known by name, whose expression is recorded
+// later by Define, so that Patterns can refer to themselves and to each
+// other, as the rules of a grammar do.  Expressions refer to a declared
+// Pattern by matching it with MatchPattern, or as the item of a MatchList,
+// before or after it is defined.  Matching a declared Pattern that is not
+// defined yet panics.
+func Declare(name string) *Pattern {
+	if name == "" {
+		panic("Declared Pattern name is empty")
+	}
+
+	p := &Pattern{name: name}
+
+	p.root = p.ref()
+
+	return p
+}
+
+// Define records the expression of a declared Pattern, built by fn, as
+// Compile does.  Declared Patterns never backtrack, and are never lowered to
+// an automaton, as they may refer to themselves.
+func (p *Pattern) Define(fn func(PatternMatcher) PatternOperator) {
+	switch {
+	case p.name == "":
+		panic("Calling Define() on a Pattern that is not declared")
+	case p.root.kind != nodeRef:
+		panic("Calling Define() on Pattern " + p.name + ", which is already defined")
+	}
+
+	root := record(fn)
+
+	if hasLazy(root) {
+		panic("Pattern has a Lazy() operand, which a declared Pattern does not support")
+	}
+
+	p.root = root
+}
+
+// Pattern::ref returns a node referring to the pattern, if it is declared, or
+// else its root
+func (p *Pattern) ref() *node {
+	if p.name == "" {
+		return p.root
+	}
+
+	return &node{kind: nodeRef, ref: p}
+}
+
+// executor::matchRef matches the root of the declared Pattern of a ref node.
+// If failures are tracked, a ref failing without any of the refs it contains
+// failing is recorded as a failure, at the position it was matched from.
+func (e *executor) matchRef(n *node) bool {
+	if n.ref.root.kind == nodeRef {
+		panic("Matching Pattern " + n.ref.name + " before it is defined")
+	}
+
+	if e.fails == nil {
+		return e.matchNode(n.ref.root)
+	}
+
+	count, pos := e.fails.count, e.pos
+
+	if e.matchNode(n.ref.root) {
+		return true
+	}
+
+	if e.fails.count == count {
+		e.fails.add(n.ref.name, pos)
+	}
+
+	return false
+}
+
+// refFailures are the failures of the declared Patterns of a match, count
+// being the number of failures, and names the names of the Patterns that
+// failed at the farthest offset.
+type refFailures struct {
+	count  int
+	offset int
+	names  []string
+}
+
+// refFailures::add records the failure of a declared Pattern at an offset,
+// keeping those that failed at the farthest offset
+func (f *refFailures) add(name string, offset int) {
+	f.count++
+
+	switch {
+	case len(f.names) == 0 || offset > f.offset:
+		f.offset = offset
+		f.names = []string{name}
+
+	case offset == f.offset:
+		for _, x := range f.names {
+			if x == name {
+				return
+			}
+		}
+		f.names = append(f.names, name)
+	}
+}
+
+// Capture is the text captured by a named group, as offsets within the input
+type Capture struct {
+	Name  string // The name given to BeginCapture
+	Start int    // The offset of the first byte of the text
+	End   int    // The offset following the last byte of the text
+}
+
+// MatchCaptures matches the pattern against the start of b, returning the
+// offset following the match, and the captures of the match, in the order
+// their groups ended, so that the captures of a group come before that of
+// the group containing it.
+//
+// Returns a *MatchError if the pattern does not match, giving the farthest
+// position at which a declared Pattern failed without any of the declared
+// Patterns it contains failing, along with the names of those that failed
+// there, i.e. the literals and tokens of a grammar.  If no declared Pattern
+// failed, the pattern itself is reported as expected at the start of b.
+func (p *Pattern) MatchCaptures(b []byte) (int, []Capture, error) {
+	e := &executor{src: b, caps: []capture{}, fails: &refFailures{}}
+
+	if !e.match(p.root) {
+		if len(e.fails.names) == 0 {
+			return 0, nil, p.searchError(b)
+		}
+
+		return 0, nil, failureError(b, e.fails)
+	}
+
+	caps := make([]Capture, len(e.caps))
+
+	for i, c := range e.caps {
+		caps[i] = Capture{Name: c.name, Start: c.start, End: c.end}
+	}
+
+	return e.pos, caps, nil
+}
+
+// failureError describes the farthest failures of the declared Patterns of a
+// match against b
+func failureError(b []byte, f *refFailures) *MatchError {
+	pos := Pos{Offset: f.offset, Line: 1, Column: 1}
+
+	for _, r := range string(b[:f.offset]) {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+
+	r, _ := utf8.DecodeRune(b[f.offset:])
+
+	if f.offset == len(b) {
+		r = lexer.RuneEOF
+	}
+
+	return &MatchError{Pos: pos, Expected: f.names, Found: describeRune(r)}
+}
@@ -0,0 +1,120 @@
+package matcher
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Balanced parentheses, as a declared Pattern that refers to itself as the
+// items of a list separated by nothing
+var patternParens = func() *Pattern {
+	p := Declare("Parens")
+
+	nothing := Compile(func(m Matcher) MatcherOperator { return m.MatchZeroOrOneRunes(nil) })
+
+	p.Define(func(m PatternMatcher) PatternOperator {
+		return m.MatchOneRune('(').And().MatchList(p, nothing, ListOptions{}).And().MatchOneRune(')')
+	})
+
+	return p
+}()
+
+// TestDeclare checks that a declared Pattern can refer to itself, and how it
+// is written
+func TestDeclare(t *testing.T) {
+	tests := map[string]string{"()": "()", "(()())x": "(()())", "x(())": "(())", "(()": "()", "(": "!"}
+
+	for src, want := range tests {
+		got := "!"
+		if b := patternParens.Find([]byte(src)); b != nil {
+			got = string(b)
+		}
+
+		if got != want {
+			t.Errorf("%q: found %q, want %q", src, got, want)
+		}
+	}
+
+	p := Compile(func(m PatternMatcher) PatternOperator {
+		return m.MatchPattern(patternParens).And().MatchEOF()
+	})
+
+	if got, want := p.String(), `(?&Parens)\z`; got != want {
+		t.Errorf("String() is %s, want %s", got, want)
+	}
+}
+
+// TestDeclarePanics checks the panics of declaring, defining and matching
+// declared Patterns
+func TestDeclarePanics(t *testing.T) {
+	tests := map[string]func(){
+		"Declared Pattern name is empty": func() { Declare("") },
+		"Calling Define() on a Pattern that is not declared": func() {
+			p := Compile(func(m Matcher) MatcherOperator { return m.MatchOneRune('(') })
+			p.Define(func(m PatternMatcher) PatternOperator { return m.MatchOneRune(')') })
+		},
+		"Calling Define() on Pattern Parens, which is already defined": func() {
+			patternParens.Define(func(m PatternMatcher) PatternOperator { return m.MatchOneRune(')') })
+		},
+		"Matching Pattern Undefined before it is defined": func() {
+			Declare("Undefined").Find([]byte("x"))
+		},
+		"Pattern has a declared Pattern, which CompileLinear does not support": func() {
+			CompileLinear(func(m PatternMatcher) PatternOperator { return m.MatchPattern(patternParens) })
+		},
+	}
+
+	for want, fn := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != want {
+					t.Errorf("panicked with %v, want %q", r, want)
+				}
+			}()
+
+			fn()
+		}()
+	}
+}
+
+// TestMatchCaptures checks the captures of MatchCaptures, in the order their
+// groups end, and the errors giving the farthest failures of declared
+// Patterns
+func TestMatchCaptures(t *testing.T) {
+	digits := Declare("Digits")
+	digits.Define(func(m PatternMatcher) PatternOperator {
+		return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9"))
+	})
+
+	comma := Declare(`","`)
+	comma.Define(func(m PatternMatcher) PatternOperator { return m.MatchOneRune(',') })
+
+	p := Compile(func(m PatternMatcher) PatternOperator {
+		return m.BeginCapture("pair").
+			BeginCapture("a").MatchPattern(digits).EndMatchOne().And().MatchPattern(comma).And().
+			BeginCapture("b").MatchPattern(digits).EndMatchOne().
+			EndMatchOne()
+	})
+
+	n, caps, err := p.MatchCaptures([]byte("12,3x"))
+	if got, want := fmt.Sprint(n, caps, err), "4 [{a 0 2} {b 3 4} {pair 0 4}] <nil>"; got != want {
+		t.Errorf("matched %s, want %s", got, want)
+	}
+
+	tests := map[string]string{
+		"12,": `1:4: expected Digits, found EOF`,
+		"12;": `1:3: expected ",", found ';'`,
+		"x":   `1:1: expected Digits, found 'x'`,
+	}
+
+	for src, want := range tests {
+		if _, _, err := p.MatchCaptures([]byte(src)); err == nil || err.Error() != want {
+			t.Errorf("%q: %v, want %s", src, err, want)
+		}
+	}
+
+	// Without declared Patterns, the pattern itself is expected
+	if _, _, err := listItem.MatchCaptures([]byte("x")); err == nil || err.Error() != `1:1: expected [0-9]+, found 'x'` {
+		t.Errorf("without declared Patterns: %v", err)
+	}
+}
@@ -0,0 +1,363 @@
+package matcher
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// dfaMaxPositions limits the size of the automaton built for a Pattern.
+// Larger patterns (usually from big MinMax counts) are left to the executor.
+const dfaMaxPositions = 1024
+
+// dfa is a deterministic automaton for a Pattern.  Runes are mapped onto
+// equivalence classes, using a byte-level table for runes below 256, and the
+// transition table is indexed by state * classes + class.
+type dfa struct {
+	byteClass [256]int32
+	cuts      []rune
+	classes   int
+	trans     []int32
+	accept    []bool
+}
+
+// newDFA lowers the pattern to a dfa, returning nil if the pattern uses
+// primitives the automaton cannot express, or if the pattern would need to
+// backtrack to decide between two of its steps
+func newDFA(root *node) *dfa {
+	g := &glushkov{follow: [][]int{nil}, classes: []runeRanges{nil}}
+
+	e, ok := g.node(root)
+
+	if !ok || len(g.classes) > dfaMaxPositions {
+		return nil
+	}
+
+	g.follow[0] = e.first
+
+	// Partition the runes into classes that every position agrees on
+	cutSet := make(map[rune]bool)
+
+	for _, class := range g.classes {
+		for _, r := range class {
+			cutSet[r.lo] = true
+			cutSet[r.hi+1] = true
+		}
+	}
+
+	d := &dfa{}
+
+	for r := range cutSet {
+		d.cuts = append(d.cuts, r)
+	}
+
+	sort.Slice(d.cuts, func(i, j int) bool { return d.cuts[i] < d.cuts[j] })
+
+	d.classes = len(d.cuts) + 1
+
+	for b := 0; b < 256; b++ {
+		d.byteClass[b] = d.search(rune(b))
+	}
+
+	// Build the transitions, rejecting any state that can move to two
+	// different positions on the same class
+	states := len(g.classes)
+
+	d.trans = make([]int32, states*d.classes)
+
+	for i := range d.trans {
+		d.trans[i] = -1
+	}
+
+	for s := 0; s < states; s++ {
+		for _, p := range g.follow[s] {
+			for c := 0; c < d.classes; c++ {
+				if !g.classes[p].contains(d.classRune(c)) {
+					continue
+				}
+
+				if t := d.trans[s*d.classes+c]; t >= 0 && int(t) != p {
+					return nil
+				}
+
+				d.trans[s*d.classes+c] = int32(p)
+			}
+		}
+	}
+
+	d.accept = make([]bool, states)
+
+	d.accept[0] = e.nullable
+
+	for _, p := range e.last {
+		d.accept[p] = true
+	}
+
+	return d
+}
+
+// dfa::search returns the class of a rune using the cut points
+func (d *dfa) search(r rune) int32 {
+	return int32(sort.Search(len(d.cuts), func(i int) bool { return d.cuts[i] > r }))
+}
+
+// dfa::class returns the class of a rune
+func (d *dfa) class(r rune) int32 {
+	if r >= 0 && r < 256 {
+		return d.byteClass[r]
+	}
+
+	return d.search(r)
+}
+
+// dfa::classRune returns the first rune of a class
+func (d *dfa) classRune(c int) rune {
+	if c == 0 {
+		return 0
+	}
+
+	return d.cuts[c-1]
+}
+
+// dfa::match runs the automaton forward until it stops, keeping a single
+// marker at the start of the match, and returns the number of runes in the
+// longest accepted prefix
+func (d *dfa) match(l lexer.Lexer) (int, bool) {
+	marker := l.Marker()
+
+	state, n, last := int32(0), 0, -1
+
+	if d.accept[0] {
+		last = 0
+	}
+
+	for {
+		r := l.PeekRune(0)
+
+		if r == lexer.RuneEOF {
+			break
+		}
+
+		next := d.trans[state*int32(d.classes)+d.class(r)]
+
+		if next < 0 {
+			break
+		}
+
+		l.NextRune()
+
+		state = next
+
+		n++
+
+		if d.accept[state] {
+			last = n
+		}
+	}
+
+	if last < 0 {
+		l.Reset(marker)
+		return 0, false
+	}
+
+	// Give back any runes read past the last accepting state
+	if last < n {
+		l.Reset(marker)
+
+		for i := 0; i < last; i++ {
+			l.NextRune()
+		}
+	}
+
+	return last, true
+}
+
+// dfa::matchBytes runs the automaton forward over src, starting at offset
+// pos, and returns the offset following the longest accepted prefix
+func (d *dfa) matchBytes(src []byte, pos int) (int, bool) {
+	state, last := int32(0), -1
+
+	if d.accept[0] {
+		last = pos
+	}
+
+	for pos < len(src) {
+		r, w := rune(src[pos]), 1
+
+		if r >= utf8.RuneSelf {
+			r, w = utf8.DecodeRune(src[pos:])
+		}
+
+		next := d.trans[state*int32(d.classes)+d.class(r)]
+
+		if next < 0 {
+			break
+		}
+
+		state = next
+
+		pos += w
+
+		if d.accept[state] {
+			last = pos
+		}
+	}
+
+	return last, last >= 0
+}
+
+/*****************************************************************************
+ * Glushkov construction
+ *****************************************************************************/
+
+// glushkov builds a position automaton for a Pattern.  Each position is a
+// single rune class; position 0 is the start state.
+type glushkov struct {
+	classes []runeRanges
+	follow  [][]int
+}
+
+// gexpr describes a sub-expression of the automaton
+type gexpr struct {
+	nullable bool
+	first    []int
+	last     []int
+}
+
+// glushkov::position adds a new position for the class
+func (g *glushkov) position(class runeRanges) gexpr {
+	p := len(g.classes)
+
+	g.classes = append(g.classes, class)
+
+	g.follow = append(g.follow, nil)
+
+	return gexpr{false, []int{p}, []int{p}}
+}
+
+// glushkov::seq
+func (g *glushkov) seq(a gexpr, b gexpr) gexpr {
+	for _, p := range a.last {
+		g.follow[p] = append(g.follow[p], b.first...)
+	}
+
+	first := a.first
+	if a.nullable {
+		first = append(append([]int(nil), a.first...), b.first...)
+	}
+
+	last := b.last
+	if b.nullable {
+		last = append(append([]int(nil), a.last...), b.last...)
+	}
+
+	return gexpr{a.nullable && b.nullable, first, last}
+}
+
+// glushkov::alt
+func (g *glushkov) alt(a gexpr, b gexpr) gexpr {
+	return gexpr{
+		a.nullable || b.nullable,
+		append(append([]int(nil), a.first...), b.first...),
+		append(append([]int(nil), a.last...), b.last...),
+	}
+}
+
+// glushkov::star
+func (g *glushkov) star(a gexpr) gexpr {
+	for _, p := range a.last {
+		g.follow[p] = append(g.follow[p], a.first...)
+	}
+
+	return gexpr{true, a.first, a.last}
+}
+
+// glushkov::node builds the expression for a Pattern node
+func (g *glushkov) node(n *node) (gexpr, bool) {
+	if len(g.classes) > dfaMaxPositions {
+		return gexpr{}, false
+	}
+
+	// The automaton cannot express a skipper, which is possessive
+	if n.skip != nil {
+		return gexpr{}, false
+	}
+
+	switch n.kind {
+
+	case nodeClass:
+		class := n.class.ranges
+		if n.negate {
+			class = class.negate()
+		}
+
+		e := gexpr{nullable: true}
+
+		for i := 0; i < n.min; i++ {
+			e = g.seq(e, g.position(class))
+		}
+
+		// Optional runs nest, x{1,3} being x(x(x)?)?, so that each
+		// step only ever has one position to move to
+		switch {
+		case n.max < 0:
+			e = g.seq(e, g.star(g.position(class)))
+
+		case n.max > n.min:
+			opt := gexpr{nullable: true}
+
+			for i := n.min; i < n.max; i++ {
+				p := g.position(class)
+				opt = g.seq(p, opt)
+				opt.nullable = true
+			}
+
+			e = g.seq(e, opt)
+		}
+
+		return e, true
+
+	case nodeGroup:
+		if n.backtrack {
+			return gexpr{}, false
+		}
+
+		var e gexpr
+
+		for i, t := range n.terms {
+			// A Matcher never tries the next alternative once an operand
+			// that always succeeds has been matched
+			if t.op == opOr && e.nullable {
+				continue
+			}
+
+			te, ok := g.node(t.node)
+
+			// A cut stops alternatives from being tried, which the
+			// automaton cannot express
+			if !ok || t.cut {
+				return gexpr{}, false
+			}
+
+			switch {
+			case i == 0:
+				e = te
+			case t.op == opAnd:
+				e = g.seq(e, te)
+			default:
+				e = g.alt(e, te)
+			}
+		}
+
+		if n.optional {
+			e.nullable = true
+		}
+
+		return e, true
+	}
+
+	// Func primitives, EOF, anchors, and backreference, until, balanced and
+	// list nodes need the executor
+	return gexpr{}, false
+}
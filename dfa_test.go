@@ -0,0 +1,84 @@
+package matcher
+
+import (
+	"testing"
+	"unicode"
+)
+
+// TestDFALowering checks which Patterns are lowered to a dfa, and which are
+// left to the executor
+func TestDFALowering(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(Matcher) MatcherOperator
+		dfa  bool
+	}{
+		{"runes", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('a').And().MatchZeroOrMoreRunes([]rune("bc"))
+		}, true},
+		{"sets and groups", func(m Matcher) MatcherOperator {
+			return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).
+				AndBegin().MatchOneRune('=').And().MatchMinMaxBytes([]byte("0123456789"), 1, 3).EndMatchZeroOrOne()
+		}, true},
+		{"Func", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('a').And().MatchOneOrMoreFunc(unicode.IsDigit)
+		}, false},
+		{"EOF", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('a').And().MatchEOF()
+		}, false},
+		{"needs backtracking", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('a').Or().MatchOneRune('a').And().MatchOneRune('b')
+		}, false},
+	}
+
+	for _, test := range tests {
+		if p := Compile(test.fn); (p.dfa != nil) != test.dfa {
+			t.Errorf("%s: lowered to a dfa = %v, want %v", test.name, p.dfa != nil, test.dfa)
+		}
+	}
+}
+
+// TestDFAExecutor checks that a dfa matches as the executor does, against a
+// lexer and a byte slice
+func TestDFAExecutor(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(Matcher) MatcherOperator
+		inputs []string
+	}{
+		{"runs", func(m Matcher) MatcherOperator {
+			return m.MatchZeroOrMoreRunes([]rune("ab")).And().MatchOneRune('c')
+		}, []string{"abc", "c", "abab", "ca", "", "x"}},
+		{"alternatives", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('x').Or().MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).
+				AndBegin().MatchOneRune('.').And().MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).EndMatchZeroOrOne()
+		}, []string{"x1", "12", "1.5", "1.", ".5", "é"}},
+		{"multibyte", func(m Matcher) MatcherOperator {
+			return m.MatchMinMaxRunes([]rune("é€"), 2, 3).And().MatchZeroOrOneRune('😀')
+		}, []string{"éé", "é€é€", "€😀", "éé😀", "é"}},
+	}
+
+	for _, test := range tests {
+		p := Compile(test.fn)
+
+		if p.dfa == nil {
+			t.Fatalf("%s: not lowered to a dfa", test.name)
+		}
+
+		e := &Pattern{root: p.root, first: p.first}
+
+		for _, input := range test.inputs {
+			ok, got := matchString(p, input)
+
+			if wantOk, want := matchString(e, input); ok != wantOk || got != want {
+				t.Errorf("%s on %q: dfa matched %q, %v, executor %q, %v", test.name, input, got, ok, want, wantOk)
+			}
+
+			end, ok := p.matchBytes([]byte(input), 0)
+
+			if wantEnd, wantOk := e.matchBytes([]byte(input), 0); ok != wantOk || ok && end != wantEnd {
+				t.Errorf("%s on %q bytes: dfa matched to %d, %v, executor %d, %v", test.name, input, end, ok, wantEnd, wantOk)
+			}
+		}
+	}
+}
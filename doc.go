@@ -46,5 +46,313 @@ Notice how the regex shown maps directly to Matcher funtion calls.
 		Result() {
 			myLexer.EmitTokenWithBytes(T_NUMBER)
 	}
+
+Expressions can also be compiled into a Pattern, which records the Matcher
+calls once and can then be matched against any number of lexers, or used as an
+operand of another expression with MatchPattern():
+
+	var number = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.
+			MatchZeroOrOneRune('-').
+			And().MatchOneOrMoreBytes(bytesDigits)
+	})
+
+	if number.Match(myLexer) {
+		myLexer.EmitTokenWithBytes(T_NUMBER)
+	}
+
+Patterns can also search a byte slice, as regexp does, with Find, FindIndex,
+FindAll and FindAllIndex.  Each position is tried in turn, skipping positions
+whose first rune cannot start a match:
+
+	for _, loc := range number.FindAllIndex(logBytes, -1) {
+		fmt.Println(string(logBytes[loc[0]:loc[1]]))
+	}
+
+Groups begun with BeginCapture(name) capture the text they match, which
+ReplaceAll expands in its template as $name or ${name}.  Only Patterns
+capture, as a Matcher does not keep the text it consumes.  Captures are
+recorded with a PatternMatcher, which the Compile functions accept in place of
+a Matcher.  It has the operands of a Matcher, along with BeginCapture,
+MatchBackref and Lazy.  ReplaceAllFunc and Split also follow regexp:
+
+	var assign = matcher.Compile(func(m matcher.PatternMatcher) matcher.PatternOperator {
+		return m.
+			BeginCapture("key").MatchOneOrMoreSet(setAlphaNum).EndMatchOne().
+			And().MatchOneRune('=').
+			And().BeginCapture("value").MatchOneOrMoreSet(setAlphaNum).EndMatchOne()
+	})
+
+	fmt.Println(string(assign.ReplaceAll([]byte("a=1 b=2"), []byte("${value}=$key")))) // 1=a 2=b
+
+Unmarshal decodes the captures of the leftmost match into the fields of a
+struct tagged with their names, converting them to the types of the fields.
+Strings, bools, integers, floats, time.Duration, time.Time, with an optional
+layout, and encoding.TextUnmarshalers are converted out of the box, and
+RegisterConverter adds converters for other types.  Converter returns the
+converter of a type, for packages that decode captures of their own, such as
+grammar:
+
+	type Assign struct {
+		Key   string `capture:"key"`
+		Value int    `capture:"value"`
+	}
+
+	var a Assign
+	err := assign.Unmarshal([]byte("a=1"), &a) // Assign{Key: "a", Value: 1}
+
+Patterns made only of byte/rune classes, groups and quantifiers are lowered to a
+deterministic automaton with byte-level transition tables, and matched in a
+single forward pass.  Patterns that use Func primitives or MatchEOF, or whose
+alternatives can only be told apart by trying them, are matched by walking the
+expression exactly as a Matcher would.
+
+A Matcher, and a Pattern compiled with Compile, never backtrack: each operand of
+an expression is tried at most once per attempt, alternatives are tried in order
+and never revisited once one succeeds, and runs (ZeroOrMore, OneOrMore, MinMax)
+consume as much as they can without giving any back.  Automaton-backed Patterns
+match n runes in O(n) time.
+
+Cut() commits a grouping once the operands before it have matched, so that its
+remaining alternatives are not tried when a later operand fails.  Once an
+object's opening brace has matched, for instance, a missing closing brace is
+reported as such, rather than as a failure of the alternatives that follow:
+
+	m.MatchOneRune('{').Cut().And().MatchPattern(members).And().MatchOneRune('}').
+		Or().MatchPattern(scalar)
+
+Patterns compiled with CompileBacktracking backtrack as a regular expression
+does.  When an operand fails, the runs before it give back runes, optional
+groupings are skipped and later alternatives are tried, until the rest of the
+expression matches.  Lazy(), an operator of a PatternMatcher expression, makes
+the preceding run or optional grouping take as little as it can instead.
+Backtracking can take exponential time, so it is best kept to the expressions
+that need it:
+
+	// Regex: [0-9]*0 matches "100", where a Compile()d Pattern would not
+	var tens = matcher.CompileBacktracking(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchZeroOrMoreBytes(bytesDigits).And().MatchOneRune('0')
+	})
+
+End().Atomic() ends a grouping that is never backtracked into once it has
+matched, so that Patterns compiled with CompileBacktracking keep its first
+match.  Possessive() does the same for the preceding run or optional grouping,
+which then matches as much as it can and gives none of it back, as [0-9]*+
+would.  A Pattern compiled with Compile and matched with MatchPattern is not
+backtracked into either, and matches as it would on its own.  Elsewhere nothing
+is backtracked into, so Atomic() behaves as End().MatchOne(), and Possessive()
+has no effect.
+
+Backtracking can take time exponential in the length of the input, which is a
+risk when matching untrusted input.  Patterns compiled with CompileLinear match
+exactly as those compiled with CompileBacktracking do, with the same captures,
+but simulate every way the expression can match at once, Pike VM style, so that
+matching an expression of m operands against n runes takes O(n*m) time, whatever
+the input.  Only regular operands can be simulated, so backreferences,
+MatchUntil, MatchBalanced and MatchList operands, Cut(), atomic groupings,
+skippers, declared Patterns and Patterns compiled with Compile are rejected:
+
+	// Regex: [0-9]*0, in linear time
+	var tens = matcher.CompileLinear(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchZeroOrMoreBytes(bytesDigits).And().MatchOneRune('0')
+	})
+
+MatchBackref(name) matches the text last captured by the named grouping, so
+that a closing delimiter can repeat an opening one, as with Lua's long
+brackets.  A capture that is backtracked over is forgotten, along with its
+text.  Like BeginCapture, MatchBackref is an operand of a PatternMatcher:
+
+	// Regex: \[(?P<eq>=*)\[[\s\S]*?\]\k<eq>\]
+	var longBracket = matcher.CompileBacktracking(func(m matcher.PatternMatcher) matcher.PatternOperator {
+		return m.
+			MatchOneRune('[').
+			And().BeginCapture("eq").MatchZeroOrMoreRunes([]rune{'='}).EndMatchOne().
+			And().MatchOneRune('[').
+			And().NonMatchZeroOrMoreRunes(nil).Lazy().
+			And().MatchOneRune(']').And().MatchBackref("eq").And().MatchOneRune(']')
+	})
+
+Declare returns a Pattern known by name, whose expression Define records later,
+so that Patterns can refer to themselves and to each other, as the rules of a
+grammar do.  MatchPattern refers to a declared Pattern, before or after it is
+defined, which is written (?&name).  MatchCaptures matches a Pattern against the
+start of the input, returning every capture of the match, and describes a
+failure by the declared Patterns that failed farthest into the input, i.e.
+`1:3: expected Parens, found EOF` for "((":
+
+	// Regex: \((?&Parens)*\)
+	var parens = matcher.Declare("Parens")
+
+	parens.Define(func(m matcher.PatternMatcher) matcher.PatternOperator {
+		return m.MatchOneRune('(').And().MatchList(parens, nothing, matcher.ListOptions{}).And().MatchOneRune(')')
+	})
+
+Large character classes are best expressed as a RuneSet, which tests membership
+in constant time instead of searching a list of bytes or runes.  RuneSets can be
+created from runes, bytes, ranges, or rangeutil-style range strings, and are
+accepted by the *Set functions of Matcher:
+
+	var setAlphaNum = matcher.NewRuneSetFromRangeString("0-9a-zA-Z")
+
+	m.MatchOneOrMoreSet(setAlphaNum)
+
+Sets can also be created from unicode.RangeTables, POSIX class names such as
+"[:alpha:]", and Perl shorthands such as `\d`, and combined with Union,
+Intersect, Subtract and Complement:
+
+	// Printable ASCII, except quote and backslash
+	var setText = matcher.NewRuneSetFromClass("[:print:]").Subtract(matcher.NewRuneSet('"', '\\'))
+
+Rather than hand-writing a lexer.StateFn, Patterns can be collected into a
+Rules table that generates one.  At each position every rule is tried and the
+longest match wins, ties going to the rule with the highest priority and then
+to the rule added first.  Runes that no rule matches are emitted as
+lexer.TokenTypeUnknown:
+
+	rules := matcher.NewRules().
+		Skip(whitespace, 0).
+		Add(identifier, T_IDENTIFIER, 0).
+		Add(keywordIf, T_IF, 1). // "if" is also an identifier
+		Add(number, T_NUMBER, 0)
+
+	myLexer := lexer.NewFromBytes(rules.StateFn(), input, 3)
+
+Rules can also be organised into named modes.  Each rule can push, pop or
+switch the active mode once it matches, and only the rules of the active mode
+are tried:
+
+	rules.Add(quote, T_OPEN_QUOTE, 0).Push("STRING")
+
+	rules.Mode("STRING").
+		Add(text, T_TEXT, 0).
+		Add(quote, T_CLOSE_QUOTE, 0).Pop()
+
+Token types can be registered by name with a TokenSet, which assigns them in
+order after lexer.TokenTypeEOF and names them for error messages.  Rules
+share a TokenSet with their modes, and can add rules by token name:
+
+	var tokens = matcher.NewTokenSet()
+
+	var T_NUMBER = tokens.Add("T_NUMBER")
+
+	rules := matcher.NewRulesWithTokenSet(tokens).
+		Add(number, T_NUMBER, 0).
+		AddToken(identifier, "T_IDENTIFIER", 0)
+
+	fmt.Println(tokens.Name(T_NUMBER)) // T_NUMBER
+
+To report positions, create the lexer as an Input, which tracks the byte
+offset, line and column of the lexer as runes are consumed.  State functions
+receive the Input as their lexer, so Matchers created from it report the
+positions of their last successful Result(), and the position of the farthest
+failed operand when a Result() is false:
+
+	input := matcher.NewInputFromBytes(lex, src, 3)
+
+	m := matcher.New(l) // Within a state function
+	if !m.MatchOneRune('x').Result() {
+		fmt.Println(m.Err()) // 3:14: expected 'x', found 'y'
+	}
+
+	t := input.NextToken()
+	fmt.Println(input.TokenStartPos(), input.TokenEndPos()) // 3:10 3:14
+
+A MatchError lists the operands that failed at its position, and FormatError
+renders it as a compiler-style diagnostic, showing the source line with a
+caret under the failing column:
+
+	input.SetFilename("config.txt")
+
+	fmt.Println(matcher.FormatError(src, m.Err()))
+
+	// config.txt:2:10: expected [0-9], found 'e'
+	//	ab = 12.e
+	//	        ^
+
+MatchBOL, MatchEOL, MatchWordBoundary, MatchNotWordBoundary and MatchBOF match
+a position rather than a rune, without consuming anything.  All but MatchEOL
+look at the rune before the current position, which only an Input remembers,
+so they panic when used with other lexers.  Patterns searching a byte slice
+support them everywhere:
+
+	// Regex: (?m:^)#[^\n]*
+	comment := m.MatchBOL().And().MatchOneRune('#').And().NonMatchZeroOrMoreRunes([]rune{'\n'})
+
+MatchUntilString(term, inclusive) matches everything before the first
+occurrence of a string, such as the end of a block comment, and
+MatchUntilUnescaped skips over runes escaped by a given rune, such as those of a
+string literal.  The buffered input of an Input, and a byte slice searched by a
+Pattern, are searched with bytes.Index, while other lexers are scanned a rune at
+a time, taking time linear in the runes before the string.  Nothing is
+consumed unless the string is found.
+MatchUntilPattern stops at the first position at which a Pattern matches
+instead, only trying the Pattern where its first rune can start a match:
+
+	// Regex: \(\*(?s:.*?)\*\)
+	blockComment := m.MatchOneRune('(').And().MatchOneRune('*').And().MatchUntilString("*)", true)
+
+	// Regex: "(?s:\\.|[^\\])*?"
+	text := m.MatchOneRune('"').And().MatchUntilUnescaped(`"`, '\\').And().MatchOneRune('"')
+
+MatchBalanced(open, close, opts) matches a region from an open rune to the
+matching close rune, such as a block of code embedded in a template, skipping
+over nested regions and over the literals delimited by opts.Quotes, within
+which opts.Escape escapes the next rune.  If the region is unbalanced, the lexer
+is reset to where the region began:
+
+	// Matches {a: "}", b: {c}}
+	block := m.MatchBalanced('{', '}', matcher.BalancedOptions{Quotes: []rune{'"', '\''}, Escape: '\\'})
+
+MatchList(item, sep, opts) matches a list of Pattern items separated by Pattern
+separators, such as the arguments of a call, with at least opts.Min and at most
+opts.Max items, 0 meaning no limit.  A separator is given back if no item
+follows it, unless opts.AllowTrailing, and nothing is consumed if there are too
+few items.  The item and separator failures are reported by Err().
+ListItems() returns the Span of each item matched, whose positions are those of
+an Input, or zero with other lexers:
+
+	number := matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator { return m.MatchOneOrMoreRunes(digits) })
+	comma := matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator { return m.MatchOneRune(',') })
+
+	// Regex: \[(?:(?:[0-9]+)(?:(?:,)(?:[0-9]+))*(?:,)?)?\]
+	array := m.MatchOneRune('[').And().MatchList(number, comma, matcher.ListOptions{AllowTrailing: true}).And().MatchOneRune(']')
+
+BeginSkipping(skipper) begins a grouping for free-form text.  Within it,
+matches of the skipper Pattern, such as whitespace and comments, are consumed
+until the skipper matches nothing.  This happens before each operand and nested
+grouping, and before each item and separator of a MatchList.  Lexeme() begins
+a grouping within which nothing is skipped, so that a token, such as a string
+literal, is matched as written.  A Pattern operand is matched as it was
+compiled:
+
+	space := matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchOneOrMoreRunes([]rune(" \t\n")).
+			OrBegin().MatchOneRune('#').And().NonMatchZeroOrMoreRunes([]rune{'\n'}).EndMatchOne()
+	})
+
+	// Matches ( a, b # comment
+	//           "x y" )
+	call := m.BeginSkipping(space).
+		MatchOneRune('(').
+		And().MatchList(ident, comma, matcher.ListOptions{}).
+		And().Lexeme().MatchOneRune('"').And().NonMatchZeroOrMoreRunes([]rune{'"'}).And().MatchOneRune('"').EndMatchOne().
+		And().MatchOneRune(')').
+		EndMatchOne()
+
+Expressions can also match sequences of any element type, such as log records,
+with a SeqMatcher.  Operands are func(T) bool predicates, and the grouping,
+alternation and quantifiers are the same as for runes.  SeqPatterns are
+compiled with CompileSeq:
+
+	type Record struct{ Level string }
+
+	isError := func(r Record) bool { return r.Level == "ERROR" }
+
+	m := matcher.NewSeqMatcher(matcher.NewSeq(records))
+
+	// Regex: [^E]*E+
+	if m.NonMatchZeroOrMoreFunc(isError).And().MatchOneOrMoreFunc(isError).Result() {
+		fmt.Println(m.Items())
+	}
 */
 package matcher
@@ -0,0 +1,93 @@
+package matcher
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// MatchError describes a failed match.  Other failures with a position, such
+// as parse errors, can be reported as MatchErrors too, in order to be
+// formatted by FormatError.
+type MatchError struct {
+	Pos      Pos      // The farthest position at which an operand failed
+	Expected []string // The operands that failed at Pos
+	Found    string   // The rune, or token, found at Pos
+}
+
+// MatchError::Error
+func (e *MatchError) Error() string {
+	var msg string
+
+	switch {
+	case len(e.Expected) == 0 && e.Found == "":
+		msg = "no match"
+	case len(e.Expected) == 0:
+		msg = "unexpected " + e.Found
+	case e.Found == "":
+		msg = "expected " + joinExpected(e.Expected)
+	default:
+		msg = "expected " + joinExpected(e.Expected) + ", found " + e.Found
+	}
+
+	if !e.Pos.IsValid() {
+		return msg
+	}
+
+	return e.Pos.String() + ": " + msg
+}
+
+// joinExpected joins alternatives, i.e. "'a', 'b' or [0-9]"
+func joinExpected(expected []string) string {
+	if len(expected) == 1 {
+		return expected[0]
+	}
+
+	return strings.Join(expected[:len(expected)-1], ", ") + " or " + expected[len(expected)-1]
+}
+
+// FormatError formats an error in the style of a compiler diagnostic.  If err
+// is a *MatchError with a known position, the source line containing the
+// position is shown, with a caret under the failing column:
+//
+//	input.json:3:14: expected T_COMMA or T_CLOSE_BRACE, found T_COLON
+//	    "name": 1 : 2
+//	              ^
+//
+// Other errors are formatted with their Error method, and a nil error as "".
+func FormatError(src []byte, err error) string {
+	e, ok := err.(*MatchError)
+
+	switch {
+	case err == nil, ok && e == nil:
+		return ""
+	case !ok || !e.Pos.IsValid() || e.Pos.Offset > len(src):
+		return err.Error()
+	}
+
+	start := bytes.LastIndexByte(src[:e.Pos.Offset], '\n') + 1
+
+	end := bytes.IndexByte(src[start:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += start
+	}
+
+	line := strings.TrimSuffix(string(src[start:end]), "\r")
+
+	// Indent the caret with the same tabs as the line, so it lines up
+	var caret []byte
+
+	for i, n := start, 1; i < e.Pos.Offset && n < e.Pos.Column; n++ {
+		r, w := utf8.DecodeRune(src[i:])
+		if r == '\t' {
+			caret = append(caret, '\t')
+		} else {
+			caret = append(caret, ' ')
+		}
+		i += w
+	}
+
+	return e.Error() + "\n" + line + "\n" + string(caret) + "^"
+}
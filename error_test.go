@@ -0,0 +1,117 @@
+package matcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// TestMatchError checks the messages of MatchErrors
+func TestMatchError(t *testing.T) {
+	pos := Pos{Offset: 3, Line: 1, Column: 4}
+
+	tests := []struct {
+		err  *MatchError
+		want string
+	}{
+		{&MatchError{}, "no match"},
+		{&MatchError{Pos: pos}, "1:4: no match"},
+		{&MatchError{Pos: pos, Found: "'x'"}, "1:4: unexpected 'x'"},
+		{&MatchError{Pos: pos, Expected: []string{"'a'"}}, "1:4: expected 'a'"},
+		{&MatchError{Pos: pos, Expected: []string{"'a'", "'b'", "[0-9]"}, Found: "EOF"}, "1:4: expected 'a', 'b' or [0-9], found EOF"},
+	}
+
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("Error() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+// TestFormatError checks where the caret is placed, and which line is shown
+func TestFormatError(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		pos  Pos
+		want string
+	}{
+		{"first column", "abc\n", Pos{Offset: 0, Line: 1, Column: 1},
+			"1:1: no match\nabc\n^"},
+		{"middle line", "a\nbcd\ne", Pos{Offset: 4, Line: 2, Column: 3},
+			"2:3: no match\nbcd\n  ^"},
+		{"tabs", "\t\tx = y\n", Pos{Offset: 4, Line: 1, Column: 5},
+			"1:5: no match\n\t\tx = y\n\t\t  ^"},
+		{"multibyte runes", "héllo wörld", Pos{Offset: 8, Line: 1, Column: 8},
+			"1:8: no match\nhéllo wörld\n       ^"},
+		{"carriage return", "ab\r\ncd\r\n", Pos{Offset: 5, Line: 2, Column: 2},
+			"2:2: no match\ncd\n ^"},
+		{"last line", "ab\ncd", Pos{Offset: 4, Line: 2, Column: 2},
+			"2:2: no match\ncd\n ^"},
+		{"end of line", "ab\ncd", Pos{Offset: 2, Line: 1, Column: 3},
+			"1:3: no match\nab\n  ^"},
+		{"EOF", "ab\ncd", Pos{Offset: 5, Line: 2, Column: 3},
+			"2:3: no match\ncd\n  ^"},
+		{"EOF after newline", "ab\n", Pos{Offset: 3, Line: 2, Column: 1},
+			"2:1: no match\n\n^"},
+		{"empty source", "", Pos{Offset: 0, Line: 1, Column: 1},
+			"1:1: no match\n\n^"},
+	}
+
+	for _, test := range tests {
+		if got := FormatError([]byte(test.src), &MatchError{Pos: test.pos}); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestFormatErrorWithoutSource checks the errors that are formatted with
+// their Error method alone, and nil errors
+func TestFormatErrorWithoutSource(t *testing.T) {
+	src := []byte("abc")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"nil MatchError", (*MatchError)(nil), ""},
+		{"other error", errors.New("failed"), "failed"},
+		{"unknown position", &MatchError{Found: "'x'"}, "unexpected 'x'"},
+		{"past the source", &MatchError{Pos: Pos{Offset: 4, Line: 1, Column: 5}}, "1:5: no match"},
+	}
+
+	for _, test := range tests {
+		if got := FormatError(src, test.err); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// TestFormatErrorInput checks the error of a Matcher over an Input
+func TestFormatErrorInput(t *testing.T) {
+	src := []byte("ab = 1\n\tcd = 2x\n")
+
+	var err error
+
+	input := NewInputFromBytes(func(l lexer.Lexer) lexer.StateFn {
+		m := New(l)
+		for m.MatchZeroOrMoreBytes([]byte(" \t\n")).And().MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).
+			And().MatchOneBytes([]byte(" ")).And().MatchOneRune('=').
+			And().MatchOneBytes([]byte(" ")).And().MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).
+			And().MatchOneRune('\n').Result() {
+		}
+		err = m.Err()
+		return nil
+	}, src, 1).SetFilename("config.txt")
+
+	input.NextToken()
+
+	want := "config.txt:2:8: expected '\\n', found 'x'\n\tcd = 2x\n\t      ^"
+
+	if got := FormatError(src, err); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
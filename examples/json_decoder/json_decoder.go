@@ -16,6 +16,7 @@ import (
 	"github.com/iNamik/go_lexer"
 	"github.com/iNamik/go_lexer/rangeutil"
 	"github.com/iNamik/go_lexer_matcher"
+	"github.com/iNamik/go_lexer_matcher/tokenmatcher"
 	"github.com/iNamik/go_parser"
 )
 
@@ -23,32 +24,63 @@ import (
 const (
 	T_UNKNOWN lexer.TokenType = lexer.TokenTypeUnknown
 	T_EOF                     = lexer.TokenTypeEOF
-	T_NIL                     = lexer.TokenTypeEOF + iota
-	T_OPEN_BRACE
-	T_CLOSE_BRACE
-	T_OPEN_BRACKET
-	T_CLOSE_BRACKET
-	T_COLON
-	T_COMMA
-	T_MINUS
-	T_BACK_SLASH
-	T_NUMBER
-	T_UNQUOTED_STRING
-	T_OPEN_QUOTE
-	T_CLOSE_QUOTE
-	T_TEXT
-	T_ESCAPE_CHAR
-	T_CHAR_QUOTE
-	T_CHAR_BACK_SLASH
-	T_CHAR_SLASH
-	T_CHAR_BACK_SPACE
-	T_CHAR_FORM_FEED
-	T_CHAR_LINE_FEED
-	T_CHAR_CARRIAGE_RETURN
-	T_CHAR_TAB
-	T_CHAR_LOWER_U
-	T_CHAR_HEX_WORD
-	T_CHAR_CONTROL
+)
+
+// The remaining tokens are registered by name, which lets us print them
+var tokens = matcher.NewTokenSet()
+
+// The input tracks the positions of the tokens, which we use in error messages
+var input *matcher.Input
+
+// The parser reads tokens ahead of the one it fails at, so the position of
+// each token is recorded as the parser reads it
+var positions = make(map[*lexer.Token]matcher.Pos)
+
+/**
+ * positionLexer records the position of each token read from the input
+ */
+type positionLexer struct {
+	*matcher.Input
+}
+
+/**
+ * positionLexer::NextToken
+ */
+func (l positionLexer) NextToken() *lexer.Token {
+	t := l.Input.NextToken()
+
+	positions[t] = l.TokenStartPos()
+
+	return t
+}
+
+var (
+	T_NIL                  = tokens.Add("T_NIL")
+	T_OPEN_BRACE           = tokens.Add("T_OPEN_BRACE")
+	T_CLOSE_BRACE          = tokens.Add("T_CLOSE_BRACE")
+	T_OPEN_BRACKET         = tokens.Add("T_OPEN_BRACKET")
+	T_CLOSE_BRACKET        = tokens.Add("T_CLOSE_BRACKET")
+	T_COLON                = tokens.Add("T_COLON")
+	T_COMMA                = tokens.Add("T_COMMA")
+	T_MINUS                = tokens.Add("T_MINUS")
+	T_BACK_SLASH           = tokens.Add("T_BACK_SLASH")
+	T_NUMBER               = tokens.Add("T_NUMBER")
+	T_UNQUOTED_STRING      = tokens.Add("T_UNQUOTED_STRING")
+	T_OPEN_QUOTE           = tokens.Add("T_OPEN_QUOTE")
+	T_CLOSE_QUOTE          = tokens.Add("T_CLOSE_QUOTE")
+	T_TEXT                 = tokens.Add("T_TEXT")
+	T_ESCAPE_CHAR          = tokens.Add("T_ESCAPE_CHAR")
+	T_CHAR_QUOTE           = tokens.Add("T_CHAR_QUOTE")
+	T_CHAR_BACK_SLASH      = tokens.Add("T_CHAR_BACK_SLASH")
+	T_CHAR_SLASH           = tokens.Add("T_CHAR_SLASH")
+	T_CHAR_BACK_SPACE      = tokens.Add("T_CHAR_BACK_SPACE")
+	T_CHAR_FORM_FEED       = tokens.Add("T_CHAR_FORM_FEED")
+	T_CHAR_LINE_FEED       = tokens.Add("T_CHAR_LINE_FEED")
+	T_CHAR_CARRIAGE_RETURN = tokens.Add("T_CHAR_CARRIAGE_RETURN")
+	T_CHAR_TAB             = tokens.Add("T_CHAR_TAB")
+	T_CHAR_LOWER_U         = tokens.Add("T_CHAR_LOWER_U")
+	T_CHAR_HEX_WORD        = tokens.Add("T_CHAR_HEX_WORD")
+	T_CHAR_CONTROL         = tokens.Add("T_CHAR_CONTROL")
 )
 
 // Single-character tokens
@@ -63,13 +95,13 @@ var bytesDigits = rangeutil.RangeToBytes("0-9")
 
 var bytes1to9 = rangeutil.RangeToBytes("1-9")
 
-var bytesAlpha = rangeutil.RangeToBytes("a-zA-Z")
+var setAlpha = matcher.NewRuneSetFromRangeString("a-zA-Z")
 
-var bytesAlphaNum = rangeutil.RangeToBytes("0-9a-zA-Z")
+var setAlphaNum = matcher.NewRuneSetFromRangeString("0-9a-zA-Z")
 
 var bytesHex = rangeutil.RangeToBytes("0-9a-fA-F")
 
-var bytesNonText = rangeutil.RangeToBytes("\u0000-\u001f\\\"")
+var setNonText = matcher.NewRuneSetFromRangeString("\u0000-\u001f\\\"")
 
 var escapeChars = []byte{'"', '\\', '/', 'b', 'f', 'n', 'r', 't'}
 
@@ -85,71 +117,7 @@ type jsonValue struct {
  * Used for debugging and error messages.
  */
 func tokenTypeAsString(t lexer.TokenType) string {
-	var typeString string
-
-	switch t {
-	case T_UNKNOWN:
-		typeString = "T_UNKNOWN"
-	case T_EOF:
-		typeString = "T_EOF"
-	case T_NIL:
-		typeString = "T_NIL"
-	case T_OPEN_BRACE:
-		typeString = "T_OPEN_BRACE"
-	case T_CLOSE_BRACE:
-		typeString = "T_CLOSE_BRACE"
-	case T_OPEN_BRACKET:
-		typeString = "T_OPEN_BRACKET"
-	case T_CLOSE_BRACKET:
-		typeString = "T_CLOSE_BRACKET"
-	case T_COLON:
-		typeString = "T_COLON"
-	case T_COMMA:
-		typeString = "T_COMMA"
-	case T_MINUS:
-		typeString = "T_MINUS"
-	case T_BACK_SLASH:
-		typeString = "T_BACK_SLASH"
-	case T_NUMBER:
-		typeString = "T_NUMBER"
-	case T_UNQUOTED_STRING:
-		typeString = "T_UNQUOTED_STRING"
-	case T_OPEN_QUOTE:
-		typeString = "T_OPEN_QUOTE"
-	case T_CLOSE_QUOTE:
-		typeString = "T_CLOSE_QUOTE"
-	case T_TEXT:
-		typeString = "T_TEXT"
-	case T_ESCAPE_CHAR:
-		typeString = "T_ESCAPE_CHAR"
-	case T_CHAR_QUOTE:
-		typeString = "T_CHAR_QUOTE"
-	case T_CHAR_BACK_SLASH:
-		typeString = "T_CHAR_BACK_SLASH"
-	case T_CHAR_SLASH:
-		typeString = "T_CHAR_SLASH"
-	case T_CHAR_BACK_SPACE:
-		typeString = "T_CHAR_BACK_SPACE"
-	case T_CHAR_FORM_FEED:
-		typeString = "T_CHAR_FORM_FEED"
-	case T_CHAR_LINE_FEED:
-		typeString = "T_CHAR_LINE_FEED"
-	case T_CHAR_CARRIAGE_RETURN:
-		typeString = "T_CHAR_CARRIAGE_RETURN"
-	case T_CHAR_TAB:
-		typeString = "T_CHAR_TAB"
-	case T_CHAR_LOWER_U:
-		typeString = "T_CHAR_LOWER_U"
-	case T_CHAR_HEX_WORD:
-		typeString = "T_CHAR_HEX_WORD"
-	case T_CHAR_CONTROL:
-		typeString = "T_CHAR_CONTROL"
-
-	default:
-		typeString = strconv.Itoa(int(t))
-	}
-
-	return typeString
+	return tokens.Name(t)
 }
 
 /**
@@ -168,14 +136,20 @@ func main() {
 		usage()
 	}
 
-	// Create a new lexer to turn the input text into tokens
-	l := lexer.NewFromBytes(lex, jsonBytes, 3)
+	// Create a new lexer to turn the input text into tokens, tracking the
+	// positions of the tokens for error messages
+	input = matcher.NewInputFromBytes(lex, jsonBytes, 3).SetFilename("stdin")
 
 	// Create a new parser that feeds off the lexer and generates expression values
-	p := parser.New(parse, l, 1)
+	p := parser.New(parse, positionLexer{input}, 1)
 
 	value := p.Next() // Pull a JSON value off the parser
 
+	if err := value.(*jsonValue).err; err != nil {
+		fmt.Println(matcher.FormatError(jsonBytes, err))
+		os.Exit(1)
+	}
+
 	fmt.Printf("%v\n", value)
 }
 
@@ -216,7 +190,7 @@ func lex(l lexer.Lexer) lexer.StateFn {
 		return lexQuotedString
 
 		// Unquoted String
-	} else if l.MatchOneBytes(bytesAlpha) && l.MatchZeroOrMoreBytes(bytesAlphaNum) {
+	} else if l.MatchOneFunc(setAlpha.Contains) && l.MatchZeroOrMoreFunc(setAlphaNum.Contains) {
 		l.EmitTokenWithBytes(T_UNQUOTED_STRING)
 
 		// Number:  /-?(0|([1-9][0-9]*))(\.[0-9]+)?([eE][-+]?[0-9]+)?/
@@ -265,7 +239,7 @@ func lexQuotedString(l lexer.Lexer) lexer.StateFn {
 	}
 
 	// Normal text
-	if l.NonMatchOneOrMoreBytes(bytesNonText) {
+	if l.NonMatchOneOrMoreFunc(setNonText.Contains) {
 		l.EmitTokenWithBytes(T_TEXT)
 		return lexQuotedString
 	}
@@ -335,6 +309,28 @@ func parse(p parser.Parser) parser.StateFn {
 	return nil // One-pass only
 }
 
+// The entries of objects and arrays are separated by commas, and may be
+// followed by a trailing comma
+var listOptions = matcher.ListOptions{AllowTrailing: true}
+
+/**
+ * listError returns the error of an object or array whose entries, or closing
+ * token, failed to match.  If the last entry tried failed, its error explains
+ * why the list ended early.  Otherwise, the error of the matcher is returned,
+ * at the position of the token it failed at.
+ */
+func listError(tm tokenmatcher.Matcher, entryErr error) error {
+	if entryErr != nil {
+		return entryErr
+	}
+
+	e := tm.Err().(*tokenmatcher.MatchError)
+
+	e.Pos = positions[e.Token]
+
+	return &e.MatchError
+}
+
 /**
  * parseValue parses a json value.  This function may be called recursively
  * if values contain other values.
@@ -351,67 +347,56 @@ func parseValue(p parser.Parser) (value interface{}, err error) {
 	// Object (map) '{'
 	case T_OPEN_BRACE:
 		valueMap := make(map[string]interface{})
-		for err == nil && p.PeekTokenType(0) != T_CLOSE_BRACE {
+
+		var pairErr error
+
+		pair := func(p parser.Parser) bool {
 			var entryName string
 			var entryValue interface{}
 
-			entryName, entryValue, err = parseNameValuePair(p)
+			entryName, entryValue, pairErr = parseNameValuePair(p)
 
-			if err == nil {
+			if pairErr == nil {
 				valueMap[entryName] = entryValue
-
-				if p.PeekTokenType(0) == T_COMMA {
-					t = p.NextToken() // consume ','
-
-				} else if p.PeekTokenType(0) != T_CLOSE_BRACE {
-					t = p.NextToken()
-
-					err = errors.New(fmt.Sprintf("Expecing T_COMMA OR T_CLOSE_BRACE, found %s", tokenTypeAsString(t.Type())))
-				}
 			}
+
+			return pairErr == nil
 		}
-		if err == nil {
-			t = p.NextToken()
 
-			// Expecting '}'
-			if t.Type() == T_CLOSE_BRACE {
-				value = valueMap
-			} else {
-				err = wrongTokenError(T_CLOSE_BRACE, t.Type())
-			}
+		// Regex: (pair (T_COMMA pair)* T_COMMA?)? T_CLOSE_BRACE
+		tm := tokenmatcher.NewWithTokenSet(p, tokens)
+
+		if tm.MatchList(pair, []lexer.TokenType{T_COMMA}, listOptions).And().MatchOneToken(T_CLOSE_BRACE).Result() {
+			value = valueMap
+		} else {
+			err = listError(tm, pairErr)
 		}
 
 	// Array '['
 	case T_OPEN_BRACKET:
 		valueArray := make([]interface{}, 0)
 
-		for err == nil && p.PeekTokenType(0) != T_CLOSE_BRACKET {
+		var entryErr error
+
+		entry := func(p parser.Parser) bool {
 			var entryValue interface{}
 
-			entryValue, err = parseValue(p)
+			entryValue, entryErr = parseValue(p)
 
-			if err == nil {
+			if entryErr == nil {
 				valueArray = append(valueArray, entryValue)
-
-				if p.PeekTokenType(0) == T_COMMA {
-					t = p.NextToken() // consume ','
-
-				} else if p.PeekTokenType(0) != T_CLOSE_BRACKET {
-					t = p.NextToken()
-
-					err = errors.New(fmt.Sprintf("Expecing T_COMMA OR T_CLOSE_BRACKET, found %s", tokenTypeAsString(t.Type())))
-				}
 			}
+
+			return entryErr == nil
 		}
-		if err == nil {
-			t = p.NextToken()
 
-			// Expecting ']'
-			if t.Type() == T_CLOSE_BRACKET {
-				value = valueArray
-			} else {
-				err = wrongTokenError(T_CLOSE_BRACKET, t.Type())
-			}
+		// Regex: (value (T_COMMA value)* T_COMMA?)? T_CLOSE_BRACKET
+		tm := tokenmatcher.NewWithTokenSet(p, tokens)
+
+		if tm.MatchList(entry, []lexer.TokenType{T_COMMA}, listOptions).And().MatchOneToken(T_CLOSE_BRACKET).Result() {
+			value = valueArray
+		} else {
+			err = listError(tm, entryErr)
 		}
 
 	// null | true | false
@@ -425,7 +410,11 @@ func parseValue(p parser.Parser) (value interface{}, err error) {
 			value = false
 
 		default:
-			err = errors.New(fmt.Sprintf("Unknown literal: '%s'", tString))
+			err = &matcher.MatchError{
+				Pos:      positions[t],
+				Expected: []string{"null", "true", "false"},
+				Found:    fmt.Sprintf("'%s'", tString),
+			}
 		}
 
 	// number
@@ -443,7 +432,7 @@ func parseValue(p parser.Parser) (value interface{}, err error) {
 		value, err = parseQuotedString(p)
 
 	default:
-		err = unexpectedTokenError(t.Type())
+		err = unexpectedTokenError(t)
 	}
 
 	if err != nil {
@@ -517,14 +506,14 @@ func parseQuotedString(p parser.Parser) (value string, err error) {
 						buffer.Write(hexBytes)
 					}
 				} else {
-					err = wrongTokenError(T_CHAR_HEX_WORD, t.Type())
+					err = wrongTokenError(T_CHAR_HEX_WORD, t)
 				}
 
 			default:
-				err = unexpectedTokenError(t.Type())
+				err = unexpectedTokenError(t)
 			}
 		default:
-			err = unexpectedTokenError(t.Type())
+			err = unexpectedTokenError(t)
 		}
 	}
 
@@ -535,7 +524,7 @@ func parseQuotedString(p parser.Parser) (value string, err error) {
 		if t.Type() == T_CLOSE_QUOTE {
 			value = buffer.String()
 		} else {
-			err = wrongTokenError(T_CLOSE_QUOTE, t.Type())
+			err = wrongTokenError(T_CLOSE_QUOTE, t)
 		}
 	}
 
@@ -550,10 +539,9 @@ func parseNameValuePair(p parser.Parser) (name string, value interface{}, err er
 
 	err = nil
 
-	if p.PeekTokenType(0) == T_UNQUOTED_STRING && p.PeekTokenType(1) == T_COLON {
-		name = string(p.NextToken().Bytes())
-
-		p.NextToken() // Ignore ':'
+	// Regex: T_UNQUOTED_STRING T_COLON
+	if tm := tokenmatcher.New(p); tm.MatchOneToken(T_UNQUOTED_STRING).And().MatchOneToken(T_COLON).Result() {
+		name = string(tm.Tokens()[0].Bytes())
 
 		value, err = parseValue(p)
 	} else if p.PeekTokenType(0) == T_OPEN_QUOTE {
@@ -567,9 +555,11 @@ func parseNameValuePair(p parser.Parser) (name string, value interface{}, err er
 			if t.Type() == T_COLON {
 				value, err = parseValue(p)
 			} else {
-				err = wrongTokenError(T_COLON, t.Type())
+				err = wrongTokenError(T_COLON, t)
 			}
 		}
+	} else {
+		err = tokenError([]lexer.TokenType{T_UNQUOTED_STRING, T_OPEN_QUOTE}, p.PeekToken(0))
 	}
 
 	if err != nil {
@@ -584,13 +574,27 @@ func parseNameValuePair(p parser.Parser) (name string, value interface{}, err er
 /**
  * wrongTokenError
  */
-func wrongTokenError(expected, actual lexer.TokenType) error {
-	return errors.New(fmt.Sprintf("Expecting %s, found %s", tokenTypeAsString(expected), tokenTypeAsString(actual)))
+func wrongTokenError(expected lexer.TokenType, actual *lexer.Token) error {
+	return tokenError([]lexer.TokenType{expected}, actual)
 }
 
 /**
  * unexpectedTokenError
  */
-func unexpectedTokenError(t lexer.TokenType) error {
-	return errors.New(fmt.Sprintf("Unexpected token: '%s'", tokenTypeAsString(t)))
+func unexpectedTokenError(t *lexer.Token) error {
+	return &matcher.MatchError{Pos: positions[t], Found: tokenTypeAsString(t.Type())}
+}
+
+/**
+ * tokenError returns an error at the position of the token found instead of
+ * the expected ones
+ */
+func tokenError(expected []lexer.TokenType, actual *lexer.Token) error {
+	names := make([]string, len(expected))
+
+	for i, t := range expected {
+		names[i] = tokenTypeAsString(t)
+	}
+
+	return &matcher.MatchError{Pos: positions[actual], Expected: names, Found: tokenTypeAsString(actual.Type())}
 }
@@ -0,0 +1,693 @@
+package matcher
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// fuzzLetters are the runes generated expressions are built from.  Each letter
+// is used at most once per expression, so every decision the matcher makes is
+// deterministic and its result is directly comparable to regexp's.
+const fuzzLetters = "abcdefghijkl"
+
+// fuzzInputLetters are the runes generated inputs are built from
+const fuzzInputLetters = fuzzLetters + "z \n"
+
+// fuzzTokenMatch is emitted with the consumed bytes once a match completes
+const fuzzTokenMatch lexer.TokenType = lexer.TokenTypeEOF + 1
+
+// fuzzNode is a node of a generated expression.  Class nodes (set != nil)
+// match a quantified set of runes, group nodes match alternatives of
+// sequences.
+type fuzzNode struct {
+	set      []rune
+	min      int
+	max      int // max < 0 means unbounded
+	flavour  int // selects which Matcher call is used for the node
+	alts     [][]*fuzzNode
+	optional bool
+	eof      bool
+	capture  string
+	anchor   int // anchors the top-level expression, see fuzzAnchors
+	eol      bool
+	lazy     bool
+}
+
+// fuzzAnchors are the anchors a top-level expression can start with, and
+// their regexp syntax.  Anchors are only generated where regexp never has to
+// backtrack to satisfy them.
+var fuzzAnchors = []string{"", "(?m:^)", `\A`, `\b`, `\B`}
+
+// fuzzMatcher is the part of the Matcher and PatternMatcher interfaces that
+// generated expressions are built with, so that the same expression can be
+// matched by a Matcher and recorded by a PatternMatcher
+type fuzzMatcher[M any, O any, E any] interface {
+	MatchOneBytes([]byte) O
+	MatchOneRunes([]rune) O
+	MatchOneRune(rune) O
+	MatchOneFunc(lexer.MatchFn) O
+	MatchOneSet(*RuneSet) O
+	MatchZeroOrOneBytes([]byte) O
+	MatchZeroOrOneRunes([]rune) O
+	MatchZeroOrOneRune(rune) O
+	MatchZeroOrOneFunc(lexer.MatchFn) O
+	MatchZeroOrOneSet(*RuneSet) O
+	MatchZeroOrMoreBytes([]byte) O
+	MatchZeroOrMoreRunes([]rune) O
+	MatchZeroOrMoreFunc(lexer.MatchFn) O
+	MatchZeroOrMoreSet(*RuneSet) O
+	MatchOneOrMoreBytes([]byte) O
+	MatchOneOrMoreRunes([]rune) O
+	MatchOneOrMoreFunc(lexer.MatchFn) O
+	MatchOneOrMoreSet(*RuneSet) O
+	MatchMinMaxBytes([]byte, int, int) O
+	MatchMinMaxRunes([]rune, int, int) O
+	MatchMinMaxFunc(lexer.MatchFn, int, int) O
+	MatchMinMaxSet(*RuneSet, int, int) O
+	MatchEOF() O
+	MatchEOL() O
+	MatchBOL() O
+	MatchBOF() O
+	MatchWordBoundary() O
+	MatchNotWordBoundary() O
+	Begin() M
+}
+
+// fuzzOperator is the part of the MatcherOperator and PatternOperator
+// interfaces that generated expressions are built with
+type fuzzOperator[M any, O any, E any] interface {
+	And() M
+	Or() M
+	AndBegin() M
+	End() E
+	EndMatchOne() O
+	EndMatchZeroOrOne() O
+}
+
+// fuzzEnd is the part of the MatcherEnd and PatternEnd interfaces that
+// generated expressions are built with
+type fuzzEnd[O any] interface {
+	MatchOne() O
+	MatchZeroOrOne() O
+}
+
+// fuzzGen builds expressions from the fuzzer's bytes
+type fuzzGen struct {
+	data     []byte
+	letters  []rune
+	captures []string
+	reuse    bool
+}
+
+// fuzzGen::next returns the next choice in [0, n)
+func (g *fuzzGen) next(n int) int {
+	if len(g.data) == 0 {
+		return 0
+	}
+	b := g.data[0]
+	g.data = g.data[1:]
+	return int(b) % n
+}
+
+// fuzzGen::class generates a quantified class, returning nil once all
+// letters have been used.  required classes never match the empty string.
+func (g *fuzzGen) class(required bool) *fuzzNode {
+	if len(g.letters) == 0 {
+		return nil
+	}
+
+	k := 1 + g.next(3)
+	if k > len(g.letters) {
+		k = len(g.letters)
+	}
+
+	n := &fuzzNode{set: g.letters[:k:k], flavour: g.next(5)}
+	g.letters = g.letters[k:]
+
+	if g.reuse {
+		// Letters are drawn from a few, so that alternatives and runs overlap
+		n.set = nil
+		for i := 0; i < k; i++ {
+			if r := rune(fuzzLetters[g.next(3)]); !strings.ContainsRune(string(n.set), r) {
+				n.set = append(n.set, r)
+			}
+		}
+		g.letters = []rune(fuzzLetters)
+	}
+
+	switch g.next(5) {
+	case 0:
+		n.min, n.max = 1, 1
+	case 1:
+		n.min, n.max = 0, 1
+	case 2:
+		n.min, n.max = 0, -1
+	case 3:
+		n.min, n.max = 1, -1
+	default:
+		n.min = g.next(3)
+		n.max = n.min + g.next(3)
+	}
+
+	if required && n.min == 0 {
+		n.min = 1
+	}
+	if n.max >= 0 && n.max < n.min {
+		n.max = n.min
+	}
+	if n.max == 0 {
+		n.max = 1
+	}
+	if g.reuse && n.min != n.max {
+		n.lazy = g.next(3) == 0
+	}
+
+	return n
+}
+
+// fuzzGen::alts generates a list of alternatives.  Only the last alternative
+// may match the empty string, as the matcher never tries another alternative
+// once one has succeeded.
+func (g *fuzzGen) alts(depth int) [][]*fuzzNode {
+	var alts [][]*fuzzNode
+
+	for i, nAlts := 0, 1+g.next(3); i < nAlts; i++ {
+		var seq []*fuzzNode
+
+		for j, nItems := 0, 1+g.next(3); j < nItems; j++ {
+			var n *fuzzNode
+
+			if j > 0 && depth < 2 && g.next(4) == 0 {
+				n = &fuzzNode{flavour: g.next(2), optional: g.next(2) == 0}
+				n.lazy = g.reuse && n.optional && g.next(3) == 0
+				if g.next(2) == 0 {
+					n.capture = fmt.Sprintf("g%d", len(g.captures))
+					g.captures = append(g.captures, n.capture)
+				}
+				n.alts = g.alts(depth + 1)
+				if n.alts == nil {
+					n = nil
+				}
+			} else {
+				n = g.class(j == 0 && i < nAlts-1)
+			}
+
+			if n == nil {
+				break
+			}
+
+			seq = append(seq, n)
+		}
+
+		if seq == nil {
+			break
+		}
+
+		alts = append(alts, seq)
+	}
+
+	return alts
+}
+
+// newFuzzExpr generates an expression from the specified bytes, returning
+// the names of its captures.  If reuse is true, letters are reused, so that
+// the expression can only be matched by backtracking.
+func newFuzzExpr(data []byte, reuse bool) (*fuzzNode, []string) {
+	g := &fuzzGen{data: data, letters: []rune(fuzzLetters), reuse: reuse}
+
+	n := &fuzzNode{eof: g.next(4) == 0, anchor: g.next(len(fuzzAnchors) + 1)}
+	n.alts = g.alts(0)
+
+	if n.anchor == len(fuzzAnchors) {
+		n.anchor = 0
+	}
+	if !n.eof {
+		n.eol = g.next(4) == 0
+	}
+
+	return n, g.captures
+}
+
+// fuzzNode::regexp returns the regular expression equivalent to the node
+func (n *fuzzNode) regexp() string {
+	if n.set != nil {
+		s := "[" + string(n.set) + "]"
+		switch {
+		case n.min == 1 && n.max == 1:
+		case n.min == 0 && n.max == 1:
+			s += "?"
+		case n.min == 0 && n.max < 0:
+			s += "*"
+		case n.min == 1 && n.max < 0:
+			s += "+"
+		default:
+			s += fmt.Sprintf("{%d,%d}", n.min, n.max)
+		}
+		if n.lazy {
+			s += "?"
+		}
+		return s
+	}
+
+	alts := make([]string, len(n.alts))
+	for i, alt := range n.alts {
+		for _, item := range alt {
+			alts[i] += item.regexp()
+		}
+	}
+
+	s := "(?:" + strings.Join(alts, "|") + ")"
+	if n.capture != "" {
+		s = "(?P<" + n.capture + ">" + strings.Join(alts, "|") + ")"
+	}
+	if n.optional {
+		s += "?"
+	}
+	if n.lazy {
+		s += "?"
+	}
+	if n.eof {
+		s += `\z`
+	}
+	if n.eol {
+		s += `(?m:$)`
+	}
+
+	return fuzzAnchors[n.anchor] + s
+}
+
+// matchFuzzNode applies the node to the matcher.  Lazy runs and captures are
+// only recorded by a PatternMatcher: a Matcher matches a capture as a group.
+func matchFuzzNode[M fuzzMatcher[M, O, E], O fuzzOperator[M, O, E], E fuzzEnd[O]](n *fuzzNode, m M) O {
+	if n.lazy {
+		eager := *n
+		eager.lazy = false
+		return any(any(matchFuzzNode(&eager, m)).(PatternOperator).Lazy()).(O)
+	}
+
+	if n.set == nil {
+		if pm, ok := any(m).(PatternMatcher); ok && n.capture != "" {
+			m = any(pm.BeginCapture(n.capture)).(M)
+		} else {
+			m = m.Begin()
+		}
+		op := matchFuzzAlts[M, O, E](m, n.alts)
+		switch {
+		case n.optional && n.flavour == 0:
+			return op.EndMatchZeroOrOne()
+		case n.optional:
+			return op.End().MatchZeroOrOne()
+		case n.flavour == 0:
+			return op.EndMatchOne()
+		default:
+			return op.End().MatchOne()
+		}
+	}
+
+	bytes := []byte(string(n.set))
+	fn := func(r rune) bool { return strings.ContainsRune(string(n.set), r) }
+	set := NewRuneSet(n.set...)
+
+	switch {
+	case n.min == 1 && n.max == 1:
+		switch n.flavour {
+		case 0:
+			return m.MatchOneBytes(bytes)
+		case 1:
+			return m.MatchOneRunes(n.set)
+		case 2:
+			return m.MatchOneFunc(fn)
+		case 4:
+			return m.MatchOneSet(set)
+		}
+		if len(n.set) == 1 {
+			return m.MatchOneRune(n.set[0])
+		}
+		return m.MatchOneBytes(bytes)
+	case n.min == 0 && n.max == 1:
+		switch n.flavour {
+		case 0:
+			return m.MatchZeroOrOneBytes(bytes)
+		case 1:
+			return m.MatchZeroOrOneRunes(n.set)
+		case 2:
+			return m.MatchZeroOrOneFunc(fn)
+		case 4:
+			return m.MatchZeroOrOneSet(set)
+		}
+		if len(n.set) == 1 {
+			return m.MatchZeroOrOneRune(n.set[0])
+		}
+		return m.MatchZeroOrOneBytes(bytes)
+	case n.min == 0 && n.max < 0:
+		switch n.flavour {
+		case 0:
+			return m.MatchZeroOrMoreBytes(bytes)
+		case 1:
+			return m.MatchZeroOrMoreRunes(n.set)
+		case 4:
+			return m.MatchZeroOrMoreSet(set)
+		}
+		return m.MatchZeroOrMoreFunc(fn)
+	case n.min == 1 && n.max < 0:
+		switch n.flavour {
+		case 0:
+			return m.MatchOneOrMoreBytes(bytes)
+		case 1:
+			return m.MatchOneOrMoreRunes(n.set)
+		case 4:
+			return m.MatchOneOrMoreSet(set)
+		}
+		return m.MatchOneOrMoreFunc(fn)
+	}
+
+	switch n.flavour {
+	case 0:
+		return m.MatchMinMaxBytes(bytes, n.min, n.max)
+	case 1:
+		return m.MatchMinMaxRunes(n.set, n.min, n.max)
+	case 4:
+		return m.MatchMinMaxSet(set, n.min, n.max)
+	}
+	return m.MatchMinMaxFunc(fn, n.min, n.max)
+}
+
+// matchFuzzAlts applies a list of alternatives to the matcher, grouping
+// multi-item alternatives so the left-to-right evaluation of And() and Or()
+// gives them regexp precedence
+func matchFuzzAlts[M fuzzMatcher[M, O, E], O fuzzOperator[M, O, E], E fuzzEnd[O]](m M, alts [][]*fuzzNode) O {
+	var op O
+
+	for i, alt := range alts {
+		if i > 0 {
+			m = op.Or()
+		}
+
+		grouped := len(alts) > 1 && len(alt) > 1
+		if grouped {
+			m = m.Begin()
+		}
+
+		for j, item := range alt {
+			if j > 0 {
+				m = op.And()
+			}
+			op = matchFuzzNode(item, m)
+		}
+
+		if grouped {
+			op = op.EndMatchOne()
+		}
+	}
+
+	return op
+}
+
+// fuzzNode::usesFunc reports whether the expression uses any Func primitives
+func (n *fuzzNode) usesFunc() bool {
+	if n.set != nil {
+		return n.flavour == 2 || (n.flavour == 3 && n.max != 1)
+	}
+
+	for _, alt := range n.alts {
+		for _, item := range alt {
+			if item.usesFunc() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fuzzNode::apply records the top-level expression
+func (n *fuzzNode) apply(m PatternMatcher) PatternOperator {
+	return applyFuzzNode[PatternMatcher, PatternOperator, PatternEnd](n, m)
+}
+
+// fuzzNode::applyMatcher applies the top-level expression to the Matcher
+func (n *fuzzNode) applyMatcher(m Matcher) MatcherOperator {
+	return applyFuzzNode[Matcher, MatcherOperator, MatcherEnd](n, m)
+}
+
+// applyFuzzNode applies a top-level expression to the matcher
+func applyFuzzNode[M fuzzMatcher[M, O, E], O fuzzOperator[M, O, E], E fuzzEnd[O]](n *fuzzNode, m M) O {
+	var op O
+
+	switch n.anchor {
+	case 0:
+		op = matchFuzzAlts[M, O, E](m, n.alts)
+	case 1:
+		op = matchFuzzAlts[M, O, E](m.MatchBOL().AndBegin(), n.alts).EndMatchOne()
+	case 2:
+		op = matchFuzzAlts[M, O, E](m.MatchBOF().AndBegin(), n.alts).EndMatchOne()
+	case 3:
+		op = matchFuzzAlts[M, O, E](m.MatchWordBoundary().AndBegin(), n.alts).EndMatchOne()
+	default:
+		op = matchFuzzAlts[M, O, E](m.MatchNotWordBoundary().AndBegin(), n.alts).EndMatchOne()
+	}
+
+	if n.eof {
+		op = op.And().MatchEOF()
+	}
+	if n.eol {
+		op = op.And().MatchEOL()
+	}
+
+	return op
+}
+
+// runFuzzLexer runs fn against the input, returning its result and the
+// number of bytes consumed.  If tracked is true, the lexer is wrapped in an
+// Input, and its byte offset is checked against the bytes consumed.
+func runFuzzLexer(input []byte, tracked bool, fn func(lexer.Lexer) bool) (bool, int) {
+	var result bool
+
+	start := func(l lexer.Lexer) lexer.StateFn {
+		result = fn(l)
+		l.EmitTokenWithBytes(fuzzTokenMatch)
+		return nil
+	}
+
+	if !tracked {
+		consumed := len(lexer.NewFromBytes(start, input, 1).NextToken().Bytes())
+		return result, consumed
+	}
+
+	in := NewInputFromBytes(start, input, 1)
+
+	consumed := len(in.NextToken().Bytes())
+
+	if in.TokenEndPos().Offset != consumed {
+		return result, -1 // Position out of sync with the lexer
+	}
+
+	return result, consumed
+}
+
+// fuzzSeqNode converts the nodes of a Pattern to those of a SeqPattern over
+// runes, replacing classes and funcs with predicates
+func fuzzSeqNode(n *node) *node {
+	c := *n
+
+	switch n.kind {
+	case nodeGroup:
+		c.terms = make([]term, len(n.terms))
+		for i, t := range n.terms {
+			c.terms[i] = term{t.op, fuzzSeqNode(t.node), t.cut}
+		}
+	case nodeClass:
+		c.kind, c.pred = nodeFunc, n.class.Contains
+	case nodeFunc:
+		c.pred = func(r rune) bool { return n.fn(r) }
+	}
+
+	return &c
+}
+
+// fuzzRun is a way of matching a generated expression against a lexer
+type fuzzRun struct {
+	name    string
+	tracked bool
+	fn      func(lexer.Lexer) bool
+}
+
+// addFuzzSeeds adds random expressions and inputs to the fuzz corpus
+func addFuzzSeeds(f *testing.F) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 64; i++ {
+		expr := make([]byte, 8+r.Intn(32))
+		r.Read(expr)
+		input := make([]byte, r.Intn(12))
+		r.Read(input)
+		f.Add(expr, input)
+	}
+}
+
+// fuzzInput maps the fuzzer's bytes onto fuzzInputLetters
+func fuzzInput(input []byte) []byte {
+	if len(input) > 64 {
+		input = input[:64]
+	}
+	for i, b := range input {
+		input[i] = fuzzInputLetters[int(b)%len(fuzzInputLetters)]
+	}
+	return input
+}
+
+// checkFuzzRuns compares each run against the match of the anchored regexp
+func checkFuzzRuns(t *testing.T, n *fuzzNode, re *regexp.Regexp, input []byte, runs []fuzzRun) {
+	loc := re.FindIndex(input)
+
+	for _, run := range runs {
+		// Anchors other than MatchEOL need the previous rune from an Input
+		result, consumed := runFuzzLexer(input, run.tracked || n.anchor != 0, run.fn)
+
+		switch {
+		case loc == nil && result:
+			t.Fatalf("%s on %q: %s accepted %d bytes, regexp rejected", re, input, run.name, consumed)
+		case loc == nil && consumed != 0:
+			t.Fatalf("%s on %q: %s rejected but consumed %d bytes", re, input, run.name, consumed)
+		case loc != nil && !result:
+			t.Fatalf("%s on %q: %s rejected, regexp accepted %d bytes", re, input, run.name, loc[1])
+		case loc != nil && loc[1] != consumed:
+			t.Fatalf("%s on %q: %s consumed %d bytes, regexp %d", re, input, run.name, consumed, loc[1])
+		}
+	}
+}
+
+// checkFuzzSearch compares searches with each Pattern against the unanchored
+// regexp
+func checkFuzzSearch(t *testing.T, n *fuzzNode, captures []string, input []byte, patterns ...*Pattern) {
+	search := regexp.MustCompile(n.regexp())
+	all := search.FindAllIndex(input, -1)
+
+	template := "<$0"
+	for _, name := range captures {
+		template += "|${" + name + "}"
+	}
+	template += ">"
+
+	for _, sp := range patterns {
+		if found := sp.FindAllIndex(input, -1); fmt.Sprint(found) != fmt.Sprint(all) {
+			t.Fatalf("%s on %q: FindAllIndex returned %v, regexp %v", search, input, found, all)
+		}
+		if got, want := sp.ReplaceAll(input, []byte(template)), search.ReplaceAll(input, []byte(template)); string(got) != string(want) {
+			t.Fatalf("%s on %q: ReplaceAll returned %q, regexp %q", search, input, got, want)
+		}
+		for _, k := range []int{-1, 2} {
+			if got, want := sp.Split(input, k), search.Split(string(input), k); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+				t.Fatalf("%s on %q: Split(%d) returned %q, regexp %q", search, input, k, got, want)
+			}
+		}
+	}
+}
+
+// checkFuzzCaptures compares the captures of a CompileLinear Pattern against
+// those of the backtracking executor, at every offset of the input
+func checkFuzzCaptures(t *testing.T, re *regexp.Regexp, input []byte, bp *Pattern, lp *Pattern) {
+	for pos := 0; pos <= len(input); pos++ {
+		bEnd, bOk := bp.matchBytes(input, pos)
+		lEnd, lOk := lp.matchBytes(input, pos)
+
+		if bOk != lOk || bEnd != lEnd {
+			t.Fatalf("%s on %q at %d: linear returned %v %d, backtracking %v %d", re, input, pos, lOk, lEnd, bOk, bEnd)
+		}
+
+		if !bOk {
+			continue
+		}
+
+		if got, want := fmt.Sprint(lp.captures(input, pos)), fmt.Sprint(bp.captures(input, pos)); got != want {
+			t.Fatalf("%s on %q at %d: linear captured %s, backtracking %s", re, input, pos, got, want)
+		}
+	}
+}
+
+// FuzzMatcherRegexp compares the Matcher, the Patterns compiled from the same
+// calls, and a SeqPattern over the same nodes, against an anchored
+// regexp.Regexp built from a generated expression.  Searches with the Pattern
+// are compared against the unanchored regexp.
+func FuzzMatcherRegexp(f *testing.F) {
+	addFuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, expr []byte, input []byte) {
+		input = fuzzInput(input)
+
+		n, captures := newFuzzExpr(expr, false)
+		re := regexp.MustCompile("^" + n.regexp())
+
+		p := Compile(n.apply)
+		if p.dfa == nil && !n.usesFunc() && !n.eof && !n.eol && n.anchor == 0 {
+			t.Fatalf("%s: pattern was not lowered to a dfa", re)
+		}
+
+		bp := CompileBacktracking(n.apply)
+		lp := CompileLinear(n.apply)
+
+		checkFuzzRuns(t, n, re, input, []fuzzRun{
+			{"matcher", false, func(l lexer.Lexer) bool { return n.applyMatcher(New(l)).Result() }},
+			{"pattern", false, p.Match},
+			{"executor", false, (&Pattern{root: p.root}).Match},
+			{"input", true, func(l lexer.Lexer) bool { return n.applyMatcher(New(l)).Result() }},
+			{"backtracking", false, bp.Match},
+			{"linear", false, lp.Match},
+		})
+
+		checkFuzzSearch(t, n, captures, input, p, &Pattern{root: p.root, first: p.first}, &Pattern{root: p.root}, bp, lp)
+		checkFuzzCaptures(t, re, input, bp, lp)
+
+		if n.anchor != 0 || n.eol {
+			return // Sequences have no anchors
+		}
+
+		loc := re.FindIndex(input)
+		seq := NewSeq([]rune(string(input)))
+		result := (&SeqPattern[rune]{root: fuzzSeqNode(p.root)}).Match(seq)
+
+		switch {
+		case (loc != nil) != result:
+			t.Fatalf("%s on %q: seq returned %v, regexp %v", re, input, result, loc != nil)
+		case loc != nil && loc[1] != seq.Pos():
+			t.Fatalf("%s on %q: seq consumed %d elements, regexp %d", re, input, seq.Pos(), loc[1])
+		case loc == nil && seq.Pos() != 0:
+			t.Fatalf("%s on %q: seq rejected but consumed %d elements", re, input, seq.Pos())
+		}
+	})
+}
+
+// FuzzBacktrackingRegexp compares backtracking and CompileLinear Patterns
+// against regexp, using generated expressions that reuse letters and have
+// lazy runs, which regexp can only match by backtracking, and compares their
+// captures against each other
+func FuzzBacktrackingRegexp(f *testing.F) {
+	addFuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, expr []byte, input []byte) {
+		input = fuzzInput(input)
+
+		n, captures := newFuzzExpr(expr, true)
+		re := regexp.MustCompile("^" + n.regexp())
+
+		p := CompileBacktracking(n.apply)
+		lp := CompileLinear(n.apply)
+
+		checkFuzzRuns(t, n, re, input, []fuzzRun{
+			{"backtracking", false, p.Match},
+			{"input", true, p.Match},
+			{"nested", false, Compile(func(m Matcher) MatcherOperator { return m.MatchPattern(p) }).Match},
+			{"linear", false, lp.Match},
+			{"linear input", true, lp.Match},
+			{"linear nested", false, CompileLinear(func(m Matcher) MatcherOperator { return m.MatchPattern(lp) }).Match},
+		})
+
+		checkFuzzSearch(t, n, captures, input, p, lp)
+		checkFuzzCaptures(t, re, input, p, lp)
+	})
+}
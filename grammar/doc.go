@@ -0,0 +1,67 @@
+/*
+Package grammar builds parsers from the struct tags of the Go types they
+produce, in the style of participle, so that a configuration language can be
+parsed into typed values without writing lexer.StateFns or parse functions.
+
+The grammar tag of each field, named matcher, is matched in the order of the
+fields, and the grammar of a struct is the sequence of its tags:
+
+	type Config struct {
+		Entries []*Entry `matcher:"@@*"`
+	}
+
+	type Entry struct {
+		Key   string   `matcher:"@Ident '='"`
+		Value *Value   `matcher:"@@"`
+	}
+
+	type Value struct {
+		Number *float64 `matcher:"  @Float"`
+		Text   *string  `matcher:"| @String"`
+		List   []*Value `matcher:"| '[' (@@ (',' @@)*)? ']'"`
+		True   bool     `matcher:"| @'true'"`
+	}
+
+	config, err := grammar.Parse[Config](src)
+
+A tag is an expression of:
+
+	'text' "text"   A literal, ending at a word boundary if it ends with a word rune
+	Name            A terminal, i.e. Ident, Int, Float, String
+	@@              Parses the struct of the field, appending it if it is a slice
+	@x              Assigns the text matched by x to the field, or appends it
+	x y             Sequence
+	x | y           Ordered choice
+	x* x+ x?        Repetition
+	( x )           Grouping
+
+Captured text is converted to the type of the field as matcher.Unmarshal
+converts it, by matcher.Converter, so that a string, []byte, bool, integer,
+float, encoding.TextUnmarshaler or type given to matcher.RegisterConverter, or
+a slice of them, may be captured, and pointers to struct fields are allocated
+as they are captured.  Integers are decimal, and a bool is converted by
+strconv.ParseBool, so @'true' captures true, while a bool that is never
+captured stays false.  Text that cannot be converted is an error at the
+capture, rather than a mismatch.
+
+As with a Matcher, alternatives are tried in order, repetitions consume as
+many matches as they can, and nothing is ever backtracked into.  Grammars must
+not be left-recursive.  Before each literal, terminal and capture, and at the
+end of the input, the Skip Pattern of the Grammar, whitespace by default, is
+skipped.  Terminals are matcher Patterns, and more can be added by name:
+
+	g := grammar.Build[Config]().
+		Terminal("Path", path).
+		Skip(spaceAndComments)
+
+A Grammar is compiled into a matcher Pattern, which Pattern returns.  Each
+struct is a Pattern given to matcher.Declare, so that rules can refer to
+themselves, as Value does through List, and each literal and terminal is a
+declared Pattern named after it.  Captures are named groups, and Parse fills
+the struct from the captures of MatchCaptures.
+
+A failed Parse returns a *matcher.MatchError for the farthest position
+reached, i.e. `2:9: expected "=", found '-'`, which matcher.FormatError
+formats with the offending line.
+*/
+package grammar
@@ -0,0 +1,136 @@
+package grammar
+
+import (
+	"reflect"
+
+	"github.com/iNamik/go_lexer_matcher"
+)
+
+// Grammar parses input into values of type T, following the grammar tags of
+// the fields of T and of the structs they capture
+type Grammar[T any] struct {
+	rule      *structRule
+	skipper   *matcher.Pattern
+	terminals map[string]*matcher.Pattern
+	pattern   *matcher.Pattern
+	captures  []*expr
+}
+
+var (
+	setIdentStart = matcher.NewRuneSetFromRangeString("a-zA-Z_")
+	setIdentRest  = matcher.NewRuneSetFromRangeString("a-zA-Z0-9_")
+	setDigits     = matcher.NewRuneSetFromRangeString("0-9")
+	setSpace      = matcher.NewRuneSetFromClass(`\s`)
+)
+
+// The terminals every Grammar starts with
+var (
+	// Regex: [a-zA-Z_][a-zA-Z0-9_]*
+	patternIdent = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchOneSet(setIdentStart).And().MatchZeroOrMoreSet(setIdentRest)
+	})
+
+	// Regex: -?[0-9]+
+	patternInt = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchZeroOrOneRune('-').And().MatchOneOrMoreSet(setDigits)
+	})
+
+	// Regex: -?[0-9]+(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?
+	patternFloat = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchZeroOrOneRune('-').And().MatchOneOrMoreSet(setDigits).
+			AndBegin().MatchOneRune('.').And().MatchOneOrMoreSet(setDigits).EndMatchZeroOrOne().
+			AndBegin().MatchOneRunes([]rune{'e', 'E'}).And().MatchZeroOrOneRunes([]rune{'+', '-'}).And().MatchOneOrMoreSet(setDigits).EndMatchZeroOrOne()
+	})
+
+	// Regex: "(?s:\\.|[^\\])*?"
+	patternString = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchOneRune('"').And().MatchUntilUnescaped(`"`, '\\').And().MatchOneRune('"')
+	})
+
+	// Regex: \s+
+	patternSpace = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchOneOrMoreSet(setSpace)
+	})
+)
+
+// Build builds the Grammar of T, which must be a struct, panicking if any of
+// the grammar tags of T, or of the structs they capture, is invalid.  The
+// Grammar starts with the terminals Ident, Int, Float and String, and skips
+// whitespace.
+func Build[T any]() *Grammar[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	if t.Kind() != reflect.Struct {
+		panic("Calling Build() with a type that is not a struct: " + t.String())
+	}
+
+	b := &builder{rules: make(map[reflect.Type]*structRule)}
+
+	return &Grammar[T]{
+		rule:    b.rule(t),
+		skipper: patternSpace,
+		terminals: map[string]*matcher.Pattern{
+			"Ident":  patternIdent,
+			"Int":    patternInt,
+			"Float":  patternFloat,
+			"String": patternString,
+		},
+	}
+}
+
+// Parse builds the Grammar of T and parses src with it
+func Parse[T any](src []byte) (*T, error) {
+	return Build[T]().Parse(src)
+}
+
+// Terminal adds a terminal, matched by the Pattern, that grammar tags refer
+// to by name, replacing any terminal of the same name
+func (g *Grammar[T]) Terminal(name string, p *matcher.Pattern) *Grammar[T] {
+	g.terminals[name] = p
+	g.pattern = nil
+
+	return g
+}
+
+// Skip sets the Pattern whose matches are skipped before each literal,
+// terminal and capture, and at the end of the input, i.e. whitespace and
+// comments.  nil skips nothing.
+func (g *Grammar[T]) Skip(p *matcher.Pattern) *Grammar[T] {
+	g.skipper = p
+	g.pattern = nil
+
+	return g
+}
+
+// Pattern returns the Pattern the Grammar is compiled into, which matches all
+// of the input, panicking if a rule refers to an unknown terminal.  Each
+// struct is a declared Pattern named after it, and each literal and terminal
+// one named after it.
+func (g *Grammar[T]) Pattern() *matcher.Pattern {
+	if g.pattern == nil {
+		g.pattern, g.captures = compile(g.rule, g.skipper, g.terminals)
+	}
+
+	return g.pattern
+}
+
+// Parse parses all of src into a new T, filled from the captures of a match
+// of the Pattern of the Grammar.  Returns a *matcher.MatchError, listing the
+// literals and terminals expected at the farthest position reached, if src
+// does not match the grammar, or giving the position of captured text that
+// cannot be converted to the type of its field.
+func (g *Grammar[T]) Parse(src []byte) (*T, error) {
+	p := g.Pattern()
+
+	_, caps, err := p.MatchCaptures(src)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := decode(g.rule, g.captures, src, caps)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Interface().(*T), nil
+}
@@ -0,0 +1,387 @@
+package grammar
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/iNamik/go_lexer_matcher"
+)
+
+// Config is the grammar of the package documentation
+type Config struct {
+	Entries []*Entry `matcher:"@@*"`
+}
+
+type Entry struct {
+	Key   string `matcher:"@Ident '='"`
+	Value *Value `matcher:"@@"`
+}
+
+type Value struct {
+	Number *float64 `matcher:"  @Float"`
+	Text   *string  `matcher:"| @String"`
+	List   []*Value `matcher:"| '[' (@@ (',' @@)*)? ']'"`
+	True   bool     `matcher:"| @'true'"`
+}
+
+// String returns the value as written, with lists in brackets
+func (v *Value) String() string {
+	switch {
+	case v.Number != nil:
+		return fmt.Sprint(*v.Number)
+	case v.Text != nil:
+		return *v.Text
+	case v.True:
+		return "true"
+	}
+
+	s := make([]string, len(v.List))
+	for i, x := range v.List {
+		s[i] = x.String()
+	}
+
+	return "[" + strings.Join(s, " ") + "]"
+}
+
+// configString returns the entries of a Config as key=value pairs
+func configString(c *Config) string {
+	s := make([]string, len(c.Entries))
+	for i, e := range c.Entries {
+		s[i] = e.Key + "=" + e.Value.String()
+	}
+
+	return strings.Join(s, " ")
+}
+
+// TestParse checks struct, slice and pointer fields, recursion and
+// alternatives spanning fields
+func TestParse(t *testing.T) {
+	tests := map[string]string{
+		``:                                ``,
+		`a = 1`:                           `a=1`,
+		"a=1.5e3\nb = \"x\\\"y\"  c=true": `a=1500 b="x\"y" c=true`,
+		`l = [1, [true, "s"], []]`:        `l=[1 [true "s"] []]`,
+	}
+
+	for src, want := range tests {
+		c, err := Parse[Config]([]byte(src))
+
+		if err != nil {
+			t.Errorf("%q: %v", src, err)
+			continue
+		}
+
+		if got := configString(c); got != want {
+			t.Errorf("%q: parsed %s, want %s", src, got, want)
+		}
+	}
+}
+
+// TestParseErrors checks that the farthest failure is reported, with all
+// the literals and terminals expected there
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"a = 1\nb = -x":  `2:5: expected Float, String, "[" or "true", found '-'`,
+		"a = 1\nbc - 2":  `2:4: expected "=", found '-'`,
+		`a = [1, 2`:      `1:10: expected "," or "]", found EOF`,
+		`a = [1 2]`:      `1:8: expected "," or "]", found '2'`,
+		`a = truex`:      `1:5: expected Float, String, "[" or "true", found 't'`,
+		`a = 1 = 2`:      `1:7: expected Ident or EOF, found '='`,
+		`a = "unclosed`:  `1:5: expected Float, String, "[" or "true", found '"'`,
+		"a = 1\n\n  =":   `3:3: expected Ident or EOF, found '='`,
+		"a = [\n  true,": `2:8: expected Float, String, "[" or "true", found EOF`,
+	}
+
+	for src, want := range tests {
+		_, err := Parse[Config]([]byte(src))
+
+		if err == nil {
+			t.Errorf("%q: parsed", src)
+			continue
+		}
+
+		if _, ok := err.(*matcher.MatchError); !ok || err.Error() != want {
+			t.Errorf("%q: %v, want %s", src, err, want)
+		}
+	}
+}
+
+// Scalars captures into each kind of field
+type Scalars struct {
+	Int    int       `matcher:"(  'int'    @Int"`
+	Int8   int8      `matcher:" | 'int8'   @Int"`
+	Uint   uint16    `matcher:" | 'uint'   @Int"`
+	Float  float32   `matcher:" | 'float'  @Float"`
+	Bool   bool      `matcher:" | 'bool'   @('true' | 'false')?"`
+	Bytes  []byte    `matcher:" | 'bytes'  @String"`
+	Ptr    *int      `matcher:" | 'ptr'    @Int"`
+	Ints   []int     `matcher:" | 'ints'   @Int (',' @Int)*"`
+	Ptrs   []*string `matcher:" | 'ptrs'   @Ident (',' @Ident)*"`
+	Level  Level     `matcher:" | 'level'  @Ident"`
+	Levels []*Level  `matcher:" | 'levels' @Ident @Ident+ )*"`
+}
+
+// Level is an encoding.TextUnmarshaler
+type Level int
+
+// UnmarshalText accepts low and high
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+
+	return nil
+}
+
+// TestParseCaptures checks the conversion of captures to each field type,
+// and that a conversion that fails is an error at the capture
+func TestParseCaptures(t *testing.T) {
+	src := `int -12 int8 127 uint 65535 float 2.5 bool true bytes "b" ptr 3
+		ints 1, 2,3 ptrs a, b level high levels low high high low`
+
+	s, err := Parse[Scalars]([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := fmt.Sprintf("%d %d %d %g %v %s %d %v %s %s %d %d %d",
+		s.Int, s.Int8, s.Uint, s.Float, s.Bool, s.Bytes, *s.Ptr, s.Ints,
+		*s.Ptrs[0], *s.Ptrs[1], s.Level, *s.Levels[0], *s.Levels[3])
+
+	if want := `-12 127 65535 2.5 true "b" 3 [1 2 3] a b 2 1 1`; got != want {
+		t.Errorf("parsed %s, want %s", got, want)
+	}
+
+	if len(s.Levels) != 4 {
+		t.Errorf("parsed %d levels, want 4", len(s.Levels))
+	}
+
+	// A bool is converted by strconv.ParseBool, and left false if not captured
+	for _, src := range []string{"bool", "bool false"} {
+		if s, err := Parse[Scalars]([]byte(src)); err != nil || s.Bool {
+			t.Errorf("%q: %v, %v", src, s, err)
+		}
+	}
+
+	tests := map[string]string{
+		"int8 128":   `1:6: expected int8, found "128"`,
+		"uint -1":    `1:6: expected uint16, found "-1"`,
+		"level mid":  `1:7: expected grammar.Level, found "mid"`,
+		"levels low": `1:11: expected Ident, found EOF`,
+	}
+
+	for src, want := range tests {
+		if _, err := Parse[Scalars]([]byte(src)); err == nil || err.Error() != want {
+			t.Errorf("%q: %v, want %s", src, err, want)
+		}
+	}
+}
+
+// Words matches literals, escaped and at word boundaries
+type Words struct {
+	Words []string `matcher:"( @'in' | @'int' | @\"it's\" | @'a\\'b' | @'+' | @'++' )*"`
+}
+
+// TestParseLiterals checks that literals ending with a word rune only match
+// whole words, and that quotes can be escaped
+func TestParseLiterals(t *testing.T) {
+	tests := map[string]string{
+		`in int`:     "[in int]",
+		`int in`:     "[int in]",
+		`it's a'b`:   "[it's a'b]",
+		`++ + ++`:    "[+ + + + +]",
+		`in+int`:     "[in + int]",
+		`inside`:     "!",
+		`"in"`:       "!",
+		`a'b in it`:  "!",
+		`it's int's`: "!",
+	}
+
+	for src, want := range tests {
+		w, err := Parse[Words]([]byte(src))
+
+		got := "!"
+		if err == nil {
+			got = fmt.Sprint(w.Words)
+		}
+
+		if got != want {
+			t.Errorf("%q: parsed %s, want %s", src, got, want)
+		}
+	}
+}
+
+// Counts matches repetitions
+type Counts struct {
+	Opt  *string  `matcher:"'<' @Ident? '>'"`
+	Star []string `matcher:"'[' @Int* ']'"`
+	Plus []string `matcher:"'(' @Int+ ')'"`
+}
+
+// TestParseRepetition checks the counts of *, + and ?
+func TestParseRepetition(t *testing.T) {
+	tests := map[string]string{
+		`<> [] (1)`:        "<nil> [] [1]",
+		`<x> [1 2] (3 4)`:  "x [1 2] [3 4]",
+		`<> [1] ()`:        "!",
+		`<x y> [] (1)`:     "!",
+		`<> [1 2 3 4] (5)`: "<nil> [1 2 3 4] [5]",
+	}
+
+	for src, want := range tests {
+		c, err := Parse[Counts]([]byte(src))
+
+		got := "!"
+		if err == nil {
+			opt := "<nil>"
+			if c.Opt != nil {
+				opt = *c.Opt
+			}
+			got = fmt.Sprintf("%s %v %v", opt, c.Star, c.Plus)
+		}
+
+		if got != want {
+			t.Errorf("%q: parsed %s, want %s", src, got, want)
+		}
+	}
+}
+
+// Alternatives keeps the captures of the alternative that matched
+type Alternatives struct {
+	Name  string `matcher:"  @Ident ':'"`
+	Value int    `matcher:"| 'x' '=' @Int"`
+}
+
+// TestParseAlternatives checks that the captures of a failed alternative are
+// dropped
+func TestParseAlternatives(t *testing.T) {
+	tests := map[string]Alternatives{
+		"x = 1": {Value: 1},
+		"y :":   {Name: "y"},
+		"x :":   {Name: "x"},
+	}
+
+	for src, want := range tests {
+		a, err := Parse[Alternatives]([]byte(src))
+
+		if err != nil || *a != want {
+			t.Errorf("%q: parsed %+v, %v, want %+v", src, a, err, want)
+		}
+	}
+}
+
+// TestGrammarSkip checks a Skip Pattern of comments, and skipping nothing
+func TestGrammarSkip(t *testing.T) {
+	comments := matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchOneOrMoreRunes([]rune(" \n")).
+			OrBegin().MatchOneRune('#').And().NonMatchZeroOrMoreRunes([]rune{'\n'}).EndMatchOne()
+	})
+
+	g := Build[Config]().Skip(comments)
+
+	c, err := g.Parse([]byte("# config\na = 1 # one\nb = [2, # two\n 3]\n# end"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := configString(c); got != "a=1 b=[2 3]" {
+		t.Errorf("parsed %s", got)
+	}
+
+	if _, err := g.Parse([]byte("a = 1\tb = 2")); err == nil || err.Error() != `1:6: expected Ident or EOF, found '\t'` {
+		t.Errorf("a tab was skipped: %v", err)
+	}
+
+	g.Skip(nil)
+
+	if _, err := g.Parse([]byte("a=1")); err != nil {
+		t.Errorf("without skipping: %v", err)
+	}
+
+	if _, err := g.Parse([]byte("a = 1")); err == nil || err.Error() != `1:2: expected "=", found ' '` {
+		t.Errorf("without skipping, skipped: %v", err)
+	}
+}
+
+// TestGrammarTerminal checks that terminals can be added and replaced
+func TestGrammarTerminal(t *testing.T) {
+	type Path struct {
+		Parts []string `matcher:"@Part ('/' @Part)*"`
+	}
+
+	part := matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchOneOrMoreSet(matcher.NewRuneSetFromRangeString("a-z."))
+	})
+
+	p, err := Build[Path]().Terminal("Part", part).Parse([]byte("usr/local/.."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(p.Parts, []string{"usr", "local", ".."}) {
+		t.Errorf("parsed %q", p.Parts)
+	}
+
+	type Number struct {
+		N Hex `matcher:"@Int"`
+	}
+
+	hex := matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchOneRune('0').And().MatchOneRune('x').And().MatchOneOrMoreSet(matcher.NewRuneSetFromRangeString("0-9a-f"))
+	})
+
+	n, err := Build[Number]().Terminal("Int", hex).Parse([]byte("0xff"))
+	if err != nil || n.N != 255 {
+		t.Errorf("parsed %v, %v, want 255", n, err)
+	}
+}
+
+// Hex is converted by a converter registered with matcher.RegisterConverter
+type Hex int
+
+func init() {
+	matcher.RegisterConverter(func(text string) (Hex, error) {
+		n, err := strconv.ParseInt(text, 0, 64)
+		return Hex(n), err
+	})
+}
+
+// TestParseLong checks that a long input parses
+func TestParseLong(t *testing.T) {
+	src := strings.Repeat("key = [1, \"two\", true]\n", 10000)
+
+	c, err := Parse[Config]([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Entries) != 10000 || c.Entries[9999].Value.String() != `[1 "two" true]` {
+		t.Errorf("parsed %d entries", len(c.Entries))
+	}
+}
+
+// TestGrammarPattern checks the Pattern a Grammar is compiled into, and that
+// it is compiled again once its skipper changes
+func TestGrammarPattern(t *testing.T) {
+	g := Build[Entry]()
+
+	if got, want := g.Pattern().String(), `(?:(?:[\t\n\f\r ]+)*(?&Entry)(?:[\t\n\f\r ]+)*(?&EOF))`; got != want {
+		t.Errorf("Pattern() is %s, want %s", got, want)
+	}
+
+	if got, want := g.Skip(nil).Pattern().String(), `(?:(?&Entry)(?&EOF))`; got != want {
+		t.Errorf("Pattern() without skipping is %s, want %s", got, want)
+	}
+
+	if _, caps, err := g.Pattern().MatchCaptures([]byte("a=1")); err != nil || len(caps) == 0 {
+		t.Errorf("matched %v, %v", caps, err)
+	}
+}
@@ -0,0 +1,245 @@
+package grammar
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/iNamik/go_lexer_matcher"
+)
+
+// The captures of a compiled Grammar are named markStruct, for the
+// zero-width capture starting each struct, or "@" and the index of the
+// capture expr in compiler.captures
+const markStruct = "("
+
+var (
+	// Regex: (?:)
+	patternNothing = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchZeroOrOneRunes(nil)
+	})
+
+	// Regex: \z
+	patternEOF = matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		return m.MatchEOF()
+	})
+)
+
+// compiler compiles the rules of a Grammar into a Pattern.  Each rule is a
+// declared Pattern, named after its struct, and each literal and terminal a
+// declared Pattern named after it, so that the errors of a match list them.
+type compiler struct {
+	skipper   *matcher.Pattern
+	terminals map[string]*matcher.Pattern
+	rules     map[*structRule]*matcher.Pattern
+	named     map[string]*matcher.Pattern
+	captures  []*expr
+}
+
+// compile compiles a rule, followed by the end of the input, into a Pattern,
+// returning it along with the capture exprs its captures are named after
+func compile(r *structRule, skipper *matcher.Pattern, terminals map[string]*matcher.Pattern) (*matcher.Pattern, []*expr) {
+	c := &compiler{
+		skipper:   skipper,
+		terminals: terminals,
+		rules:     make(map[*structRule]*matcher.Pattern),
+		named:     make(map[string]*matcher.Pattern),
+	}
+
+	p := matcher.Compile(func(m matcher.PatternMatcher) matcher.PatternOperator {
+		return c.begin(m).MatchPattern(c.rule(r)).And().MatchPattern(c.name("EOF", patternEOF)).EndMatchOne()
+	})
+
+	return p, c.captures
+}
+
+// compiler::begin begins a grouping skipping the skipper before each operand,
+// if there is one
+func (c *compiler) begin(m matcher.PatternMatcher) matcher.PatternMatcher {
+	if c.skipper == nil {
+		return m.Begin()
+	}
+
+	return m.BeginSkipping(c.skipper)
+}
+
+// compiler::rule returns the declared Pattern of a rule, declaring it if
+// needed, so that rules can refer to themselves.  A zero-width capture marks
+// the start of the captures of the struct.
+func (c *compiler) rule(r *structRule) *matcher.Pattern {
+	if p, ok := c.rules[r]; ok {
+		return p
+	}
+
+	name := r.typ.Name()
+	if name == "" {
+		name = r.typ.String()
+	}
+
+	p := matcher.Declare(name)
+
+	c.rules[r] = p
+
+	p.Define(func(m matcher.PatternMatcher) matcher.PatternOperator {
+		op := c.begin(m).BeginCapture(markStruct).MatchPattern(patternNothing).EndMatchOne()
+
+		return c.expr(op.And(), r.expr).EndMatchOne()
+	})
+
+	return p
+}
+
+// compiler::name returns a declared Pattern matching p, known by name
+func (c *compiler) name(name string, p *matcher.Pattern) *matcher.Pattern {
+	if d, ok := c.named[name]; ok {
+		return d
+	}
+
+	d := matcher.Declare(name)
+
+	d.Define(func(m matcher.PatternMatcher) matcher.PatternOperator {
+		return m.MatchPattern(p)
+	})
+
+	c.named[name] = d
+
+	return d
+}
+
+// compiler::capture returns the name of the capture of an expr
+func (c *compiler) capture(e *expr) string {
+	c.captures = append(c.captures, e)
+
+	return "@" + strconv.Itoa(len(c.captures)-1)
+}
+
+// compiler::expr adds the operand matching an expr to m.  Repetitions match
+// their expr as the items of a MatchList separated by nothing, skipping before
+// each item.
+func (c *compiler) expr(m matcher.PatternMatcher, e *expr) matcher.PatternOperator {
+	switch e.kind {
+
+	case exprSeq, exprAlt:
+		op := c.expr(m.Begin(), e.exprs[0])
+
+		for _, x := range e.exprs[1:] {
+			if e.kind == exprSeq {
+				op = c.expr(op.And(), x)
+			} else {
+				op = c.expr(op.Or(), x)
+			}
+		}
+
+		return op.EndMatchOne()
+
+	case exprRepeat:
+		if e.max == 1 {
+			return c.expr(m.Begin(), e.exprs[0]).EndMatchZeroOrOne()
+		}
+
+		item := matcher.Compile(func(m matcher.PatternMatcher) matcher.PatternOperator {
+			return c.expr(c.begin(m), e.exprs[0]).EndMatchOne()
+		})
+
+		return m.MatchList(item, patternNothing, matcher.ListOptions{Min: e.min})
+
+	case exprLiteral:
+		return m.MatchPattern(c.name(strconv.Quote(e.text), e.pattern))
+
+	case exprTerminal:
+		t, ok := c.terminals[e.text]
+		if !ok {
+			panic("Grammar refers to an unknown terminal: " + e.text)
+		}
+
+		return m.MatchPattern(c.name(e.text, t))
+
+	case exprCapture:
+		return c.expr(m.BeginCapture(c.capture(e)), e.exprs[0]).EndMatchOne()
+	}
+
+	// exprStruct
+	return m.BeginCapture(c.capture(e)).MatchPattern(c.rule(e.rule)).EndMatchOne()
+}
+
+// assign is a value to be assigned to a field, once its struct has matched
+type assign struct {
+	field *field
+	value reflect.Value
+}
+
+// decode builds the struct of a rule from the captures of a match of src, in
+// the order their groups ended.  The assigns of the struct being captured are
+// on top of the stack, which a struct capture pops into a struct, assigned to
+// the struct below it.  Returns a *matcher.MatchError at the capture if
+// captured text cannot be converted to the type of its field.
+func decode(r *structRule, captures []*expr, src []byte, caps []matcher.Capture) (reflect.Value, error) {
+	var stack [][]assign
+
+	for _, c := range caps {
+		if c.Name == markStruct {
+			stack = append(stack, nil)
+			continue
+		}
+
+		i, _ := strconv.Atoi(c.Name[1:])
+		e, top := captures[i], len(stack)-1
+
+		if e.kind == exprStruct {
+			v := build(e.rule, stack[top])
+
+			if e.field.elem.Kind() != reflect.Pointer {
+				v = v.Elem()
+			}
+
+			stack = stack[:top]
+			stack[top-1] = append(stack[top-1], assign{e.field, v})
+
+			continue
+		}
+
+		text := string(src[c.Start:c.End])
+
+		v, err := e.field.convert(text)
+		if err != nil {
+			return reflect.Value{}, &matcher.MatchError{Pos: posAt(src, c.Start), Expected: []string{e.field.elem.String()}, Found: strconv.Quote(text)}
+		}
+
+		if e.field.ptr {
+			ptr := reflect.New(e.field.elem)
+			ptr.Elem().Set(v)
+			v = ptr
+		}
+
+		stack[top] = append(stack[top], assign{e.field, v})
+	}
+
+	return build(r, stack[0]), nil
+}
+
+// build returns a pointer to a new struct of a rule, with the values assigned
+func build(r *structRule, assigns []assign) reflect.Value {
+	v := reflect.New(r.typ)
+
+	for _, a := range assigns {
+		fv := v.Elem().Field(a.field.index)
+
+		if a.field.slice {
+			fv.Set(reflect.Append(fv, a.value))
+		} else {
+			fv.Set(a.value)
+		}
+	}
+
+	return v
+}
+
+// posAt returns the position of an offset within src
+func posAt(src []byte, offset int) matcher.Pos {
+	input := matcher.NewInputFromBytes(nil, src, 1)
+
+	for input.Pos().Offset < offset {
+		input.NextRune()
+	}
+
+	return input.Pos()
+}
@@ -0,0 +1,368 @@
+package grammar
+
+import (
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/iNamik/go_lexer_matcher"
+)
+
+// exprKind identifies the type of a grammar expression
+type exprKind int
+
+const (
+	exprSeq exprKind = iota
+	exprAlt
+	exprRepeat
+	exprLiteral
+	exprTerminal
+	exprCapture
+	exprStruct
+)
+
+// expr is a parsed grammar tag.  Sequences and alternatives match their
+// exprs, repeats match exprs[0] between min and max times (max < 0 means no
+// limit), and captures match exprs[0], assigning its text to the field.
+// Literals hold their text, and the Pattern matching it, and terminals hold
+// their name, in text.  Struct exprs parse the struct of rule into the field.
+type expr struct {
+	kind    exprKind
+	exprs   []*expr
+	min     int
+	max     int
+	text    string
+	pattern *matcher.Pattern
+	field   *field
+	rule    *structRule
+}
+
+// structRule is the grammar of a struct, i.e. the grammar tags of its fields,
+// in order
+type structRule struct {
+	typ  reflect.Type
+	expr *expr
+}
+
+// field is a field of a struct that captures are assigned to.  elem is the
+// type of each capture, which is appended to the field if it is a slice, and
+// assigned through a new pointer if ptr is true.  Captured text is converted
+// to elem by convert.
+type field struct {
+	index   int
+	elem    reflect.Type
+	slice   bool
+	ptr     bool
+	convert func(string) (reflect.Value, error)
+}
+
+// builder builds the rules of structs, once each, so that rules can refer to
+// themselves
+type builder struct {
+	rules map[reflect.Type]*structRule
+}
+
+var (
+	typeBytes = reflect.TypeOf([]byte(nil))
+	setWord   = matcher.NewRuneSetFromClass(`\w`)
+)
+
+// builder::rule returns the rule of a struct type, building it if needed
+func (b *builder) rule(t reflect.Type) *structRule {
+	if r, ok := b.rules[t]; ok {
+		return r
+	}
+
+	r := &structRule{typ: t}
+
+	b.rules[t] = r
+
+	p := &tagParser{b: b, typ: t}
+
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("matcher")
+
+		if ok && tag != "-" {
+			p.tags = append(p.tags, i)
+		}
+	}
+
+	if len(p.tags) == 0 {
+		panic("Grammar of " + t.String() + " has no tagged fields")
+	}
+
+	r.expr = p.parse()
+
+	return r
+}
+
+// tagParser parses the grammar tags of the fields of a struct, which form a
+// single expression, so that an alternative can begin in one field and end in
+// another.  tags holds the indexes of the tagged fields, tag being the one
+// being parsed, and each capture is assigned to the field whose tag it is in.
+type tagParser struct {
+	b    *builder
+	typ  reflect.Type
+	tags []int
+	tag  int
+	src  string
+	pos  int
+	f    reflect.StructField
+}
+
+// tagParser::parse parses the tags
+func (p *tagParser) parse() *expr {
+	p.f = p.typ.Field(p.tags[0])
+	p.src = p.f.Tag.Get("matcher")
+
+	e := p.alt()
+
+	if p.peek() != 0 {
+		p.invalid("an unexpected " + strconv.QuoteRune(p.peekRune()))
+	}
+
+	return e
+}
+
+// tagParser::invalid panics, describing what is wrong with the tag
+func (p *tagParser) invalid(problem string) {
+	name := p.typ.Name()
+	if name == "" {
+		name = p.typ.String()
+	}
+
+	panic("Grammar tag of " + name + "." + p.f.Name + " has " + problem)
+}
+
+// tagParser::peek skips spaces, moving on to the tag of the next field at the
+// end of a tag, returning the next byte, or 0 at the end of the last tag
+func (p *tagParser) peek() byte {
+	for {
+		for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+			p.pos++
+		}
+
+		if p.pos < len(p.src) {
+			return p.src[p.pos]
+		}
+
+		if p.tag == len(p.tags)-1 {
+			return 0
+		}
+
+		p.tag++
+		p.f = p.typ.Field(p.tags[p.tag])
+		p.src, p.pos = p.f.Tag.Get("matcher"), 0
+	}
+}
+
+// tagParser::peekRune returns the next rune, for panics
+func (p *tagParser) peekRune() rune {
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+
+	return r
+}
+
+// tagParser::alt parses seq ('|' seq)*
+func (p *tagParser) alt() *expr {
+	e := &expr{kind: exprAlt, exprs: []*expr{p.seq()}}
+
+	for p.peek() == '|' {
+		p.pos++
+		e.exprs = append(e.exprs, p.seq())
+	}
+
+	if len(e.exprs) == 1 {
+		return e.exprs[0]
+	}
+
+	return e
+}
+
+// tagParser::seq parses term+
+func (p *tagParser) seq() *expr {
+	e := &expr{kind: exprSeq}
+
+	for c := p.peek(); c != 0 && c != '|' && c != ')'; c = p.peek() {
+		e.exprs = append(e.exprs, p.term())
+	}
+
+	switch len(e.exprs) {
+	case 0:
+		p.invalid("an empty expression")
+	case 1:
+		return e.exprs[0]
+	}
+
+	return e
+}
+
+// tagParser::term parses atom ('*' | '+' | '?')?
+func (p *tagParser) term() *expr {
+	e := p.atom()
+
+	switch p.peek() {
+	case '*':
+		e = &expr{kind: exprRepeat, exprs: []*expr{e}, min: 0, max: -1}
+	case '+':
+		e = &expr{kind: exprRepeat, exprs: []*expr{e}, min: 1, max: -1}
+	case '?':
+		e = &expr{kind: exprRepeat, exprs: []*expr{e}, min: 0, max: 1}
+	default:
+		return e
+	}
+
+	p.pos++
+
+	return e
+}
+
+// tagParser::atom parses '@@', '@' atom, a literal, a terminal name, or
+// '(' alt ')'
+func (p *tagParser) atom() *expr {
+	switch c := p.peek(); {
+	case c == '@' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '@':
+		p.pos += 2
+		return p.structField()
+
+	case c == '@':
+		p.pos++
+
+		f := p.textField()
+
+		return &expr{kind: exprCapture, exprs: []*expr{p.atom()}, field: f}
+
+	case c == '\'' || c == '"':
+		return p.literal(c)
+
+	case c == '(':
+		p.pos++
+
+		e := p.alt()
+
+		if p.peek() != ')' {
+			p.invalid("an unbalanced (")
+		}
+
+		p.pos++
+
+		return e
+
+	case setIdentStart.Contains(rune(c)):
+		start := p.pos
+
+		for p.pos < len(p.src) && setIdentRest.Contains(rune(p.src[p.pos])) {
+			p.pos++
+		}
+
+		return &expr{kind: exprTerminal, text: p.src[start:p.pos]}
+
+	case c == 0:
+		p.invalid("an empty expression")
+	}
+
+	p.invalid("an unexpected " + strconv.QuoteRune(p.peekRune()))
+
+	return nil
+}
+
+// tagParser::literal parses a literal quoted by q, within which a backslash
+// escapes the next rune.  The Pattern of a literal ending with a word rune
+// only matches at the end of a word, so that 'in' does not match "int".
+func (p *tagParser) literal(q byte) *expr {
+	var runes []rune
+
+	for p.pos++; ; {
+		if p.pos >= len(p.src) {
+			p.invalid("an unterminated literal")
+		}
+
+		r, w := utf8.DecodeRuneInString(p.src[p.pos:])
+
+		p.pos += w
+
+		if r == rune(q) {
+			break
+		}
+
+		if r == '\\' && p.pos < len(p.src) {
+			r, w = utf8.DecodeRuneInString(p.src[p.pos:])
+			p.pos += w
+		}
+
+		runes = append(runes, r)
+	}
+
+	if len(runes) == 0 {
+		p.invalid("an empty literal")
+	}
+
+	pattern := matcher.Compile(func(m matcher.Matcher) matcher.MatcherOperator {
+		op := m.MatchOneRune(runes[0])
+
+		for _, r := range runes[1:] {
+			op = op.And().MatchOneRune(r)
+		}
+
+		if setWord.Contains(runes[len(runes)-1]) {
+			op = op.And().MatchWordBoundary()
+		}
+
+		return op
+	})
+
+	return &expr{kind: exprLiteral, text: string(runes), pattern: pattern}
+}
+
+// tagParser::structField returns a struct expr for the field, which must be a
+// struct, or a pointer or slice of them
+func (p *tagParser) structField() *expr {
+	elem, slice := p.f.Type, false
+
+	if elem.Kind() == reflect.Slice {
+		elem, slice = elem.Elem(), true
+	}
+
+	t := elem
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		p.invalid("a @@ capture into a field that is not a struct, or a pointer or slice of them")
+	}
+
+	p.checkExported()
+
+	return &expr{kind: exprStruct, rule: p.b.rule(t), field: &field{index: p.f.Index[0], elem: elem, slice: slice}}
+}
+
+// tagParser::textField returns the field captured text is assigned to, which
+// must be of a type matcher.Converter converts to, or a pointer or slice of
+// them
+func (p *tagParser) textField() *field {
+	f := &field{index: p.f.Index[0], elem: p.f.Type}
+
+	if f.elem.Kind() == reflect.Slice && f.elem != typeBytes {
+		f.elem, f.slice = f.elem.Elem(), true
+	}
+
+	if f.elem.Kind() == reflect.Pointer {
+		f.elem, f.ptr = f.elem.Elem(), true
+	}
+
+	p.checkExported()
+
+	if f.convert = matcher.Converter(f.elem); f.convert == nil {
+		p.invalid("a capture into a field of unsupported type " + p.f.Type.String())
+	}
+
+	return f
+}
+
+// tagParser::checkExported panics if the field cannot be assigned to
+func (p *tagParser) checkExported() {
+	if !p.f.IsExported() {
+		p.invalid("a capture into an unexported field")
+	}
+}
@@ -0,0 +1,127 @@
+package grammar
+
+import (
+	"testing"
+)
+
+// TestBuildPanics checks the panics of invalid types and grammar tags
+func TestBuildPanics(t *testing.T) {
+	type Inner struct {
+		X string `matcher:"@Ident"`
+	}
+
+	tests := []struct {
+		build func()
+		want  string
+	}{
+		{func() { Build[int]() }, "Calling Build() with a type that is not a struct: int"},
+		{func() {
+			type Untagged struct{ X string }
+			Build[Untagged]()
+		}, "Grammar of grammar.Untagged has no tagged fields"},
+		{func() {
+			type Empty struct {
+				X string `matcher:""`
+			}
+			Build[Empty]()
+		}, "Grammar tag of Empty.X has an empty expression"},
+		{func() {
+			type EmptyAlt struct {
+				X string `matcher:"@Ident |"`
+			}
+			Build[EmptyAlt]()
+		}, "Grammar tag of EmptyAlt.X has an empty expression"},
+		{func() {
+			type Unbalanced struct {
+				X string `matcher:"('a' @Ident"`
+			}
+			Build[Unbalanced]()
+		}, "Grammar tag of Unbalanced.X has an unbalanced ("},
+		{func() {
+			type Close struct {
+				X string `matcher:"@Ident )"`
+			}
+			Build[Close]()
+		}, "Grammar tag of Close.X has an unexpected ')'"},
+		{func() {
+			type Unexpected struct {
+				X string `matcher:"@Ident ;"`
+			}
+			Build[Unexpected]()
+		}, "Grammar tag of Unexpected.X has an unexpected ';'"},
+		{func() {
+			type Unterminated struct {
+				X string `matcher:"'a"`
+			}
+			Build[Unterminated]()
+		}, "Grammar tag of Unterminated.X has an unterminated literal"},
+		{func() {
+			type EmptyLiteral struct {
+				X string `matcher:"'' @Ident"`
+			}
+			Build[EmptyLiteral]()
+		}, "Grammar tag of EmptyLiteral.X has an empty literal"},
+		{func() {
+			type NotStruct struct {
+				X string `matcher:"@@"`
+			}
+			Build[NotStruct]()
+		}, "Grammar tag of NotStruct.X has a @@ capture into a field that is not a struct, or a pointer or slice of them"},
+		{func() {
+			type Unsupported struct {
+				X map[string]int `matcher:"@Ident"`
+			}
+			Build[Unsupported]()
+		}, "Grammar tag of Unsupported.X has a capture into a field of unsupported type map[string]int"},
+		{func() {
+			type Unexported struct {
+				x string `matcher:"@Ident"`
+			}
+			Build[Unexported]()
+		}, "Grammar tag of Unexported.x has a capture into an unexported field"},
+		{func() {
+			type UnexportedStruct struct {
+				inner *Inner `matcher:"@@"`
+			}
+			Build[UnexportedStruct]()
+		}, "Grammar tag of UnexportedStruct.inner has a capture into an unexported field"},
+		{func() {
+			type Unknown struct {
+				X string `matcher:"@Path"`
+			}
+			Build[Unknown]().Parse(nil)
+		}, "Grammar refers to an unknown terminal: Path"},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != test.want {
+					t.Errorf("panicked with %v, want %q", r, test.want)
+				}
+			}()
+
+			test.build()
+		}()
+	}
+}
+
+// TestBuildTags checks the tags that are ignored, and that an expression can
+// span the tags of several fields
+func TestBuildTags(t *testing.T) {
+	type Pair struct {
+		Skipped string
+		Ignored string `matcher:"-"`
+		Key     string `matcher:"( @Ident"`
+		Value   string `matcher:"  '=' @Int )?"`
+	}
+
+	p, err := Parse[Pair]([]byte("a = 1"))
+	if err != nil || p.Key != "a" || p.Value != "1" {
+		t.Errorf("parsed %+v, %v", p, err)
+	}
+
+	if p, err := Parse[Pair](nil); err != nil || *p != (Pair{}) {
+		t.Errorf("parsed %+v, %v", p, err)
+	}
+}
@@ -12,196 +12,339 @@ import (
 func (m *matcher) Reset() Matcher {
 	m.stack.Clear()
 
+	m.spans = nil
+
 	m.clearState()
 
 	m.hasResult = false
 
+	m.failPos = Pos{}
+
+	m.expected = nil
+
+	m.found = ""
+
 	return m
 }
 
 // Matcher::MatchZeroOrOneBytes
 func (m *matcher) MatchZeroOrOneBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneBytes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneBytes(match) }, expectBytes(match, false))
 	return m
 }
 
 // Matcher::MatchZeroOrOneRunes
 func (m *matcher) MatchZeroOrOneRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneRunes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneRunes(match) }, expectRunes(match, false))
 	return m
 }
 
 // Matcher::MatchZeroOrOneRune
 func (m *matcher) MatchZeroOrOneRune(match rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneRune(match) })
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneRune(match) }, expectRune(match))
 	return m
 }
 
 // Matcher::MatchZeroOrOneFunc
 func (m *matcher) MatchZeroOrOneFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneFunc(match) })
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneFunc(match) }, expectFunc(false))
+	return m
+}
+
+// Matcher::MatchZeroOrOneSet
+func (m *matcher) MatchZeroOrOneSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrOneFunc(match.Contains) }, expectSet(match, false))
 	return m
 }
 
 // Matcher::MatchZeroOrMoreBytes
 func (m *matcher) MatchZeroOrMoreBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchZeroOrMoreBytes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrMoreBytes(match) }, expectBytes(match, false))
 	return m
 }
 
 // Matcher::MatchZeroOrMoreRunes
 func (m *matcher) MatchZeroOrMoreRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchZeroOrMoreRunes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrMoreRunes(match) }, expectRunes(match, false))
 	return m
 }
 
 // Matcher::MatchZeroOrMoreFunc
 func (m *matcher) MatchZeroOrMoreFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchZeroOrMoreFunc(match) })
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrMoreFunc(match) }, expectFunc(false))
+	return m
+}
+
+// Matcher::MatchZeroOrMoreSet
+func (m *matcher) MatchZeroOrMoreSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.MatchZeroOrMoreFunc(match.Contains) }, expectSet(match, false))
 	return m
 }
 
 // Matcher::MatchOneBytes
 func (m *matcher) MatchOneBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchOneBytes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchOneBytes(match) }, expectBytes(match, false))
 	return m
 }
 
 // Matcher::MatchOneRunes
 func (m *matcher) MatchOneRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchOneRunes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchOneRunes(match) }, expectRunes(match, false))
 	return m
 }
 
 // Matcher::MatchOneRune
 func (m *matcher) MatchOneRune(match rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchOneRune(match) })
+	m.doMatch(func() bool { return m.lexer.MatchOneRune(match) }, expectRune(match))
 	return m
 }
 
 // Matcher::MatchOneFunc
 func (m *matcher) MatchOneFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchOneFunc(match) })
+	m.doMatch(func() bool { return m.lexer.MatchOneFunc(match) }, expectFunc(false))
+	return m
+}
+
+// Matcher::MatchOneSet
+func (m *matcher) MatchOneSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.MatchOneFunc(match.Contains) }, expectSet(match, false))
 	return m
 }
 
 // Matcher::MatchOneOrMoreBytes
 func (m *matcher) MatchOneOrMoreBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchOneOrMoreBytes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchOneOrMoreBytes(match) }, expectBytes(match, false))
 	return m
 }
 
 // Matcher::MatchOneOrMoreRuness
 func (m *matcher) MatchOneOrMoreRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchOneOrMoreRunes(match) })
+	m.doMatch(func() bool { return m.lexer.MatchOneOrMoreRunes(match) }, expectRunes(match, false))
 	return m
 }
 
 // Matcher::MatchOneOrMoreFunc
 func (m *matcher) MatchOneOrMoreFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchOneOrMoreFunc(match) })
+	m.doMatch(func() bool { return m.lexer.MatchOneOrMoreFunc(match) }, expectFunc(false))
+	return m
+}
+
+// Matcher::MatchOneOrMoreSet
+func (m *matcher) MatchOneOrMoreSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.MatchOneOrMoreFunc(match.Contains) }, expectSet(match, false))
 	return m
 }
 
 // MatchMinMaxBytes consumes a specified run of matching runes
 func (m *matcher) MatchMinMaxBytes(match []byte, min int, max int) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchMinMaxBytes(match, min, max) })
+	m.doMatch(func() bool { return m.lexer.MatchMinMaxBytes(match, min, max) }, expectBytes(match, false))
 	return m
 }
 
 // MatchMinMaxRunes consumes a specified run of matching runes
 func (m *matcher) MatchMinMaxRunes(match []rune, min int, max int) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchMinMaxRunes(match, min, max) })
+	m.doMatch(func() bool { return m.lexer.MatchMinMaxRunes(match, min, max) }, expectRunes(match, false))
 	return m
 }
 
 // MatchMinMaxFunc consumes a specified run of matching runes
 func (m *matcher) MatchMinMaxFunc(match lexer.MatchFn, min int, max int) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchMinMaxFunc(match, min, max) })
+	m.doMatch(func() bool { return m.lexer.MatchMinMaxFunc(match, min, max) }, expectFunc(false))
+	return m
+}
+
+// MatchMinMaxSet consumes a specified run of matching runes
+func (m *matcher) MatchMinMaxSet(match *RuneSet, min int, max int) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.MatchMinMaxFunc(match.Contains, min, max) }, expectSet(match, false))
 	return m
 }
 
 // Matcher::NonMatchZeroOrOneBytes
 func (m *matcher) NonMatchZeroOrOneBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrOneBytes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrOneBytes(match) }, expectBytes(match, true))
 	return m
 }
 
 // Matcher::NonMatchZeroOrOneRunes
 func (m *matcher) NonMatchZeroOrOneRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrOneRunes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrOneRunes(match) }, expectRunes(match, true))
 	return m
 }
 
 // Matcher::NonMatchZeroOrOneFunc
 func (m *matcher) NonMatchZeroOrOneFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrOneFunc(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrOneFunc(match) }, expectFunc(true))
+	return m
+}
+
+// Matcher::NonMatchZeroOrOneSet
+func (m *matcher) NonMatchZeroOrOneSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrOneFunc(match.Contains) }, expectSet(match, true))
 	return m
 }
 
 // Matcher::NonMatchZeroOrMoreBytes
 func (m *matcher) NonMatchZeroOrMoreBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrMoreBytes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrMoreBytes(match) }, expectBytes(match, true))
 	return m
 }
 
 // Matcher::NonMatchZeroOrMoreRunes
 func (m *matcher) NonMatchZeroOrMoreRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrMoreRunes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrMoreRunes(match) }, expectRunes(match, true))
 	return m
 }
 
 // Matcher::NonMatchZeroOrMoreFunc
 func (m *matcher) NonMatchZeroOrMoreFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrMoreFunc(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrMoreFunc(match) }, expectFunc(true))
+	return m
+}
+
+// Matcher::NonMatchZeroOrMoreSet
+func (m *matcher) NonMatchZeroOrMoreSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.NonMatchZeroOrMoreFunc(match.Contains) }, expectSet(match, true))
 	return m
 }
 
 // Matcher::NonMatchOneBytes
 func (m *matcher) NonMatchOneBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchOneBytes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchOneBytes(match) }, expectBytes(match, true))
 	return m
 }
 
 // Matcher::NonMatchOneRunes
 func (m *matcher) NonMatchOneRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchOneRunes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchOneRunes(match) }, expectRunes(match, true))
 	return m
 }
 
 // Matcher::NonMatchOneFunc
 func (m *matcher) NonMatchOneFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchOneFunc(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchOneFunc(match) }, expectFunc(true))
+	return m
+}
+
+// Matcher::NonMatchOneSet
+func (m *matcher) NonMatchOneSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.NonMatchOneFunc(match.Contains) }, expectSet(match, true))
 	return m
 }
 
 // Matcher::NonMatchOneOrMoreBytes
 func (m *matcher) NonMatchOneOrMoreBytes(match []byte) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchOneOrMoreBytes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchOneOrMoreBytes(match) }, expectBytes(match, true))
 	return m
 }
 
 // Matcher::NonMatchOneOrMoreRunes
 func (m *matcher) NonMatchOneOrMoreRunes(match []rune) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchOneOrMoreRunes(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchOneOrMoreRunes(match) }, expectRunes(match, true))
 	return m
 }
 
 // Matcher::NonMatchOneOrMoreFunc
 func (m *matcher) NonMatchOneOrMoreFunc(match lexer.MatchFn) MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.NonMatchOneOrMoreFunc(match) })
+	m.doMatch(func() bool { return m.lexer.NonMatchOneOrMoreFunc(match) }, expectFunc(true))
+	return m
+}
+
+// Matcher::NonMatchOneOrMoreSet
+func (m *matcher) NonMatchOneOrMoreSet(match *RuneSet) MatcherOperator {
+	m.doMatch(func() bool { return m.lexer.NonMatchOneOrMoreFunc(match.Contains) }, expectSet(match, true))
 	return m
 }
 
 // Matcher::MatchEOF
 func (m *matcher) MatchEOF() MatcherOperator {
-	m.doMatch(func() bool { return m.lexer.MatchEOF() })
+	m.doMatch(func() bool { return m.lexer.MatchEOF() }, expectEOF)
+	return m
+}
+
+// Matcher::MatchBOL
+func (m *matcher) MatchBOL() MatcherOperator {
+	m.doMatch(func() bool { return anchorMatchesLexer(anchorBOL, m.lexer) }, expectAnchor(anchorBOL))
+	return m
+}
+
+// Matcher::MatchEOL
+func (m *matcher) MatchEOL() MatcherOperator {
+	m.doMatch(func() bool { return anchorMatchesLexer(anchorEOL, m.lexer) }, expectAnchor(anchorEOL))
+	return m
+}
+
+// Matcher::MatchWordBoundary
+func (m *matcher) MatchWordBoundary() MatcherOperator {
+	m.doMatch(func() bool { return anchorMatchesLexer(anchorWordBoundary, m.lexer) }, expectAnchor(anchorWordBoundary))
+	return m
+}
+
+// Matcher::MatchNotWordBoundary
+func (m *matcher) MatchNotWordBoundary() MatcherOperator {
+	m.doMatch(func() bool { return anchorMatchesLexer(anchorNotWordBoundary, m.lexer) }, expectAnchor(anchorNotWordBoundary))
+	return m
+}
+
+// Matcher::MatchBOF
+func (m *matcher) MatchBOF() MatcherOperator {
+	m.doMatch(func() bool { return anchorMatchesLexer(anchorBOF, m.lexer) }, expectAnchor(anchorBOF))
+	return m
+}
+
+// Matcher::MatchPattern
+func (m *matcher) MatchPattern(p *Pattern) MatcherOperator {
+	m.doMatch(func() bool { return p.Match(m.lexer) }, expectPattern(p))
+	return m
+}
+
+// Matcher::MatchUntilString
+func (m *matcher) MatchUntilString(term string, inclusive bool) MatcherOperator {
+	checkUntilTerm(term)
+	m.doMatch(func() bool { return matchUntil(m.lexer, term, inclusive, noEscape) }, expectUntil(term))
+	return m
+}
+
+// Matcher::MatchUntilUnescaped
+func (m *matcher) MatchUntilUnescaped(term string, escape rune) MatcherOperator {
+	checkUntilTerm(term)
+	m.doMatch(func() bool { return matchUntil(m.lexer, term, false, escape) }, expectUntil(term))
+	return m
+}
+
+// Matcher::MatchUntilPattern
+func (m *matcher) MatchUntilPattern(p *Pattern) MatcherOperator {
+	m.doMatch(func() bool { return matchUntilPattern(m.lexer, p) }, expectUntilPattern(p))
+	return m
+}
+
+// Matcher::MatchBalanced
+func (m *matcher) MatchBalanced(open rune, close rune, opts BalancedOptions) MatcherOperator {
+	checkBalanced(open, close)
+	m.doMatch(func() bool { return matchBalanced(m.lexer, open, close, opts) }, expectBalanced(open, close))
+	return m
+}
+
+// Matcher::MatchList
+func (m *matcher) MatchList(item *Pattern, sep *Pattern, opts ListOptions) MatcherOperator {
+	checkListOptions(opts)
+	// The items and separators that fail are recorded by list
+	m.doMatch(func() bool { return m.list(item, sep, opts) }, nil)
 	return m
 }
 
 // Matcher::Begin
 func (m *matcher) Begin() Matcher {
-	return m.begin()
+	return m.begin(m.state.skipper)
+}
+
+// Matcher::BeginSkipping
+func (m *matcher) BeginSkipping(skipper *Pattern) Matcher {
+	return m.begin(skipper)
+}
+
+// Matcher::Lexeme
+func (m *matcher) Lexeme() Matcher {
+	return m.begin(nil)
 }
 
 // Matcher::End
@@ -218,7 +361,7 @@ func (m *matcher) EndMatchZeroOrOne() MatcherOperator {
 // Matcher::EndMatchOne
 func (m *matcher) EndMatchOne() MatcherOperator {
 
-	return m.End().MatchZeroOrOne()
+	return m.End().MatchOne()
 }
 
 // Matcher::Result
@@ -230,7 +373,21 @@ func (m *matcher) Result() bool {
 	result := m.state.result
 
 	if result == false {
-		m.lexer.Reset(m.state.marker)
+		m.rewind()
+
+		m.listItems = nil
+
+		m.err = &MatchError{Pos: m.failPos, Expected: m.expected, Found: m.found}
+	} else {
+		m.err = nil
+
+		m.listItems = m.spans
+
+		if m.input != nil {
+			m.startPos, _ = m.input.markerPos(m.state.marker)
+
+			m.endPos = m.input.pos
+		}
 	}
 
 	m.Reset()
@@ -238,6 +395,26 @@ func (m *matcher) Result() bool {
 	return result
 }
 
+// Matcher::StartPos
+func (m *matcher) StartPos() Pos {
+	return m.startPos
+}
+
+// Matcher::EndPos
+func (m *matcher) EndPos() Pos {
+	return m.endPos
+}
+
+// Matcher::Err
+func (m *matcher) Err() error {
+	return m.err
+}
+
+// Matcher::ListItems
+func (m *matcher) ListItems() []Span {
+	return m.listItems
+}
+
 /*****************************************************************************
  * Matcher End
  *****************************************************************************/
@@ -254,6 +431,12 @@ func (m *matcher) MatchOne() MatcherOperator {
 	return m
 }
 
+// MatcherEnd::Atomic
+func (m *matcher) Atomic() MatcherOperator {
+	m.end(endMatchOne)
+	return m
+}
+
 /*****************************************************************************
  * Matcher Operator
  *****************************************************************************/
@@ -273,7 +456,11 @@ func (m *matcher) Or() Matcher {
 	if m.hasResult == false {
 		panic("No operator executed before operand")
 	}
-	m.state.skipNext = m.state.skipAll == true || m.state.result == true
+	m.state.skipNext = m.state.skipAll == true || m.state.result == true || m.state.cut == true
+	if m.state.skipNext == false {
+		// Rewind any runes consumed by the failed alternative
+		m.rewind()
+	}
 	m.state.fn = matcherOr
 	return m
 }
@@ -291,3 +478,23 @@ func (m *matcher) OrBegin() Matcher {
 	m.Begin()
 	return m
 }
+
+// MatcherOperator::Possessive
+func (m *matcher) Possessive() MatcherOperator {
+	if m.hasResult == false {
+		panic("No operator executed before operand")
+	}
+	// Runs and groupings never give anything back outside of a Pattern
+	return m
+}
+
+// MatcherOperator::Cut
+func (m *matcher) Cut() MatcherOperator {
+	if m.hasResult == false {
+		panic("No operator executed before operand")
+	}
+	if m.state.skipAll == false && m.state.result == true {
+		m.state.cut = true
+	}
+	return m
+}
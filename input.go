@@ -0,0 +1,465 @@
+package matcher
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// Pos is a position within the input of a lexer
+type Pos struct {
+	Filename string // Filename, if any
+	Offset   int    // Byte offset, starting at 0
+	Line     int    // Line number, starting at 1
+	Column   int    // Column in runes, starting at 1
+}
+
+// String returns the position as "filename:line:column", or "line:column"
+// if there is no filename
+func (p Pos) String() string {
+	s := strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+
+	if p.Filename != "" {
+		s = p.Filename + ":" + s
+	}
+
+	return s
+}
+
+// IsValid returns true if the position is known, i.e. it came from an Input
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+// Input is a lexer.Lexer adapter that tracks the position of the lexer as
+// runes are consumed, and the position of each token it emits.  Matchers,
+// Patterns and Rules used with an Input report positions in their results
+// and diagnostics.
+//
+// The Match* functions of an Input consume runes one at a time through
+// NextRune, so that no consumed rune goes uncounted.  Invalid UTF-8 bytes
+// are counted as one byte each.
+//
+// The markers created at the same offset of a token are the same marker, so
+// an Input tracks at most one marker per rune of the current token, however
+// often a Matcher or Pattern backtracks.
+type Input struct {
+	lexer.Lexer
+	pos     Pos
+	prev    rune
+	start   Pos
+	markers map[*lexer.Marker]inputMarker
+	offsets map[int]*lexer.Marker
+	spans   [][2]Pos
+	token   [2]Pos
+	src     []byte
+}
+
+// inputMarker is the position of an Input when a marker was created
+type inputMarker struct {
+	pos  Pos
+	prev rune
+}
+
+// NewInputFromBytes creates a new lexer over the specified bytes, as
+// lexer.NewFromBytes, wrapped in an Input.  The state functions receive the
+// Input as their lexer.
+func NewInputFromBytes(start lexer.StateFn, input []byte, cap int) *Input {
+	i := &Input{
+		pos:     Pos{Line: 1, Column: 1},
+		prev:    lexer.RuneEOF,
+		markers: make(map[*lexer.Marker]inputMarker),
+		offsets: make(map[int]*lexer.Marker),
+		src:     input,
+	}
+
+	i.start = i.pos
+
+	i.token = [2]Pos{i.pos, i.pos}
+
+	i.Lexer = lexer.NewFromBytes(i.wrap(start), input, cap)
+
+	return i
+}
+
+// Input::wrap wraps a state function so that it, and the state functions it
+// returns, receive the Input instead of the lexer
+func (i *Input) wrap(fn lexer.StateFn) lexer.StateFn {
+	if fn == nil {
+		return nil
+	}
+
+	return func(l lexer.Lexer) lexer.StateFn {
+		return i.wrap(fn(i))
+	}
+}
+
+// SetFilename sets the filename reported in positions
+func (i *Input) SetFilename(filename string) *Input {
+	i.pos.Filename = filename
+
+	i.start.Filename = filename
+
+	i.token[0].Filename, i.token[1].Filename = filename, filename
+
+	return i
+}
+
+// Pos returns the current position of the lexer
+func (i *Input) Pos() Pos {
+	return i.pos
+}
+
+// TokenStartPos returns the position of the first rune of the token most
+// recently returned by NextToken
+func (i *Input) TokenStartPos() Pos {
+	return i.token[0]
+}
+
+// TokenEndPos returns the position following the last rune of the token most
+// recently returned by NextToken
+func (i *Input) TokenEndPos() Pos {
+	return i.token[1]
+}
+
+// PrevRune returns the rune before the current position of the lexer, or
+// lexer.RuneEOF at the start of the input
+func (i *Input) PrevRune() rune {
+	return i.prev
+}
+
+// Input::markerPos returns the position at which a marker was created
+func (i *Input) markerPos(m *lexer.Marker) (Pos, bool) {
+	mk, ok := i.markers[m]
+
+	return mk.pos, ok
+}
+
+// Input::buffered returns the input following the current position
+func (i *Input) buffered() []byte {
+	return i.src[i.pos.Offset:]
+}
+
+// Input::advance updates the position past a consumed rune
+func (i *Input) advance(r rune) {
+	if r == lexer.RuneEOF {
+		return
+	}
+
+	i.prev = r
+
+	if w := utf8.RuneLen(r); w > 0 && r != utf8.RuneError {
+		i.pos.Offset += w
+	} else {
+		i.pos.Offset++
+	}
+
+	if r == '\n' {
+		i.pos.Line++
+		i.pos.Column = 1
+	} else {
+		i.pos.Column++
+	}
+}
+
+// Input::emitted records the span of an emitted token, and starts the next
+// token at the current position.  Markers do not survive an emit.
+func (i *Input) emitted() {
+	i.spans = append(i.spans, [2]Pos{i.start, i.pos})
+
+	i.ignored()
+}
+
+// Input::ignored starts the next token at the current position
+func (i *Input) ignored() {
+	i.start = i.pos
+
+	for m := range i.markers {
+		delete(i.markers, m)
+	}
+
+	for offset := range i.offsets {
+		delete(i.offsets, offset)
+	}
+}
+
+// Input::run consumes a run of at least min, and at most max, matching runes.
+// max < 0 means no limit.  Consumed runes are rewound if the run is too short.
+func (i *Input) run(fn lexer.MatchFn, min int, max int) bool {
+	m, pos, prev := i.Lexer.Marker(), i.pos, i.prev
+
+	n := 0
+
+	for (max < 0 || n < max) && i.PeekRune(0) != lexer.RuneEOF && fn(i.PeekRune(0)) {
+		i.NextRune()
+		n++
+	}
+
+	if n < min {
+		i.Lexer.Reset(m)
+		i.pos, i.prev = pos, prev
+		return false
+	}
+
+	return true
+}
+
+// Input::NextRune
+func (i *Input) NextRune() rune {
+	r := i.Lexer.NextRune()
+
+	i.advance(r)
+
+	return r
+}
+
+// Input::Marker returns the marker already created at the current offset of
+// the token, if any, as the lexer is in the same state whenever it is there
+func (i *Input) Marker() *lexer.Marker {
+	if m, ok := i.offsets[i.pos.Offset]; ok {
+		return m
+	}
+
+	m := i.Lexer.Marker()
+
+	i.markers[m] = inputMarker{i.pos, i.prev}
+
+	i.offsets[i.pos.Offset] = m
+
+	return m
+}
+
+// Input::Reset
+func (i *Input) Reset(m *lexer.Marker) {
+	mk, ok := i.markers[m]
+
+	if !ok {
+		panic("Resetting an Input to a marker it did not create, or from before the last token")
+	}
+
+	i.Lexer.Reset(m)
+
+	i.pos, i.prev = mk.pos, mk.prev
+}
+
+// Input::EmitToken
+func (i *Input) EmitToken(t lexer.TokenType) {
+	i.Lexer.EmitToken(t)
+	i.emitted()
+}
+
+// Input::EmitTokenWithBytes
+func (i *Input) EmitTokenWithBytes(t lexer.TokenType) {
+	i.Lexer.EmitTokenWithBytes(t)
+	i.emitted()
+}
+
+// Input::IgnoreToken
+func (i *Input) IgnoreToken() {
+	i.Lexer.IgnoreToken()
+	i.ignored()
+}
+
+// Input::EmitEOF
+func (i *Input) EmitEOF() {
+	i.Lexer.EmitEOF()
+	i.emitted()
+}
+
+// Input::NextToken
+func (i *Input) NextToken() *lexer.Token {
+	t := i.Lexer.NextToken()
+
+	if len(i.spans) > 0 {
+		i.token = i.spans[0]
+		i.spans = i.spans[1:]
+	} else {
+		i.token = [2]Pos{i.pos, i.pos}
+	}
+
+	return t
+}
+
+// Input::MatchZeroOrOneBytes
+func (i *Input) MatchZeroOrOneBytes(match []byte) bool {
+	return i.run(bytesFn(match), 0, 1)
+}
+
+// Input::MatchZeroOrOneRunes
+func (i *Input) MatchZeroOrOneRunes(match []rune) bool {
+	return i.run(runesFn(match), 0, 1)
+}
+
+// Input::MatchZeroOrOneRune
+func (i *Input) MatchZeroOrOneRune(match rune) bool {
+	return i.run(runeFn(match), 0, 1)
+}
+
+// Input::MatchZeroOrOneFunc
+func (i *Input) MatchZeroOrOneFunc(match lexer.MatchFn) bool {
+	return i.run(match, 0, 1)
+}
+
+// Input::MatchZeroOrMoreBytes
+func (i *Input) MatchZeroOrMoreBytes(match []byte) bool {
+	return i.run(bytesFn(match), 0, -1)
+}
+
+// Input::MatchZeroOrMoreRunes
+func (i *Input) MatchZeroOrMoreRunes(match []rune) bool {
+	return i.run(runesFn(match), 0, -1)
+}
+
+// Input::MatchZeroOrMoreFunc
+func (i *Input) MatchZeroOrMoreFunc(match lexer.MatchFn) bool {
+	return i.run(match, 0, -1)
+}
+
+// Input::MatchOneBytes
+func (i *Input) MatchOneBytes(match []byte) bool {
+	return i.run(bytesFn(match), 1, 1)
+}
+
+// Input::MatchOneRunes
+func (i *Input) MatchOneRunes(match []rune) bool {
+	return i.run(runesFn(match), 1, 1)
+}
+
+// Input::MatchOneRune
+func (i *Input) MatchOneRune(match rune) bool {
+	return i.run(runeFn(match), 1, 1)
+}
+
+// Input::MatchOneFunc
+func (i *Input) MatchOneFunc(match lexer.MatchFn) bool {
+	return i.run(match, 1, 1)
+}
+
+// Input::MatchOneOrMoreBytes
+func (i *Input) MatchOneOrMoreBytes(match []byte) bool {
+	return i.run(bytesFn(match), 1, -1)
+}
+
+// Input::MatchOneOrMoreRunes
+func (i *Input) MatchOneOrMoreRunes(match []rune) bool {
+	return i.run(runesFn(match), 1, -1)
+}
+
+// Input::MatchOneOrMoreFunc
+func (i *Input) MatchOneOrMoreFunc(match lexer.MatchFn) bool {
+	return i.run(match, 1, -1)
+}
+
+// Input::MatchMinMaxBytes
+func (i *Input) MatchMinMaxBytes(match []byte, min int, max int) bool {
+	return i.run(bytesFn(match), min, max)
+}
+
+// Input::MatchMinMaxRunes
+func (i *Input) MatchMinMaxRunes(match []rune, min int, max int) bool {
+	return i.run(runesFn(match), min, max)
+}
+
+// Input::MatchMinMaxFunc
+func (i *Input) MatchMinMaxFunc(match lexer.MatchFn, min int, max int) bool {
+	return i.run(match, min, max)
+}
+
+// Input::NonMatchZeroOrOneBytes
+func (i *Input) NonMatchZeroOrOneBytes(match []byte) bool {
+	return i.run(notFn(bytesFn(match)), 0, 1)
+}
+
+// Input::NonMatchZeroOrOneRunes
+func (i *Input) NonMatchZeroOrOneRunes(match []rune) bool {
+	return i.run(notFn(runesFn(match)), 0, 1)
+}
+
+// Input::NonMatchZeroOrOneFunc
+func (i *Input) NonMatchZeroOrOneFunc(match lexer.MatchFn) bool {
+	return i.run(notFn(match), 0, 1)
+}
+
+// Input::NonMatchZeroOrMoreBytes
+func (i *Input) NonMatchZeroOrMoreBytes(match []byte) bool {
+	return i.run(notFn(bytesFn(match)), 0, -1)
+}
+
+// Input::NonMatchZeroOrMoreRunes
+func (i *Input) NonMatchZeroOrMoreRunes(match []rune) bool {
+	return i.run(notFn(runesFn(match)), 0, -1)
+}
+
+// Input::NonMatchZeroOrMoreFunc
+func (i *Input) NonMatchZeroOrMoreFunc(match lexer.MatchFn) bool {
+	return i.run(notFn(match), 0, -1)
+}
+
+// Input::NonMatchOneBytes
+func (i *Input) NonMatchOneBytes(match []byte) bool {
+	return i.run(notFn(bytesFn(match)), 1, 1)
+}
+
+// Input::NonMatchOneRunes
+func (i *Input) NonMatchOneRunes(match []rune) bool {
+	return i.run(notFn(runesFn(match)), 1, 1)
+}
+
+// Input::NonMatchOneFunc
+func (i *Input) NonMatchOneFunc(match lexer.MatchFn) bool {
+	return i.run(notFn(match), 1, 1)
+}
+
+// Input::NonMatchOneOrMoreBytes
+func (i *Input) NonMatchOneOrMoreBytes(match []byte) bool {
+	return i.run(notFn(bytesFn(match)), 1, -1)
+}
+
+// Input::NonMatchOneOrMoreRunes
+func (i *Input) NonMatchOneOrMoreRunes(match []rune) bool {
+	return i.run(notFn(runesFn(match)), 1, -1)
+}
+
+// Input::NonMatchOneOrMoreFunc
+func (i *Input) NonMatchOneOrMoreFunc(match lexer.MatchFn) bool {
+	return i.run(notFn(match), 1, -1)
+}
+
+// bytesFn returns a lexer.MatchFn matching any of the bytes
+func bytesFn(match []byte) lexer.MatchFn {
+	return func(r rune) bool {
+		for _, b := range match {
+			if rune(b) == r {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// runesFn returns a lexer.MatchFn matching any of the runes
+func runesFn(match []rune) lexer.MatchFn {
+	return func(r rune) bool {
+		for _, m := range match {
+			if m == r {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// runeFn returns a lexer.MatchFn matching the rune
+func runeFn(match rune) lexer.MatchFn {
+	return func(r rune) bool {
+		return r == match
+	}
+}
+
+// notFn returns a lexer.MatchFn matching the runes fn does not
+func notFn(fn lexer.MatchFn) lexer.MatchFn {
+	return func(r rune) bool {
+		return !fn(r)
+	}
+}
@@ -0,0 +1,164 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// newTestInput creates an Input over s whose state function does nothing
+func newTestInput(s string) *Input {
+	return NewInputFromBytes(func(lexer.Lexer) lexer.StateFn { return nil }, []byte(s), 1)
+}
+
+// TestInputPos checks line, column and offset tracking, with multibyte runes
+func TestInputPos(t *testing.T) {
+	i := newTestInput("aé\nb")
+
+	want := []string{"1:2", "1:3", "2:1", "2:2", "2:2"}
+	offsets := []int{1, 3, 4, 5, 5}
+
+	for n := range want {
+		i.NextRune()
+
+		if pos := i.Pos(); pos.String() != want[n] || pos.Offset != offsets[n] {
+			t.Errorf("after %d runes: %s at %d, want %s at %d", n+1, pos, pos.Offset, want[n], offsets[n])
+		}
+	}
+
+	if r := i.PrevRune(); r != 'b' {
+		t.Errorf("PrevRune() = %q, want 'b'", r)
+	}
+
+	if pos := i.SetFilename("x.txt").Pos().String(); pos != "x.txt:2:2" {
+		t.Errorf("Pos() = %s, want x.txt:2:2", pos)
+	}
+}
+
+// TestInputMarkers checks that Reset restores the position, and that the
+// markers created at the same offset are shared
+func TestInputMarkers(t *testing.T) {
+	i := newTestInput("ab\ncd")
+
+	start := i.Marker()
+
+	i.NextRune()
+	i.NextRune()
+	i.NextRune()
+
+	mid := i.Marker()
+
+	for n := 0; n < 100; n++ {
+		i.Reset(start)
+
+		if m := i.Marker(); m != start {
+			t.Fatalf("Marker() at the start created a new marker")
+		}
+
+		i.MatchOneRune('a')
+		i.MatchOneRune('b')
+		i.MatchOneRune('\n')
+
+		if m := i.Marker(); m != mid {
+			t.Fatalf("Marker() at 2:1 created a new marker")
+		}
+	}
+
+	if len(i.markers) != 2 || len(i.offsets) != 2 {
+		t.Errorf("%d markers at %d offsets, want 2", len(i.markers), len(i.offsets))
+	}
+
+	i.NextRune()
+
+	i.Reset(mid)
+
+	if pos, prev := i.Pos().String(), i.PrevRune(); pos != "2:1" || prev != '\n' {
+		t.Errorf("Reset() to 2:1 gave %s after %q", pos, prev)
+	}
+
+	i.IgnoreToken()
+
+	if len(i.markers) != 0 || len(i.offsets) != 0 {
+		t.Errorf("%d markers at %d offsets after IgnoreToken(), want 0", len(i.markers), len(i.offsets))
+	}
+
+	if m := i.Marker(); m == mid {
+		t.Errorf("Marker() after IgnoreToken() reused a marker of the previous token")
+	}
+}
+
+// TestInputResetPanics checks that Reset panics on a marker the Input did
+// not create, or that was created before the last token
+func TestInputResetPanics(t *testing.T) {
+	tests := map[string]func(){
+		"lexer marker": func() {
+			i := newTestInput("ab")
+			i.Reset(i.Lexer.Marker())
+		},
+		"other Input": func() {
+			i := newTestInput("ab")
+			i.Reset(newTestInput("ab").Marker())
+		},
+		"previous token": func() {
+			i := newTestInput("ab")
+			m := i.Marker()
+			i.NextRune()
+			i.EmitToken(lexer.TokenTypeEOF + 1)
+			i.Reset(m)
+		},
+	}
+
+	for name, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", name)
+				}
+			}()
+
+			fn()
+		}()
+	}
+}
+
+// TestMatcherPositions checks StartPos() and EndPos() of successive matches
+// over an Input, across emitted and ignored tokens, and that a failed match
+// after an emit rewinds to the start of the next token
+func TestMatcherPositions(t *testing.T) {
+	in := newTestInput("ab\ncd ef")
+	m := New(in)
+
+	positions := func() string {
+		return m.StartPos().String() + "-" + m.EndPos().String()
+	}
+
+	if !m.MatchOneRune('a').And().MatchOneRune('b').Result() || positions() != "1:1-1:3" {
+		t.Errorf("first match at %s, want 1:1-1:3", positions())
+	}
+
+	in.EmitToken(1)
+
+	if m.MatchOneRune('\n').And().MatchOneRune('x').Result() {
+		t.Errorf("matched after an emit")
+	}
+
+	if pos := in.Pos().String(); pos != "1:3" {
+		t.Errorf("failed match after an emit left the input at %s, want 1:3", pos)
+	}
+
+	if !m.MatchOneRune('\n').And().MatchOneRune('c').Result() || positions() != "1:3-2:2" {
+		t.Errorf("match after an emit at %s, want 1:3-2:2", positions())
+	}
+
+	in.IgnoreToken()
+
+	if !m.MatchOneRune('d').And().MatchOneRune(' ').Result() || positions() != "2:2-2:4" {
+		t.Errorf("match after an ignore at %s, want 2:2-2:4", positions())
+	}
+
+	in.EmitToken(1)
+
+	if !m.MatchOneRune('e').AndBegin().MatchOneRune('x').Or().MatchOneRune('f').EndMatchOne().Result() || positions() != "2:4-2:6" {
+		t.Errorf("match of a grouping after an emit at %s, want 2:4-2:6", positions())
+	}
+}
@@ -0,0 +1,223 @@
+package matcher
+
+import (
+	"strconv"
+)
+
+// ListOptions configures MatchList
+type ListOptions struct {
+	Min           int  // The least number of items
+	Max           int  // The most number of items, 0 for no limit
+	AllowTrailing bool // Allows a separator after the last item
+}
+
+// Span is the extent of an item matched by MatchList.  Only an Input tracks
+// positions, so the Span of an item matched from any other lexer is the zero
+// Span, whose positions are not valid.
+type Span struct {
+	Start Pos // The position of the first rune of the item
+	End   Pos // The position following the last rune of the item
+}
+
+// checkListOptions panics if the options of a MatchList are invalid
+func checkListOptions(opts ListOptions) {
+	switch {
+	case opts.Min < 0 || opts.Max < 0:
+		panic("ListOptions has a negative Min or Max")
+	case opts.Max > 0 && opts.Max < opts.Min:
+		panic("ListOptions has a Max less than its Min")
+	}
+}
+
+// listMax returns the most number of items of a list, -1 meaning no limit
+func listMax(opts ListOptions) int {
+	if opts.Max == 0 {
+		return -1
+	}
+
+	return opts.Max
+}
+
+// listOps are the operations needed to match a list
+type listOps struct {
+	item   func() (int, bool) // Matches an item, returning the runes consumed
+	sep    func() (int, bool) // Matches a separator, returning the runes consumed
+	mark   func() func()      // Returns a func rewinding to the current position
+	record func()             // Records an item, once matched
+}
+
+// matchList matches a list of items with the operations.  Each item after the
+// first is preceded by a separator, which is given back if the item does not
+// match, unless trailing is true.  A separator and item matching nothing end
+// the list.  Nothing is consumed if there are fewer than min items.
+func matchList(ops listOps, min int, max int, trailing bool) bool {
+	rewind := ops.mark()
+
+	count := 0
+
+	for max < 0 || count < max {
+		back := ops.mark()
+
+		s := 0
+
+		if count > 0 {
+			var ok bool
+
+			if s, ok = ops.sep(); !ok {
+				break
+			}
+		}
+
+		i, ok := ops.item()
+
+		if !ok || (count > 0 && s+i == 0) {
+			back()
+			break
+		}
+
+		if ops.record != nil {
+			ops.record()
+		}
+
+		count++
+	}
+
+	if count < min {
+		rewind()
+		return false
+	}
+
+	if count > 0 && trailing {
+		back := ops.mark()
+
+		if _, ok := ops.sep(); !ok {
+			back()
+		}
+	}
+
+	return true
+}
+
+// matcher::list matches a list of Patterns against the lexer, applying the
+// skipper of the current grouping before each item and separator, and
+// recording the span of each item, which is only known if the lexer is an
+// Input
+func (m *matcher) list(item *Pattern, sep *Pattern, opts ListOptions) bool {
+	var start Pos
+
+	// Failures are recorded, so that Err() lists a missing separator or item
+	// along with whatever fails after the list
+	match := func(p *Pattern) (int, bool) {
+		if m.state.skipper != nil {
+			skipPattern(m.lexer, m.state.skipper)
+		}
+
+		if m.input != nil {
+			start = m.input.pos
+		}
+
+		n, ok := p.match(m.lexer)
+		if !ok {
+			m.fail(expectPattern(p))
+		}
+		return n, ok
+	}
+
+	ops := listOps{
+		item: func() (int, bool) { return match(item) },
+		sep:  func() (int, bool) { return match(sep) },
+		mark: func() func() {
+			mk := m.lexer.Marker()
+			spans := len(m.spans)
+			return func() {
+				m.lexer.Reset(mk)
+				m.spans = m.spans[:spans]
+			}
+		},
+	}
+
+	ops.record = func() {
+		var end Pos
+
+		if m.input != nil {
+			end = m.input.pos
+		}
+
+		m.spans = append(m.spans, Span{start, end})
+	}
+
+	return matchList(ops, opts.Min, listMax(opts), opts.AllowTrailing)
+}
+
+// executor::list matches a list node, applying its skipper before each item
+// and separator
+func (e *executor) list(n *node) bool {
+	match := func(p *node) (int, bool) {
+		if n.skip != nil {
+			e.skip(n.skip)
+		}
+
+		m := e.n
+		ok := e.match(p)
+		return e.n - m, ok
+	}
+
+	ops := listOps{
+		item: func() (int, bool) { return match(n.item) },
+		sep:  func() (int, bool) { return match(n.sep) },
+		mark: func() func() {
+			m := e.mark()
+			return func() { e.reset(m) }
+		},
+	}
+
+	return matchList(ops, n.min, n.max, n.trailing)
+}
+
+// listString returns a list node in regex syntax, i.e. a(?:,a)*(?:,)?
+func listString(n *node) string {
+	item := "(?:" + n.item.termsString() + ")"
+	sep := "(?:" + n.sep.termsString() + ")"
+
+	max := n.max
+	if max > 0 {
+		max--
+	}
+
+	min := n.min - 1
+	if min < 0 {
+		min = 0
+	}
+
+	s := item + "(?:" + sep + item + ")" + quantifierString(min, max)
+
+	if n.trailing {
+		s += sep + "?"
+	}
+
+	if n.min == 0 {
+		s = "(?:" + s + ")?"
+	}
+
+	return s
+}
+
+// quantifierString returns a quantifier in regex syntax, i.e. * or {2,3}
+func quantifierString(min int, max int) string {
+	switch {
+	case min == 1 && max == 1:
+		return ""
+	case min == 0 && max == 1:
+		return "?"
+	case min == 0 && max < 0:
+		return "*"
+	case min == 1 && max < 0:
+		return "+"
+	case max < 0:
+		return "{" + strconv.Itoa(min) + ",}"
+	case min == max:
+		return "{" + strconv.Itoa(min) + "}"
+	}
+
+	return "{" + strconv.Itoa(min) + "," + strconv.Itoa(max) + "}"
+}
@@ -0,0 +1,124 @@
+package matcher
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// Items of digits, separated by commas, for list tests
+var (
+	listItem = Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9"))
+	})
+
+	listSep = Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneRune(',')
+	})
+)
+
+// TestMatchList checks the number of items and trailing separators of
+// MatchList, with a Matcher and a Pattern
+func TestMatchList(t *testing.T) {
+	list := func(opts ListOptions) func(Matcher) MatcherOperator {
+		return func(m Matcher) MatcherOperator { return m.MatchList(listItem, listSep, opts) }
+	}
+
+	runOperandTests(t, []operandTest{
+		{"default", list(ListOptions{}),
+			map[string]string{"1,22,3x": "1,22,3", "1,x": "1", "1,": "1", "x": "", "": ""}},
+		{"Min", list(ListOptions{Min: 2}),
+			map[string]string{"1,2": "1,2", "1,2,3": "1,2,3", "1,x": "!", "1": "!", "": "!"}},
+		{"Max", list(ListOptions{Max: 2}),
+			map[string]string{"1,2,3": "1,2", "1": "1", "": ""}},
+		{"Min and Max", list(ListOptions{Min: 1, Max: 1}),
+			map[string]string{"1,2": "1", "x": "!"}},
+		{"AllowTrailing", list(ListOptions{AllowTrailing: true}),
+			map[string]string{"1,2,x": "1,2,", "1,2": "1,2", ",": "", "1,,": "1,"}},
+		{"AllowTrailing and Max", list(ListOptions{Max: 2, AllowTrailing: true}),
+			map[string]string{"1,2,3": "1,2,"}},
+		{"followed", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('[').And().MatchList(listItem, listSep, ListOptions{AllowTrailing: true}).And().MatchOneRune(']')
+		}, map[string]string{"[1,2]": "[1,2]", "[1,2,]": "[1,2,]", "[]": "[]", "[,]": "!", "[1,,]": "!"}},
+	})
+}
+
+// TestMatchListItems checks the spans of the items of the last successful
+// Result(), that they are dropped with the items of failed alternatives, and
+// that they are zero over a lexer that is not an Input
+func TestMatchListItems(t *testing.T) {
+	var items []string
+
+	start := func(l lexer.Lexer) lexer.StateFn {
+		m := New(l)
+
+		skipping := m.BeginSkipping(Compile(func(m Matcher) MatcherOperator { return m.MatchOneOrMoreRunes([]rune(" \n")) }))
+
+		if skipping.MatchList(listItem, listSep, ListOptions{}).And().MatchOneRune(';').
+			Or().MatchList(listItem, listSep, ListOptions{}).And().MatchOneRune('.').
+			EndMatchOne().Result() {
+			for _, span := range m.ListItems() {
+				items = append(items, fmt.Sprintf("%d-%d", span.Start.Offset, span.End.Offset))
+			}
+		}
+
+		l.EmitEOF()
+		return nil
+	}
+
+	NewInputFromBytes(start, []byte("1, 22 ,\n333."), 1).NextToken()
+
+	if got := fmt.Sprint(items); got != "[0-1 3-5 8-11]" {
+		t.Errorf("ListItems() = %s, want [0-1 3-5 8-11]", got)
+	}
+
+	m := New(NewInputFromBytes(nil, []byte("1,2"), 1))
+
+	if m.MatchList(listItem, listSep, ListOptions{}).And().MatchOneRune(';').Result() || m.ListItems() != nil {
+		t.Errorf("ListItems() = %v after a failed Result(), want nil", m.ListItems())
+	}
+
+	if !m.MatchList(listItem, listSep, ListOptions{}).Result() || len(m.ListItems()) != 2 {
+		t.Errorf("ListItems() = %v, want 2 items", m.ListItems())
+	}
+
+	// Other lexers do not track positions, so each span is zero
+	var spans []Span
+
+	start = func(l lexer.Lexer) lexer.StateFn {
+		if m := New(l); m.MatchList(listItem, listSep, ListOptions{}).Result() {
+			spans = m.ListItems()
+		}
+
+		l.EmitEOF()
+		return nil
+	}
+
+	lexer.NewFromBytes(start, []byte("1,2"), 1).NextToken()
+
+	if len(spans) != 2 || spans[0] != (Span{}) || spans[1] != (Span{}) {
+		t.Errorf("ListItems() = %v over a lexer, want 2 zero spans", spans)
+	}
+}
+
+// TestMatchListPanics checks that invalid options panic
+func TestMatchListPanics(t *testing.T) {
+	tests := map[string]ListOptions{
+		"negative Min": {Min: -1},
+		"negative Max": {Max: -1},
+		"Max < Min":    {Min: 3, Max: 2},
+	}
+
+	for name, opts := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", name)
+				}
+			}()
+
+			matchMatcher(func(m Matcher) MatcherOperator { return m.MatchList(listItem, listSep, opts) }, "1")
+		}()
+	}
+}
@@ -19,6 +19,9 @@ type Matcher interface {
 	// MatchZeroOrOneFunc consumes the next rune if it matches, always returning true
 	MatchZeroOrOneFunc(lexer.MatchFn) MatcherOperator
 
+	// MatchZeroOrOneSet consumes the next rune if it matches, always returning true
+	MatchZeroOrOneSet(*RuneSet) MatcherOperator
+
 	// MatchZeroOrMoreBytes consumes a run of matching runes, always returning true
 	MatchZeroOrMoreBytes([]byte) MatcherOperator
 
@@ -28,6 +31,9 @@ type Matcher interface {
 	// MatchZeroOrMoreFunc consumes a run of matching runes, always returning true
 	MatchZeroOrMoreFunc(lexer.MatchFn) MatcherOperator
 
+	// MatchZeroOrMoreSet consumes a run of matching runes, always returning true
+	MatchZeroOrMoreSet(*RuneSet) MatcherOperator
+
 	// MatchOneBytes consumes the next rune if its in the list of bytes
 	MatchOneBytes([]byte) MatcherOperator
 
@@ -40,6 +46,9 @@ type Matcher interface {
 	// MatchOneFunc consumes the next rune if it matches
 	MatchOneFunc(lexer.MatchFn) MatcherOperator
 
+	// MatchOneSet consumes the next rune if its in the set
+	MatchOneSet(*RuneSet) MatcherOperator
+
 	// MatchOneOrMoreBytes consumes a run of matching runes
 	MatchOneOrMoreBytes([]byte) MatcherOperator
 
@@ -49,6 +58,9 @@ type Matcher interface {
 	// MatchOneOrMoreFunc consumes a run of matching runes
 	MatchOneOrMoreFunc(lexer.MatchFn) MatcherOperator
 
+	// MatchOneOrMoreSet consumes a run of matching runes
+	MatchOneOrMoreSet(*RuneSet) MatcherOperator
+
 	// MatchMinMaxBytes consumes a specified run of matching runes
 	MatchMinMaxBytes([]byte, int, int) MatcherOperator
 
@@ -58,6 +70,9 @@ type Matcher interface {
 	// MatchMinMaxFunc consumes a specified run of matching runes
 	MatchMinMaxFunc(lexer.MatchFn, int, int) MatcherOperator
 
+	// MatchMinMaxSet consumes a specified run of matching runes
+	MatchMinMaxSet(*RuneSet, int, int) MatcherOperator
+
 	// NonMatchZeroOrOneBytes consumes the next rune if it does not match, always returning true
 	NonMatchZeroOrOneBytes([]byte) MatcherOperator
 
@@ -67,6 +82,9 @@ type Matcher interface {
 	// NonMatchZeroOrOneFunc consumes the next rune if it does not match, always returning true
 	NonMatchZeroOrOneFunc(lexer.MatchFn) MatcherOperator
 
+	// NonMatchZeroOrOneSet consumes the next rune if it does not match, always returning true
+	NonMatchZeroOrOneSet(*RuneSet) MatcherOperator
+
 	// NonMatchZeroOrMoreBytes consumes a run of non-matching runes, always returning true
 	NonMatchZeroOrMoreBytes([]byte) MatcherOperator
 
@@ -76,6 +94,9 @@ type Matcher interface {
 	// NonMatchZeroOrMoreFunc consumes a run of non-matching runes, always returning true
 	NonMatchZeroOrMoreFunc(lexer.MatchFn) MatcherOperator
 
+	// NonMatchZeroOrMoreSet consumes a run of non-matching runes, always returning true
+	NonMatchZeroOrMoreSet(*RuneSet) MatcherOperator
+
 	// NonMatchOneBytes consumes the next rune if its NOT in the list of bytes
 	NonMatchOneBytes([]byte) MatcherOperator
 
@@ -85,6 +106,9 @@ type Matcher interface {
 	// NonMatchOneFunc consumes the next rune if it does NOT match
 	NonMatchOneFunc(lexer.MatchFn) MatcherOperator
 
+	// NonMatchOneSet consumes the next rune if its NOT in the set
+	NonMatchOneSet(*RuneSet) MatcherOperator
+
 	// NonMatchOneOrMoreBytes consumes a run of non-matching runes
 	NonMatchOneOrMoreBytes([]byte) MatcherOperator
 
@@ -94,12 +118,69 @@ type Matcher interface {
 	// NonMatchOneOrMoreFunc consumes a run of non-matching runes
 	NonMatchOneOrMoreFunc(lexer.MatchFn) MatcherOperator
 
+	// NonMatchOneOrMoreSet consumes a run of non-matching runes
+	NonMatchOneOrMoreSet(*RuneSet) MatcherOperator
+
 	// MatchEOF tries to match the next rune against RuneEOF
 	MatchEOF() MatcherOperator
 
+	// MatchBOL tries to match the start of a line, i.e. the start of the input
+	// or the position after a '\n', without consuming anything
+	MatchBOL() MatcherOperator
+
+	// MatchEOL tries to match the end of a line, i.e. the end of the input or
+	// the position before a '\n', without consuming anything
+	MatchEOL() MatcherOperator
+
+	// MatchWordBoundary tries to match a position between a word rune
+	// [0-9A-Za-z_] and a non-word rune, the start or the end of the input
+	MatchWordBoundary() MatcherOperator
+
+	// MatchNotWordBoundary tries to match a position that is not a word boundary
+	MatchNotWordBoundary() MatcherOperator
+
+	// MatchBOF tries to match the start of the input
+	MatchBOF() MatcherOperator
+
+	// MatchPattern tries to match a compiled Pattern
+	MatchPattern(*Pattern) MatcherOperator
+
+	// MatchUntilString tries to match the runes before the first occurrence of
+	// a string, and the string itself if inclusive is true.  It fails if the
+	// string does not occur before EOF.
+	MatchUntilString(string, bool) MatcherOperator
+
+	// MatchUntilUnescaped performs MatchUntilString(), without including the
+	// string, skipping any rune that follows the escape rune
+	MatchUntilUnescaped(string, rune) MatcherOperator
+
+	// MatchUntilPattern tries to match the runes before the first position at
+	// which a compiled Pattern matches, without matching the Pattern itself.
+	// It fails if the Pattern does not match before EOF.
+	MatchUntilPattern(*Pattern) MatcherOperator
+
+	// MatchBalanced tries to match a region beginning with the open rune and
+	// ending with the matching close rune, skipping over nested regions, and
+	// over any literals delimited by the quotes of the options
+	MatchBalanced(rune, rune, BalancedOptions) MatcherOperator
+
+	// MatchList tries to match a list of items separated by separators, each
+	// matched by a compiled Pattern, as configured by the options
+	MatchList(*Pattern, *Pattern, ListOptions) MatcherOperator
+
 	// BeginOne begins a new grouping that is expected to match (i.e required)
 	Begin() Matcher
 
+	// BeginSkipping begins a new grouping, as Begin(), within which matches of
+	// the skipper Pattern, such as whitespace and comments, are consumed before
+	// each operand and nested grouping.  Nested groupings keep the skipper.
+	BeginSkipping(*Pattern) Matcher
+
+	// Lexeme begins a new grouping, as Begin(), within which nothing is
+	// skipped, such as for a token within a BeginSkipping grouping.  The
+	// enclosing skipper is still applied before the grouping itself.
+	Lexeme() Matcher
+
 	// End ends a grouping. NOTE You are expected to call one of the MatcherEnd
 	// functions in order to apply the result of the grouping to your current result.
 	End() MatcherEnd
@@ -116,6 +197,24 @@ type Matcher interface {
 
 	// Reset resets the state of the matcher
 	Reset() Matcher
+
+	// StartPos returns the position of the first rune matched by the last
+	// successful Result().  Positions are only tracked when the lexer is an
+	// Input, and are otherwise zero.
+	StartPos() Pos
+
+	// EndPos returns the position following the last rune matched by the last
+	// successful Result()
+	EndPos() Pos
+
+	// Err returns a *MatchError, giving the position of the farthest failed
+	// operand, if the last Result() was false, or nil otherwise
+	Err() error
+
+	// ListItems returns the spans of the items matched by the MatchList
+	// operands of the last successful Result(), in order.  Positions are only
+	// tracked when the lexer is an Input: the spans of other lexers are zero.
+	ListItems() []Span
 }
 
 type MatcherEnd interface {
@@ -124,6 +223,11 @@ type MatcherEnd interface {
 
 	// MatchZeroOrOne
 	MatchZeroOrOne() MatcherOperator
+
+	// Atomic performs MatchOne(), and prevents any backtracking into the
+	// grouping once it has matched.  Only Patterns compiled with
+	// CompileBacktracking backtrack, so elsewhere Atomic behaves as MatchOne.
+	Atomic() MatcherOperator
 }
 
 type MatcherOperator interface {
@@ -138,6 +242,8 @@ type MatcherOperator interface {
 	// next operand.  Short-circuit logic is performed, whereby the next operand
 	// will not actually be executed if the current matcher state is already
 	// true
+	// If the current matcher state is false, any runes consumed within the
+	// current grouping are rewound before the next operand is executed
 	Or() Matcher
 
 	// AndBegin performs an And(), followed by a Begin()
@@ -146,6 +252,18 @@ type MatcherOperator interface {
 	// OrBegin performs an Or(), followed by a Begin()
 	OrBegin() Matcher
 
+	// Cut commits the current grouping to the operands matched so far: if a
+	// later operand fails, the remaining alternatives of the grouping are not
+	// tried, and the grouping fails.  Cut has no effect if the current matcher
+	// state is false.
+	Cut() MatcherOperator
+
+	// Possessive makes the preceding run, or optional grouping, possessive:
+	// it matches as much as it can, and never gives any of it back when
+	// backtracking.  Only Patterns compiled with CompileBacktracking
+	// backtrack, so elsewhere runs and groupings are always possessive.
+	Possessive() MatcherOperator
+
 	// End ends a grouping. NOTE You are expected to call one of the MatcherEnd
 	// functions in order to apply the result of the grouping to your current result.
 	End() MatcherEnd
@@ -161,6 +279,268 @@ type MatcherOperator interface {
 	Result() bool
 }
 
+// PatternMatcher records an expression into a Pattern, as a Matcher passed to
+// Compile does, along with the operands only Patterns support: captures,
+// backreferences and lazy runs.  A Matcher keeps neither the text it consumes
+// nor any way back into it, so these operands have no Matcher equivalent.
+type PatternMatcher interface {
+
+	// MatchZeroOrOneBytes consumes the next rune if it matches, always returning true
+	MatchZeroOrOneBytes([]byte) PatternOperator
+
+	// MatchZeroOrOneRunes consumes the next rune if it matches, always returning true
+	MatchZeroOrOneRunes([]rune) PatternOperator
+
+	// MatchZeroOrOneRune consumes the next rune if it matches, always returning true
+	MatchZeroOrOneRune(rune) PatternOperator
+
+	// MatchZeroOrOneFunc consumes the next rune if it matches, always returning true
+	MatchZeroOrOneFunc(lexer.MatchFn) PatternOperator
+
+	// MatchZeroOrOneSet consumes the next rune if it matches, always returning true
+	MatchZeroOrOneSet(*RuneSet) PatternOperator
+
+	// MatchZeroOrMoreBytes consumes a run of matching runes, always returning true
+	MatchZeroOrMoreBytes([]byte) PatternOperator
+
+	// MatchZeroOrMoreRunes consumes a run of matching runes, always returning true
+	MatchZeroOrMoreRunes([]rune) PatternOperator
+
+	// MatchZeroOrMoreFunc consumes a run of matching runes, always returning true
+	MatchZeroOrMoreFunc(lexer.MatchFn) PatternOperator
+
+	// MatchZeroOrMoreSet consumes a run of matching runes, always returning true
+	MatchZeroOrMoreSet(*RuneSet) PatternOperator
+
+	// MatchOneBytes consumes the next rune if its in the list of bytes
+	MatchOneBytes([]byte) PatternOperator
+
+	// MatchOneRunes consumes the next rune if its in the list of bytes
+	MatchOneRunes([]rune) PatternOperator
+
+	// MatchOneRune consumes the next rune if it matches
+	MatchOneRune(rune) PatternOperator
+
+	// MatchOneFunc consumes the next rune if it matches
+	MatchOneFunc(lexer.MatchFn) PatternOperator
+
+	// MatchOneSet consumes the next rune if its in the set
+	MatchOneSet(*RuneSet) PatternOperator
+
+	// MatchOneOrMoreBytes consumes a run of matching runes
+	MatchOneOrMoreBytes([]byte) PatternOperator
+
+	// MatchOneOrMoreRunes consumes a run of matching runes
+	MatchOneOrMoreRunes([]rune) PatternOperator
+
+	// MatchOneOrMoreFunc consumes a run of matching runes
+	MatchOneOrMoreFunc(lexer.MatchFn) PatternOperator
+
+	// MatchOneOrMoreSet consumes a run of matching runes
+	MatchOneOrMoreSet(*RuneSet) PatternOperator
+
+	// MatchMinMaxBytes consumes a specified run of matching runes
+	MatchMinMaxBytes([]byte, int, int) PatternOperator
+
+	// MatchMinMaxRunes consumes a specified run of matching runes
+	MatchMinMaxRunes([]rune, int, int) PatternOperator
+
+	// MatchMinMaxFunc consumes a specified run of matching runes
+	MatchMinMaxFunc(lexer.MatchFn, int, int) PatternOperator
+
+	// MatchMinMaxSet consumes a specified run of matching runes
+	MatchMinMaxSet(*RuneSet, int, int) PatternOperator
+
+	// NonMatchZeroOrOneBytes consumes the next rune if it does not match, always returning true
+	NonMatchZeroOrOneBytes([]byte) PatternOperator
+
+	// NonMatchZeroOrOneRuness consumes the next rune if it does not match, always returning true
+	NonMatchZeroOrOneRunes([]rune) PatternOperator
+
+	// NonMatchZeroOrOneFunc consumes the next rune if it does not match, always returning true
+	NonMatchZeroOrOneFunc(lexer.MatchFn) PatternOperator
+
+	// NonMatchZeroOrOneSet consumes the next rune if it does not match, always returning true
+	NonMatchZeroOrOneSet(*RuneSet) PatternOperator
+
+	// NonMatchZeroOrMoreBytes consumes a run of non-matching runes, always returning true
+	NonMatchZeroOrMoreBytes([]byte) PatternOperator
+
+	// NonMatchZeroOrMoreRunes consumes a run of non-matching runes, always returning true
+	NonMatchZeroOrMoreRunes([]rune) PatternOperator
+
+	// NonMatchZeroOrMoreFunc consumes a run of non-matching runes, always returning true
+	NonMatchZeroOrMoreFunc(lexer.MatchFn) PatternOperator
+
+	// NonMatchZeroOrMoreSet consumes a run of non-matching runes, always returning true
+	NonMatchZeroOrMoreSet(*RuneSet) PatternOperator
+
+	// NonMatchOneBytes consumes the next rune if its NOT in the list of bytes
+	NonMatchOneBytes([]byte) PatternOperator
+
+	// NonMatchOneRuness consumes the next rune if its NOT in the list of bytes
+	NonMatchOneRunes([]rune) PatternOperator
+
+	// NonMatchOneFunc consumes the next rune if it does NOT match
+	NonMatchOneFunc(lexer.MatchFn) PatternOperator
+
+	// NonMatchOneSet consumes the next rune if its NOT in the set
+	NonMatchOneSet(*RuneSet) PatternOperator
+
+	// NonMatchOneOrMoreBytes consumes a run of non-matching runes
+	NonMatchOneOrMoreBytes([]byte) PatternOperator
+
+	// NonMatchOneOrMoreRunes consumes a run of non-matching runes
+	NonMatchOneOrMoreRunes([]rune) PatternOperator
+
+	// NonMatchOneOrMoreFunc consumes a run of non-matching runes
+	NonMatchOneOrMoreFunc(lexer.MatchFn) PatternOperator
+
+	// NonMatchOneOrMoreSet consumes a run of non-matching runes
+	NonMatchOneOrMoreSet(*RuneSet) PatternOperator
+
+	// MatchEOF tries to match the next rune against RuneEOF
+	MatchEOF() PatternOperator
+
+	// MatchBOL tries to match the start of a line, i.e. the start of the input
+	// or the position after a '\n', without consuming anything
+	MatchBOL() PatternOperator
+
+	// MatchEOL tries to match the end of a line, i.e. the end of the input or
+	// the position before a '\n', without consuming anything
+	MatchEOL() PatternOperator
+
+	// MatchWordBoundary tries to match a position between a word rune
+	// [0-9A-Za-z_] and a non-word rune, the start or the end of the input
+	MatchWordBoundary() PatternOperator
+
+	// MatchNotWordBoundary tries to match a position that is not a word boundary
+	MatchNotWordBoundary() PatternOperator
+
+	// MatchBOF tries to match the start of the input
+	MatchBOF() PatternOperator
+
+	// MatchPattern tries to match a compiled Pattern
+	MatchPattern(*Pattern) PatternOperator
+
+	// MatchUntilString tries to match the runes before the first occurrence of
+	// a string, and the string itself if inclusive is true.  It fails if the
+	// string does not occur before EOF.
+	MatchUntilString(string, bool) PatternOperator
+
+	// MatchUntilUnescaped performs MatchUntilString(), without including the
+	// string, skipping any rune that follows the escape rune
+	MatchUntilUnescaped(string, rune) PatternOperator
+
+	// MatchUntilPattern tries to match the runes before the first position at
+	// which a compiled Pattern matches, without matching the Pattern itself.
+	// It fails if the Pattern does not match before EOF.
+	MatchUntilPattern(*Pattern) PatternOperator
+
+	// MatchBalanced tries to match a region beginning with the open rune and
+	// ending with the matching close rune, skipping over nested regions, and
+	// over any literals delimited by the quotes of the options
+	MatchBalanced(rune, rune, BalancedOptions) PatternOperator
+
+	// MatchList tries to match a list of items separated by separators, each
+	// matched by a compiled Pattern, as configured by the options
+	MatchList(*Pattern, *Pattern, ListOptions) PatternOperator
+
+	// MatchBackref tries to match the text last captured by the named grouping,
+	// begun earlier in the expression with BeginCapture()
+	MatchBackref(string) PatternOperator
+
+	// BeginOne begins a new grouping that is expected to match (i.e required)
+	Begin() PatternMatcher
+
+	// BeginCapture begins a new grouping, as Begin(), whose matched text is
+	// captured under the specified name by Pattern searches and replacements
+	BeginCapture(string) PatternMatcher
+
+	// BeginSkipping begins a new grouping, as Begin(), within which matches of
+	// the skipper Pattern, such as whitespace and comments, are consumed before
+	// each operand and nested grouping.  Nested groupings keep the skipper.
+	BeginSkipping(*Pattern) PatternMatcher
+
+	// Lexeme begins a new grouping, as Begin(), within which nothing is
+	// skipped, such as for a token within a BeginSkipping grouping.  The
+	// enclosing skipper is still applied before the grouping itself.
+	Lexeme() PatternMatcher
+
+	// End ends a grouping. NOTE You are expected to call one of the PatternEnd
+	// functions in order to apply the result of the grouping to your current result.
+	End() PatternEnd
+
+	// EndMatchOne performs End(), followed by MatchOne()
+	EndMatchOne() PatternOperator
+
+	// EndMatchZeroOrOne performs End(), followed by MatchZeroOrOne
+	EndMatchZeroOrOne() PatternOperator
+}
+
+type PatternEnd interface {
+	// MatchOne
+	MatchOne() PatternOperator
+
+	// MatchZeroOrOne
+	MatchZeroOrOne() PatternOperator
+
+	// Atomic performs MatchOne(), and prevents any backtracking into the
+	// grouping once it has matched.  Only Patterns compiled with
+	// CompileBacktracking backtrack, so elsewhere Atomic behaves as MatchOne.
+	Atomic() PatternOperator
+}
+
+type PatternOperator interface {
+
+	// And Performs a logical 'and' between the current matcher state and the
+	// next operand.  Short-circuit logic is performed, whereby the next operand
+	// will not actually be executed if the current matcher state is already
+	// false
+	And() PatternMatcher
+
+	// Or Performs a logical 'or' between the current matcher result and the
+	// next operand.  Short-circuit logic is performed, whereby the next operand
+	// will not actually be executed if the current matcher state is already
+	// true
+	// If the current matcher state is false, any runes consumed within the
+	// current grouping are rewound before the next operand is executed
+	Or() PatternMatcher
+
+	// AndBegin performs an And(), followed by a Begin()
+	AndBegin() PatternMatcher
+
+	// OrBegin performs an Or(), followed by a Begin()
+	OrBegin() PatternMatcher
+
+	// Cut commits the current grouping to the operands matched so far: if a
+	// later operand fails, the remaining alternatives of the grouping are not
+	// tried, and the grouping fails.  Cut has no effect if the current matcher
+	// state is false.
+	Cut() PatternOperator
+
+	// Lazy makes the preceding run, or optional grouping, lazy: it matches as
+	// little as it can, and more only when backtracking.  Only Patterns
+	// compiled with CompileBacktracking or CompileLinear support Lazy.
+	Lazy() PatternOperator
+
+	// Possessive makes the preceding run, or optional grouping, possessive:
+	// it matches as much as it can, and never gives any of it back when
+	// backtracking.  Only Patterns compiled with CompileBacktracking
+	// backtrack, so elsewhere runs and groupings are always possessive.
+	Possessive() PatternOperator
+
+	// End ends a grouping. NOTE You are expected to call one of the PatternEnd
+	// functions in order to apply the result of the grouping to your current result.
+	End() PatternEnd
+
+	// EndMatchOne performs End(), followed by MatchOne()
+	EndMatchOne() PatternOperator
+
+	// EndMatchZeroOrOne performs End(), followed by MatchZeroOrOne
+	EndMatchZeroOrOne() PatternOperator
+}
+
 // New createas a new Matcher against the specifid Lexer
 func New(l lexer.Lexer) Matcher {
 	m := &matcher{
@@ -169,6 +549,8 @@ func New(l lexer.Lexer) Matcher {
 		state: &matcherState{},
 	}
 
+	m.input, _ = l.(*Input)
+
 	m.Reset()
 
 	return m
@@ -0,0 +1,60 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// matchMatcher matches the expression built by fn with a Matcher against the
+// start of s, returning whether it matched and the text it consumed
+func matchMatcher(fn func(Matcher) MatcherOperator, s string) (bool, string) {
+	var result bool
+
+	start := func(l lexer.Lexer) lexer.StateFn {
+		result = fn(New(l)).Result()
+		l.EmitTokenWithBytes(fuzzTokenMatch)
+		return nil
+	}
+
+	return result, string(NewInputFromBytes(start, []byte(s), 1).NextToken().Bytes())
+}
+
+// matchLexer is matchMatcher over a lexer that is not an Input
+func matchLexer(fn func(Matcher) MatcherOperator, s string) (bool, string) {
+	var result bool
+
+	start := func(l lexer.Lexer) lexer.StateFn {
+		result = fn(New(l)).Result()
+		l.EmitTokenWithBytes(fuzzTokenMatch)
+		return nil
+	}
+
+	return result, string(lexer.NewFromBytes(start, []byte(s), 1).NextToken().Bytes())
+}
+
+// TestPatternMatcher checks that a PatternMatcher records the same operands
+// as a Matcher, along with captures, for each way of compiling a Pattern
+func TestPatternMatcher(t *testing.T) {
+	fn := func(m Matcher) MatcherOperator {
+		return m.Begin().MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).EndMatchOne().
+			And().MatchOneRune('=').
+			And().Begin().MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).EndMatchZeroOrOne()
+	}
+
+	captures := func(m PatternMatcher) PatternOperator {
+		return m.BeginCapture("key").MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).EndMatchOne().
+			And().MatchOneRune('=').
+			And().BeginCapture("value").MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).EndMatchZeroOrOne()
+	}
+
+	if got, want := Compile(captures).String(), Compile(fn).String(); got != "(?P<key>[a-z]+)=(?P<value>[0-9]+)?" || want != "(?:[a-z]+)=(?:[0-9]+)?" {
+		t.Errorf("PatternMatcher recorded %s, Matcher %s", got, want)
+	}
+
+	for _, p := range []*Pattern{Compile(captures), CompileBacktracking(captures), CompileLinear(captures)} {
+		if got := string(p.ReplaceAll([]byte("a=1;b=;"), []byte("$value:$key"))); got != "1:a;:b;" {
+			t.Errorf("%s replaced captures as %q, want \"1:a;:b;\"", p, got)
+		}
+	}
+}
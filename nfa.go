@@ -0,0 +1,386 @@
+package matcher
+
+import (
+	"github.com/iNamik/go_lexer"
+)
+
+// CompileLinear records the expression built by fn into a Pattern
+// that matches as a Pattern compiled with CompileBacktracking would, with the
+// same captures, but by simulating every way the expression can match at
+// once, so that matching an expression of m operands against n runes takes
+// O(n*m) time, regardless of the input.  Searches also run in O(n*m) time.
+//
+// Only regular operands can be simulated, so CompileLinear panics if the
+// expression has a backreference, a MatchUntil, MatchBalanced or MatchList
+// operand, a Cut(), an atomic or possessive grouping, a skipper, a Pattern
+// compiled with Compile, or a declared Pattern.  Possessive runs are
+// supported.
+func CompileLinear[F PatternFunc](fn F) *Pattern {
+	root := record(fn)
+
+	root.backtrack = true
+
+	root.nfa = newNFA(root)
+
+	return &Pattern{root: root, first: firstSet(root)}
+}
+
+// instOp identifies the operation of an nfa instruction
+type instOp int
+
+const (
+	instRune    instOp = iota // Consume a rune accepted by node, going to x
+	instNotRune               // Go to x if node does not accept the next rune
+	instAssert                // Go to x if the anchor or EOF node matches
+	instSplit                 // Go to x, then, at a lower priority, to y
+	instSave                  // Save the position in slot, going to x
+	instCapture               // Capture from slot under name, going to x
+	instMatch                 // The expression has matched
+)
+
+// inst is an nfa instruction
+type inst struct {
+	op   instOp
+	node *node
+	x    int
+	y    int
+	slot int
+	name string
+}
+
+// nfa is a Pattern compiled into instructions for a Pike VM, which runs a
+// thread for each way the expression can match, in order of preference.
+// Slot 0 of each thread holds the offset the match started at.
+type nfa struct {
+	insts []inst
+	start int
+	slots int
+}
+
+// newNFA compiles the terms of a group, panicking on any operand the nfa
+// cannot simulate
+func newNFA(root *node) *nfa {
+	c := &nfa{insts: []inst{{op: instMatch}}, slots: 1}
+
+	body := c.terms(root, len(root.terms)-1, 0)
+
+	c.start = c.emit(inst{op: instSave, x: body, slot: 0})
+
+	return c
+}
+
+// nfa::emit appends an instruction, returning its index
+func (c *nfa) emit(i inst) int {
+	c.insts = append(c.insts, i)
+
+	return len(c.insts) - 1
+}
+
+// nfa::terms compiles the terms of a group up to and including term i,
+// followed by the instruction next, returning the entry instruction.  As
+// with a Matcher, And() and Or() are evaluated from left to right.
+func (c *nfa) terms(n *node, i int, next int) int {
+	t := n.terms[i]
+
+	if t.cut {
+		panic("Pattern has a Cut(), which CompileLinear does not support")
+	}
+
+	switch {
+	case i == 0:
+		return c.node(t.node, next)
+	case t.op == opOr:
+		first := c.terms(n, i-1, next)
+		return c.emit(inst{op: instSplit, x: first, y: c.node(t.node, next)})
+	}
+
+	return c.terms(n, i-1, c.node(t.node, next))
+}
+
+// nfa::node compiles a node followed by the instruction next, returning the
+// entry instruction
+func (c *nfa) node(n *node, next int) int {
+	if n.skip != nil {
+		panic("Pattern has a skipper, which CompileLinear does not support")
+	}
+
+	switch n.kind {
+	case nodeEOF, nodeAnchor:
+		return c.emit(inst{op: instAssert, node: n, x: next})
+	case nodeBackref:
+		panic("Pattern has a MatchBackref(), which CompileLinear does not support")
+	case nodeUntil, nodeBalanced, nodeList:
+		panic("Pattern has a MatchUntil, MatchBalanced or MatchList operand, which CompileLinear does not support")
+	case nodeRef:
+		panic("Pattern has a declared Pattern, which CompileLinear does not support")
+	case nodeGroup:
+		return c.group(n, next)
+	}
+
+	return c.run(n, next)
+}
+
+// nfa::group compiles a group followed by next
+func (c *nfa) group(n *node, next int) int {
+	switch {
+	case n.embedded && !n.backtrack:
+		panic("Pattern has a Pattern compiled with Compile, which CompileLinear does not support")
+	case n.atomic:
+		panic("Pattern has an atomic or possessive grouping, which CompileLinear does not support")
+	}
+
+	end, slot := next, c.slots
+
+	if n.capture != "" {
+		c.slots++
+		end = c.emit(inst{op: instCapture, x: next, slot: slot, name: n.capture})
+	}
+
+	body := c.terms(n, len(n.terms)-1, end)
+
+	if n.capture != "" {
+		body = c.emit(inst{op: instSave, x: body, slot: slot})
+	}
+
+	if n.optional {
+		return c.split(body, next, n.lazy)
+	}
+
+	return body
+}
+
+// nfa::run compiles a run of min to max runes followed by next, as min
+// required runes followed by a loop, or by max - min nested optional runes
+func (c *nfa) run(n *node, next int) int {
+	pc := next
+
+	switch {
+	case n.max < 0:
+		loop := c.emit(inst{op: instSplit})
+		body := c.emit(inst{op: instRune, node: n, x: loop})
+		c.insts[loop] = c.optional(n, body, next)
+		pc = loop
+	default:
+		for i := n.min; i < n.max; i++ {
+			body := c.emit(inst{op: instRune, node: n, x: pc})
+			pc = c.emit(c.optional(n, body, next))
+		}
+	}
+
+	for i := 0; i < n.min; i++ {
+		pc = c.emit(inst{op: instRune, node: n, x: pc})
+	}
+
+	return pc
+}
+
+// nfa::optional returns the split between taking another rune of a run,
+// at body, and leaving it for next.  A possessive run only leaves it for
+// next if the rune is not accepted.
+func (c *nfa) optional(n *node, body int, next int) inst {
+	if n.possessive {
+		return inst{op: instSplit, x: body, y: c.emit(inst{op: instNotRune, node: n, x: next})}
+	}
+
+	if n.lazy {
+		return inst{op: instSplit, x: next, y: body}
+	}
+
+	return inst{op: instSplit, x: body, y: next}
+}
+
+// nfa::split emits a split between x and y, or y and x if lazy
+func (c *nfa) split(x int, y int, lazy bool) int {
+	if lazy {
+		x, y = y, x
+	}
+
+	return c.emit(inst{op: instSplit, x: x, y: y})
+}
+
+/*****************************************************************************
+ * Pike VM
+ *****************************************************************************/
+
+// nfaThread is a way of matching the expression, at instruction pc, with the
+// start offsets of the captures it is within, and the captures it has made,
+// most recent first
+type nfaThread struct {
+	pc    int
+	slots []int
+	caps  *nfaCapture
+}
+
+// nfaCapture is a list of captures shared between threads
+type nfaCapture struct {
+	capture capture
+	next    *nfaCapture
+}
+
+// nfaQueue is the threads waiting at instructions for the next rune, in order
+// of preference.  An instruction is only added once per step, as the threads
+// reaching it later are less preferred, and would match in the same way.
+type nfaQueue struct {
+	threads []nfaThread
+	added   []int
+	step    int
+}
+
+// newNFAQueue
+func newNFAQueue(size int) *nfaQueue {
+	q := &nfaQueue{added: make([]int, size)}
+
+	for i := range q.added {
+		q.added[i] = -1
+	}
+
+	return q
+}
+
+// nfa::add adds the thread to the queue, following the instructions that do
+// not consume a rune, which are evaluated at the current position of e
+func (c *nfa) add(q *nfaQueue, e *executor, t nfaThread) {
+	if q.added[t.pc] == q.step {
+		return
+	}
+
+	q.added[t.pc] = q.step
+
+	i := &c.insts[t.pc]
+
+	switch i.op {
+	case instSplit:
+		c.add(q, e, nfaThread{i.x, t.slots, t.caps})
+		c.add(q, e, nfaThread{i.y, t.slots, t.caps})
+
+	case instAssert:
+		if i.node.kind == nodeEOF && e.peek() == lexer.RuneEOF || i.node.kind == nodeAnchor && e.anchor(i.node) {
+			c.add(q, e, nfaThread{i.x, t.slots, t.caps})
+		}
+
+	case instNotRune:
+		if !i.node.accepts(e.peek()) {
+			c.add(q, e, nfaThread{i.x, t.slots, t.caps})
+		}
+
+	case instSave:
+		slots := make([]int, c.slots)
+		copy(slots, t.slots)
+		slots[i.slot] = e.pos
+		c.add(q, e, nfaThread{i.x, slots, t.caps})
+
+	case instCapture:
+		caps := &nfaCapture{capture{i.name, t.slots[i.slot], e.pos}, t.caps}
+		c.add(q, e, nfaThread{i.x, t.slots, caps})
+
+	default:
+		q.threads = append(q.threads, t)
+	}
+}
+
+// nfa::exec runs the threads from the current position of e, returning the
+// most preferred thread to match, the number of runes consumed to match it,
+// and the offset following the match.  Unless anchored, a thread is started
+// at each position until one matches, each preferred to those started after
+// it, so that the leftmost match is found.  e is left where it started.
+func (c *nfa) exec(e *executor, anchored bool) (*nfaThread, int, int) {
+	start := e.mark()
+
+	cur, next := newNFAQueue(len(c.insts)), newNFAQueue(len(c.insts))
+
+	var matched *nfaThread
+
+	count, end, steps := 0, 0, 0
+
+	for {
+		if matched == nil && (steps == 0 || !anchored) {
+			c.add(cur, e, nfaThread{pc: c.start})
+		}
+
+		if len(cur.threads) == 0 && (anchored || matched != nil) {
+			break
+		}
+
+		r := e.peek()
+
+		// The threads accepting r, in order of preference
+		var survivors []nfaThread
+
+		for _, t := range cur.threads {
+			i := &c.insts[t.pc]
+
+			if i.op == instMatch {
+				// Less preferred threads are abandoned
+				t := t
+				matched, count, end = &t, steps, e.pos
+				break
+			}
+
+			if i.node.accepts(r) {
+				survivors = append(survivors, nfaThread{i.x, t.slots, t.caps})
+			}
+		}
+
+		if r == lexer.RuneEOF {
+			break
+		}
+
+		e.next()
+		steps++
+
+		next.threads, next.step = next.threads[:0], steps
+
+		for _, t := range survivors {
+			c.add(next, e, t)
+		}
+
+		cur, next = next, cur
+	}
+
+	e.reset(start)
+
+	return matched, count, end
+}
+
+// executor::linear matches the nfa of a CompileLinear Pattern at the current
+// position, consuming the runes of the most preferred match, and recording
+// its captures
+func (e *executor) linear(c *nfa) bool {
+	t, count, _ := c.exec(e, true)
+
+	if t == nil {
+		return false
+	}
+
+	for i := 0; i < count; i++ {
+		e.next()
+	}
+
+	e.n += count
+
+	e.appendCaptures(t.caps)
+
+	return true
+}
+
+// executor::appendCaptures appends a list of captures, oldest first
+func (e *executor) appendCaptures(caps *nfaCapture) {
+	if caps == nil {
+		return
+	}
+
+	e.appendCaptures(caps.next)
+
+	e.caps = append(e.caps, caps.capture)
+}
+
+// nfa::find returns the offsets of the leftmost match in b at or after pos
+func (c *nfa) find(b []byte, pos int) (int, int, bool) {
+	t, _, end := c.exec(&executor{src: b, pos: pos}, false)
+
+	if t == nil {
+		return 0, 0, false
+	}
+
+	return t.slots[0], end, true
+}
@@ -0,0 +1,164 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestLinear checks CompileLinear Patterns against the matches a
+// backtracking Pattern would make
+func TestLinear(t *testing.T) {
+	digits := []byte("0123456789")
+
+	runPatternTests(t, []patternTest{
+		// Regex: [0-9]*0
+		{"greedy", CompileLinear(func(m Matcher) MatcherOperator {
+			return m.MatchZeroOrMoreBytes(digits).And().MatchOneRune('0')
+		}), map[string]string{
+			"100":  "100",
+			"1000": "1000",
+			"1":    "!",
+		}},
+		// Regex: [0-9]*?0
+		{"lazy", CompileLinear(func(m PatternMatcher) PatternOperator {
+			return m.MatchZeroOrMoreBytes(digits).Lazy().And().MatchOneRune('0')
+		}), map[string]string{
+			"100": "10",
+			"0":   "0",
+		}},
+		// Regex: [0-9]*+0
+		{"possessive", CompileLinear(func(m Matcher) MatcherOperator {
+			return m.MatchZeroOrMoreBytes(digits).Possessive().And().MatchOneRune('0')
+		}), map[string]string{
+			"100": "!",
+			"x":   "!",
+		}},
+		// Regex: [0-9]{1,2}+[0-9]
+		{"possessive bounded", CompileLinear(func(m Matcher) MatcherOperator {
+			return m.MatchMinMaxBytes(digits, 1, 2).Possessive().And().MatchOneBytes(digits)
+		}), map[string]string{
+			"1234": "123",
+			"12":   "!",
+		}},
+		// Regex: (?:a|ab)(?:c|bcd)
+		{"alternatives", CompileLinear(func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneRune('a').OrBegin().MatchOneRune('a').And().MatchOneRune('b').EndMatchOne().EndMatchOne().
+				AndBegin().MatchOneRune('c').OrBegin().MatchOneRune('b').And().MatchOneRune('c').And().MatchOneRune('d').EndMatchOne().EndMatchOne()
+		}), map[string]string{
+			"abcd": "abcd",
+			"ac":   "ac",
+			"abc":  "abc",
+		}},
+		// Regex: (?:ab)??a
+		{"lazy grouping", CompileLinear(func(m PatternMatcher) PatternOperator {
+			return m.Begin().MatchOneRune('a').And().MatchOneRune('b').EndMatchZeroOrOne().Lazy().And().MatchOneRune('a')
+		}), map[string]string{
+			"aba": "a",
+			"ba":  "!",
+		}},
+		// Regex: \bab(?m:$)
+		{"anchors", CompileLinear(func(m Matcher) MatcherOperator {
+			return m.MatchWordBoundary().And().MatchOneRune('a').And().MatchOneRune('b').And().MatchEOL()
+		}), map[string]string{
+			"ab\n": "ab",
+			"abc":  "!",
+		}},
+		// Regex: [a-z]+\z
+		{"eof", CompileLinear(func(m Matcher) MatcherOperator {
+			return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).And().MatchEOF()
+		}), map[string]string{
+			"abc":  "abc",
+			"abc1": "!",
+		}},
+	})
+}
+
+// TestLinearCaptures checks the captures of CompileLinear Patterns, through
+// ReplaceAll
+func TestLinearCaptures(t *testing.T) {
+	// Regex: (?P<key>[a-z]+)=(?P<value>[a-z]*?)(?P<end>;|$)
+	p := CompileLinear(func(m PatternMatcher) PatternOperator {
+		return m.BeginCapture("key").MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).EndMatchOne().
+			And().MatchOneRune('=').
+			AndBegin().BeginCapture("value").MatchZeroOrMoreSet(NewRuneSetFromRangeString("a-z")).Lazy().EndMatchOne().
+			EndMatchOne().
+			AndBegin().BeginCapture("end").MatchOneRune(';').Or().MatchEOL().EndMatchOne().EndMatchOne()
+	})
+
+	got := string(p.ReplaceAll([]byte("a=bc;d=;xy=z"), []byte("[$key:$value$end]")))
+
+	if want := "[a:bc;][d:;][xy:z]"; got != want {
+		t.Errorf("ReplaceAll returned %q, want %q", got, want)
+	}
+
+	if got := fmt.Sprint(p.FindAllIndex([]byte("!a=b;c"), -1)); got != "[[1 5]]" {
+		t.Errorf("FindAllIndex returned %s, want [[1 5]]", got)
+	}
+}
+
+// TestLinearTime checks that an expression that takes exponential time to
+// backtrack, (?:a?){n}a{n} against a{n}, is matched in linear time
+func TestLinearTime(t *testing.T) {
+	const n = 64
+
+	p := CompileLinear(func(m Matcher) MatcherOperator {
+		op := m.MatchZeroOrOneRune('a')
+
+		for i := 1; i < n; i++ {
+			op = op.And().MatchZeroOrOneRune('a')
+		}
+
+		return op.And().MatchMinMaxRunes([]rune("a"), n, n)
+	})
+
+	input := strings.Repeat("a", n)
+
+	if ok, got := matchString(p, input); !ok || got != input {
+		t.Errorf("matched %v %q, want %q", ok, got, input)
+	}
+}
+
+// TestLinearPanics checks the operands CompileLinear does not support
+func TestLinearPanics(t *testing.T) {
+	compiled := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneRune('a')
+	})
+
+	skipper := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneRune(' ')
+	})
+
+	tests := map[string]func(m PatternMatcher) PatternOperator{
+		"backref": func(m PatternMatcher) PatternOperator {
+			return m.BeginCapture("a").MatchOneRune('a').EndMatchOne().And().MatchBackref("a")
+		},
+		"until": func(m PatternMatcher) PatternOperator {
+			return m.MatchUntilString("*/", true)
+		},
+		"cut": func(m PatternMatcher) PatternOperator {
+			return m.MatchOneRune('a').Cut().And().MatchOneRune('b')
+		},
+		"atomic": func(m PatternMatcher) PatternOperator {
+			return m.Begin().MatchOneRune('a').End().Atomic()
+		},
+		"skipper": func(m PatternMatcher) PatternOperator {
+			return m.BeginSkipping(skipper).MatchOneRune('a').EndMatchOne()
+		},
+		"compiled": func(m PatternMatcher) PatternOperator {
+			return m.MatchPattern(compiled)
+		},
+	}
+
+	for name, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: CompileLinear did not panic", name)
+				}
+			}()
+
+			CompileLinear(fn)
+		}()
+	}
+}
@@ -0,0 +1,562 @@
+package matcher
+
+import (
+	"bytes"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// Pattern is a compiled Matcher expression that can be matched against any
+// number of lexers.
+//
+// Patterns composed only of byte/rune classes, groups and quantifiers are
+// lowered to a deterministic automaton and matched in a single forward pass,
+// as long as every step of the expression can be decided by the next rune.
+// Patterns using Func primitives, MatchEOF, or alternatives that can only be
+// told apart by backtracking are matched by walking the expression, exactly
+// as a Matcher would.  Neither engine backtracks into an operand once it has
+// been tried.  Patterns compiled with CompileBacktracking are always walked,
+// backtracking as a regular expression would, and Patterns compiled with
+// CompileLinear match as they would, simulating an automaton instead.
+// Patterns returned by Declare have a name.
+type Pattern struct {
+	root  *node
+	dfa   *dfa
+	first *RuneSet
+	name  string
+}
+
+// PatternFunc builds the expression of a Pattern, either with a Matcher, so
+// that the same function can also be used with New, or with a PatternMatcher,
+// to use the operands only Patterns support.
+type PatternFunc interface {
+	func(Matcher) MatcherOperator | func(PatternMatcher) PatternOperator
+}
+
+// record records the expression built by fn, returning its root
+func record[F PatternFunc](fn F) *node {
+	if fn, ok := any(fn).(func(Matcher) MatcherOperator); ok {
+		r := newRecorder[Matcher, MatcherOperator, MatcherEnd]()
+		fn(r)
+		return r.b.root()
+	}
+
+	r := newRecorder[PatternMatcher, PatternOperator, PatternEnd]()
+
+	any(fn).(func(PatternMatcher) PatternOperator)(r)
+
+	return r.b.root()
+}
+
+// Compile records the expression built by fn into a Pattern.  The Matcher or
+// PatternMatcher passed to fn only records calls, so fn should simply return
+// the end of its expression, without calling Result() or Reset().
+func Compile[F PatternFunc](fn F) *Pattern {
+	root := record(fn)
+
+	if hasLazy(root) {
+		panic("Pattern has a Lazy() operand, compile it with CompileBacktracking or CompileLinear")
+	}
+
+	return &Pattern{root: root, dfa: newDFA(root), first: firstSet(root)}
+}
+
+// Match tries to match the pattern at the current position of the lexer,
+// consuming the matched runes.  The lexer state is left unchanged if the
+// pattern does not match.
+func (p *Pattern) Match(l lexer.Lexer) bool {
+	_, ok := p.match(l)
+
+	return ok
+}
+
+// Pattern::match returns the number of runes matched
+func (p *Pattern) match(l lexer.Lexer) (int, bool) {
+	if p.dfa != nil {
+		return p.dfa.match(l)
+	}
+
+	e := &executor{lexer: l}
+
+	if e.match(p.root) {
+		return e.n, true
+	}
+
+	return 0, false
+}
+
+// String returns the pattern in regex syntax, for diagnostics.  And() and Or()
+// are evaluated from left to right, so groups are added where regex precedence
+// would differ, and Func primitives are shown as <func>.
+func (p *Pattern) String() string {
+	return p.root.termsString()
+}
+
+/*****************************************************************************
+ * Pattern nodes
+ *****************************************************************************/
+
+// nodeKind identifies the type of a Pattern node
+type nodeKind int
+
+const (
+	nodeGroup nodeKind = iota
+	nodeClass
+	nodeFunc
+	nodeEOF
+	nodeAnchor
+	nodeBackref
+	nodeUntil
+	nodeBalanced
+	nodeList
+	nodeRef
+)
+
+// opKind identifies the operator joining a term to the previous term of a group
+type opKind int
+
+const (
+	opNone opKind = iota
+	opAnd
+	opOr
+)
+
+// term is an operand of a group, along with the operator preceding it.  cut
+// commits the group once the term has been reached with a true result.
+type term struct {
+	op   opKind
+	node *node
+	cut  bool
+}
+
+// node is a single operand of a Pattern.  Class and func nodes match between
+// min and max runes (max < 0 means no limit), group nodes match their terms
+// from left to right.  Backref nodes match the text last captured by the
+// group named by capture.  Until nodes match the runes before the first
+// unescaped occurrence of until, or before the first position at which stop
+// matches, class then holding the runes stop can start with, if known.
+// Balanced nodes match a region from open to the matching close, ignoring
+// delimiters within quotes, in which escape escapes the following rune.  List
+// nodes match between min and max items separated by sep, and a trailing
+// sep if trailing is true.  Any node may have a skip node, the root of a
+// skipper Pattern, whose matches are consumed before the node is.  The func
+// nodes of a SeqPattern hold a func(T) bool in pred instead of fn, and match
+// elements instead of runes.  The copy of the root of a Pattern given to
+// MatchPattern is embedded.  The root of a CompileLinear Pattern holds its
+// nfa.  Ref nodes match the root of the declared Pattern ref, which is only
+// known once it is defined.
+type node struct {
+	kind       nodeKind
+	class      *RuneSet
+	fn         lexer.MatchFn
+	pred       interface{}
+	negate     bool
+	min        int
+	max        int
+	terms      []term
+	optional   bool
+	atomic     bool
+	lazy       bool
+	possessive bool
+	backtrack  bool
+	capture    string
+	anchor     anchorKind
+	until      string
+	inclusive  bool
+	escape     rune
+	stop       *node
+	open       rune
+	close      rune
+	quotes     []rune
+	item       *node
+	sep        *node
+	trailing   bool
+	skip       *node
+	embedded   bool
+	nfa        *nfa
+	ref        *Pattern
+}
+
+// hasLazy returns true if the node, outside of any backtracking Pattern it
+// contains, has a lazy operand
+func hasLazy(n *node) bool {
+	if n.lazy {
+		return true
+	}
+
+	for _, t := range n.terms {
+		if !t.node.backtrack && hasLazy(t.node) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasCapture returns true if the node has a group capturing under name,
+// including within the items and separators of a list, and its skipper
+func hasCapture(n *node, name string) bool {
+	if n.kind == nodeGroup && n.capture == name {
+		return true
+	}
+
+	for _, x := range []*node{n.item, n.sep, n.skip} {
+		if x != nil && hasCapture(x, name) {
+			return true
+		}
+	}
+
+	for _, t := range n.terms {
+		if hasCapture(t.node, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// node::accepts
+func (n *node) accepts(r rune) bool {
+	if r == lexer.RuneEOF {
+		return false
+	}
+
+	if n.kind == nodeFunc {
+		return n.fn(r) != n.negate
+	}
+
+	return n.class.Contains(r) != n.negate
+}
+
+// node::termsString returns the terms of a group in regex syntax, or a ref
+// node as it is
+func (n *node) termsString() string {
+	if n.kind == nodeRef {
+		return n.String()
+	}
+
+	s, isAlt := "", false
+
+	for _, t := range n.terms {
+		switch t.op {
+		case opAnd:
+			if isAlt {
+				s, isAlt = "(?:"+s+")", false
+			}
+		case opOr:
+			s, isAlt = s+"|", true
+		}
+
+		if t.node.skip != nil {
+			s += skipString(t.node.skip)
+		}
+
+		s += t.node.String()
+	}
+
+	return s
+}
+
+// node::String returns the node in regex syntax
+func (n *node) String() string {
+	var s string
+
+	switch n.kind {
+	case nodeGroup:
+		switch {
+		case n.capture != "":
+			s = "(?P<" + n.capture + ">" + n.termsString() + ")"
+		case n.atomic:
+			s = "(?>" + n.termsString() + ")"
+		default:
+			s = "(?:" + n.termsString() + ")"
+		}
+		if n.optional {
+			s += "?"
+		}
+		if n.lazy {
+			s += "?"
+		}
+		return s
+	case nodeEOF:
+		return `\z`
+	case nodeAnchor:
+		return anchorRegexps[n.anchor]
+	case nodeBackref:
+		return `\k<` + n.capture + `>`
+	case nodeUntil:
+		return untilString(n)
+	case nodeBalanced:
+		return "<balanced " + string(n.open) + string(n.close) + ">"
+	case nodeList:
+		return listString(n)
+	case nodeRef:
+		return "(?&" + n.ref.name + ")"
+	case nodeFunc:
+		s = "<func>"
+		if n.negate {
+			s = "<^func>"
+		}
+	default:
+		ranges := n.class.ranges
+		if n.negate {
+			ranges = ranges.negate()
+		}
+		if len(ranges) == 1 && ranges[0].lo == ranges[0].hi && ranges[0].lo < 128 {
+			s = regexp.QuoteMeta(string(ranges[0].lo))
+		} else {
+			s = newRuneSet(ranges).String()
+		}
+	}
+
+	s += quantifierString(n.min, n.max)
+
+	if n.lazy {
+		s += "?"
+	}
+
+	if n.possessive {
+		s += "+"
+	}
+
+	return s
+}
+
+/*****************************************************************************
+ * Executor
+ *****************************************************************************/
+
+// executor walks a Pattern against a lexer, using the same semantics as a
+// Matcher.  n is the number of runes consumed so far, and pos is the offset
+// within src, in which the offsets of captured groups are recorded in caps.
+// When searching a byte slice, lexer is nil.  Otherwise, the runes consumed
+// from the lexer are appended to src, so that backreferences can compare
+// against them.  cutting is the group being failed by a cut, while
+// backtracking.  fails records the failures of declared Patterns, if
+// tracked.
+type executor struct {
+	lexer   lexer.Lexer
+	src     []byte
+	pos     int
+	n       int
+	caps    []capture
+	cutting *groupFrame
+	fails   *refFailures
+}
+
+// capture is the text captured by a named group, as offsets within src
+type capture struct {
+	name  string
+	start int
+	end   int
+}
+
+// executorMarker
+type executorMarker struct {
+	marker *lexer.Marker
+	pos    int
+	n      int
+	caps   int
+}
+
+// executor::mark
+func (e *executor) mark() executorMarker {
+	if e.lexer == nil {
+		return executorMarker{nil, e.pos, e.n, len(e.caps)}
+	}
+
+	return executorMarker{e.lexer.Marker(), e.pos, e.n, len(e.caps)}
+}
+
+// executor::reset
+func (e *executor) reset(m executorMarker) {
+	if e.lexer != nil {
+		e.lexer.Reset(m.marker)
+		e.src = e.src[:m.pos]
+	}
+
+	e.pos = m.pos
+	e.caps = e.caps[:m.caps]
+	e.n = m.n
+}
+
+// executor::peek returns the next rune, without consuming it
+func (e *executor) peek() rune {
+	if e.lexer != nil {
+		return e.lexer.PeekRune(0)
+	}
+
+	if e.pos >= len(e.src) {
+		return lexer.RuneEOF
+	}
+
+	r, _ := utf8.DecodeRune(e.src[e.pos:])
+
+	return r
+}
+
+// executor::next consumes the next rune
+func (e *executor) next() {
+	if e.lexer != nil {
+		e.src = utf8.AppendRune(e.src, e.lexer.NextRune())
+		e.pos = len(e.src)
+		return
+	}
+
+	_, w := utf8.DecodeRune(e.src[e.pos:])
+
+	e.pos += w
+}
+
+// executor::anchor tests an anchor at the current position
+func (e *executor) anchor(n *node) bool {
+	if e.lexer != nil {
+		return anchorMatchesLexer(n.anchor, e.lexer)
+	}
+
+	prev := rune(lexer.RuneEOF)
+	if e.pos > 0 {
+		prev, _ = utf8.DecodeLastRune(e.src[:e.pos])
+	}
+
+	return anchorMatches(n.anchor, prev, e.peek())
+}
+
+// executor::backref matches the text last captured under the name of a
+// backref node.  A name that has not captured anything yet does not match.
+func (e *executor) backref(n *node) bool {
+	for i := len(e.caps) - 1; i >= 0; i-- {
+		c := e.caps[i]
+
+		if c.name != n.capture {
+			continue
+		}
+
+		text := e.src[c.start:c.end]
+
+		if e.lexer == nil {
+			if !bytes.HasPrefix(e.src[e.pos:], text) {
+				return false
+			}
+
+			e.pos += len(text)
+			e.n += utf8.RuneCount(text)
+
+			return true
+		}
+
+		m := e.mark()
+
+		for _, r := range string(text) {
+			if e.peek() != r {
+				e.reset(m)
+				return false
+			}
+
+			e.next()
+			e.n++
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// executor::match
+func (e *executor) match(n *node) bool {
+	if n.skip != nil {
+		e.skip(n.skip)
+	}
+
+	return e.matchNode(n)
+}
+
+// executor::matchNode matches the node, without skipping
+func (e *executor) matchNode(n *node) bool {
+	switch n.kind {
+
+	case nodeEOF:
+		return e.peek() == lexer.RuneEOF
+
+	case nodeAnchor:
+		return e.anchor(n)
+
+	case nodeBackref:
+		return e.backref(n)
+
+	case nodeUntil:
+		return e.until(n)
+
+	case nodeBalanced:
+		return e.balanced(n)
+
+	case nodeList:
+		return e.list(n)
+
+	case nodeRef:
+		return e.matchRef(n)
+
+	case nodeGroup:
+		if n.nfa != nil {
+			return e.linear(n.nfa)
+		}
+
+		m := e.mark()
+
+		if n.backtrack {
+			// A backtracking Pattern keeps the first way it matches
+			if e.backtrackNode(n, func() bool { return true }) {
+				return true
+			}
+
+			e.reset(m)
+
+			return n.optional
+		}
+
+		result, cut := false, false
+
+		for i, t := range n.terms {
+			switch {
+			case i == 0:
+				result = e.match(t.node)
+			case t.op == opAnd && result:
+				result = e.match(t.node)
+			case t.op == opOr && !result && !cut:
+				e.reset(m)
+				result = e.match(t.node)
+			}
+
+			cut = cut || (t.cut && result)
+		}
+
+		if !result {
+			e.reset(m)
+		} else if n.capture != "" {
+			e.caps = append(e.caps, capture{n.capture, m.pos, e.pos})
+		}
+
+		return result || n.optional
+	}
+
+	m := e.mark()
+
+	count := 0
+
+	for (n.max < 0 || count < n.max) && n.accepts(e.peek()) {
+		e.next()
+		count++
+	}
+
+	if count < n.min {
+		e.reset(m)
+		return false
+	}
+
+	e.n += count
+
+	return true
+}
@@ -0,0 +1,93 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// matchString matches the pattern against the start of s, returning whether
+// it matched and the text it consumed
+func matchString(p *Pattern, s string) (bool, string) {
+	var result bool
+
+	start := func(l lexer.Lexer) lexer.StateFn {
+		result = p.Match(l)
+		l.EmitTokenWithBytes(fuzzTokenMatch)
+		return nil
+	}
+
+	return result, string(NewInputFromBytes(start, []byte(s), 1).NextToken().Bytes())
+}
+
+// patternTest is a Pattern, the inputs it is matched against, and the text
+// it is expected to consume from each, or "!" if it should not match
+type patternTest struct {
+	name    string
+	pattern *Pattern
+	inputs  map[string]string
+}
+
+// runPatternTests
+func runPatternTests(t *testing.T, tests []patternTest) {
+	for _, test := range tests {
+		for input, want := range test.inputs {
+			ok, got := matchString(test.pattern, input)
+
+			switch {
+			case want == "!" && ok:
+				t.Errorf("%s on %q: matched %q, want no match", test.name, input, got)
+			case want == "!" && got != "":
+				t.Errorf("%s on %q: did not match, but consumed %q", test.name, input, got)
+			case want != "!" && !ok:
+				t.Errorf("%s on %q: did not match, want %q", test.name, input, want)
+			case want != "!" && got != want:
+				t.Errorf("%s on %q: matched %q, want %q", test.name, input, got, want)
+			}
+		}
+	}
+}
+
+// operandTest is an expression, and the text it consumes from the start of
+// each input, "!" meaning no match
+type operandTest struct {
+	name   string
+	fn     func(Matcher) MatcherOperator
+	inputs map[string]string
+}
+
+// runOperandTests checks each expression with a Matcher, and with a Pattern
+// matched against a lexer and searched for in a byte slice, as each takes a
+// different path through the code of an operand
+func runOperandTests(t *testing.T, tests []operandTest) {
+	for _, test := range tests {
+		p := Compile(test.fn)
+
+		runPatternTests(t, []patternTest{{test.name, p, test.inputs}})
+
+		for input, want := range test.inputs {
+			ok, got := matchMatcher(test.fn, input)
+
+			if !ok {
+				if got != "" {
+					t.Errorf("%s: Matcher on %q: did not match, but consumed %q", test.name, input, got)
+				}
+				got = "!"
+			}
+
+			if got != want {
+				t.Errorf("%s: Matcher on %q: matched %q, want %q", test.name, input, got, want)
+			}
+
+			got = "!"
+
+			if loc := p.FindIndex([]byte(input)); loc != nil && loc[0] == 0 {
+				got = input[:loc[1]]
+			}
+
+			if got != want {
+				t.Errorf("%s: FindIndex on %q: matched %q, want %q", test.name, input, got, want)
+			}
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package matcher
 
 import (
+	"strconv"
+
 	"github.com/iNamik/go_container/queue"
 	"github.com/iNamik/go_lexer"
 )
 
 type matcherCallback func() bool
 
+// matcherExpected describes an operand, for diagnostics
+type matcherExpected func() string
+
 type matcherFn func(bool, matcherCallback) bool
 
 type matcherEndFn func(bool) bool
@@ -15,15 +20,27 @@ type matcherState struct {
 	skipAll  bool
 	skipNext bool
 	result   bool
+	cut      bool
 	fn       matcherFn
 	marker   *lexer.Marker
+	spans    int
+	skipper  *Pattern
 }
 
 type matcher struct {
 	lexer     lexer.Lexer
+	input     *Input
 	stack     queue.Interface
 	hasResult bool
 	state     *matcherState
+	failPos   Pos
+	expected  []string
+	found     string
+	startPos  Pos
+	endPos    Pos
+	err       error
+	spans     []Span
+	listItems []Span
 }
 
 // (matcherFn) matcherNil
@@ -51,12 +68,32 @@ func endMatchOne(b bool) bool {
 	return b
 }
 
-// matcher::doMatch
-func (m *matcher) doMatch(f matcherCallback) {
+// matcher::doMatch performs an operand, consuming any matches of the skipper
+// of the current grouping before it
+func (m *matcher) doMatch(f matcherCallback, expected matcherExpected) {
+	if skipper := m.state.skipper; skipper != nil {
+		match := f
+		f = func() bool {
+			skipPattern(m.lexer, skipper)
+			return match()
+		}
+	}
+
+	m.doResult(f, expected)
+}
+
+// matcher::doResult combines the result of f with the current state
+func (m *matcher) doResult(f matcherCallback, expected matcherExpected) {
+	m.mark()
+
 	if m.state.skipNext == false {
 		m.state.result = m.state.fn(m.state.result, f)
 
 		m.hasResult = true
+
+		if m.state.result == false {
+			m.fail(expected)
+		}
 	}
 
 	m.state.skipNext = m.state.skipAll
@@ -64,6 +101,33 @@ func (m *matcher) doMatch(f matcherCallback) {
 	m.state.fn = matcherNil
 }
 
+// matcher::fail records a failed operand for diagnostics, keeping those that
+// failed at the farthest position.  Group results are not recorded, as the
+// operands within the group already have been.
+func (m *matcher) fail(expected matcherExpected) {
+	if m.input == nil || expected == nil {
+		return
+	}
+
+	pos := m.input.pos
+
+	switch {
+	case len(m.expected) == 0 || pos.Offset > m.failPos.Offset:
+		m.failPos = pos
+		m.expected = []string{expected()}
+		m.found = describeRune(m.input.PeekRune(0))
+
+	case pos.Offset == m.failPos.Offset:
+		e := expected()
+		for _, x := range m.expected {
+			if x == e {
+				return
+			}
+		}
+		m.expected = append(m.expected, e)
+	}
+}
+
 // matcher::clearState
 func (m *matcher) clearState() {
 	m.state.result = false
@@ -72,9 +136,35 @@ func (m *matcher) clearState() {
 
 	m.state.skipNext = false
 
+	m.state.cut = false
+
 	m.state.fn = matcherNil
 
-	m.state.marker = m.lexer.Marker()
+	m.state.marker = nil
+
+	m.state.spans = len(m.spans)
+
+	m.state.skipper = nil
+}
+
+// matcher::mark marks the start of the current grouping, unless it has been
+// marked already.  The marker of the top grouping is only taken by its first
+// operand, as an Input drops its markers whenever a token is emitted or
+// ignored, which may happen between Result() and the next match.
+func (m *matcher) mark() {
+	if m.state.marker == nil {
+		m.state.marker = m.lexer.Marker()
+	}
+}
+
+// matcher::rewind resets the lexer to the start of the current grouping, if
+// anything has been matched within it
+func (m *matcher) rewind() {
+	if m.state.marker != nil {
+		m.lexer.Reset(m.state.marker)
+	}
+
+	m.spans = m.spans[:m.state.spans]
 }
 
 // matcher::pushState
@@ -84,6 +174,8 @@ func (m *matcher) pushState() {
 	m.state = &matcherState{}
 
 	m.clearState()
+
+	m.mark()
 }
 
 // matcher::popState
@@ -93,28 +185,137 @@ func (m *matcher) popState() {
 	m.state = i.(*matcherState)
 }
 
-// matcher::begin
-func (m *matcher) begin() Matcher {
+// matcher::begin begins a grouping, within which the skipper is applied
+func (m *matcher) begin(skipper *Pattern) Matcher {
 	tmpSkipAll := m.state.skipAll || m.state.skipNext
 
+	m.mark()
+
+	// The grouping is an operand of the enclosing grouping, whose skipper is
+	// applied before the marker of the grouping is set
+	if !tmpSkipAll && m.state.skipper != nil {
+		skipPattern(m.lexer, m.state.skipper)
+	}
+
 	m.pushState()
 
 	m.state.skipAll = tmpSkipAll
 
 	m.state.skipNext = tmpSkipAll
 
+	m.state.skipper = skipper
+
 	return m
 }
 
 // matcher::end provides the cleanup and call-back for the End* functions
 func (m *matcher) end(endFn matcherEndFn) {
 	if m.state.result == false {
-		m.lexer.Reset(m.state.marker)
+		m.rewind()
 	}
 
 	b := endFn(m.state.result)
 
 	m.popState()
 
-	m.doMatch(func() bool { return b })
+	m.doResult(func() bool { return b }, nil)
+}
+
+// describeRune describes a rune found in the input
+func describeRune(r rune) string {
+	if r == lexer.RuneEOF {
+		return "EOF"
+	}
+
+	return strconv.QuoteRune(r)
+}
+
+// expectBytes describes an operand matching, or not matching, a list of bytes
+func expectBytes(match []byte, negate bool) matcherExpected {
+	return func() string {
+		return describeRanges(bytesToRanges(match), negate)
+	}
+}
+
+// expectRunes describes an operand matching, or not matching, a list of runes
+func expectRunes(match []rune, negate bool) matcherExpected {
+	return func() string {
+		return describeRanges(runesToRanges(match), negate)
+	}
+}
+
+// expectRune describes an operand matching a rune
+func expectRune(match rune) matcherExpected {
+	return func() string {
+		return strconv.QuoteRune(match)
+	}
+}
+
+// expectSet describes an operand matching, or not matching, a RuneSet
+func expectSet(match *RuneSet, negate bool) matcherExpected {
+	return func() string {
+		return describeRanges(match.ranges, negate)
+	}
+}
+
+// expectFunc describes an operand matching, or not matching, a lexer.MatchFn
+func expectFunc(negate bool) matcherExpected {
+	if negate {
+		return func() string { return "a non-matching rune" }
+	}
+
+	return func() string { return "a matching rune" }
+}
+
+// expectEOF describes an operand matching EOF
+func expectEOF() string {
+	return "EOF"
+}
+
+// expectAnchor describes an operand matching an anchor
+func expectAnchor(kind anchorKind) matcherExpected {
+	return func() string {
+		return anchorNames[kind]
+	}
+}
+
+// expectPattern describes an operand matching a Pattern
+func expectPattern(p *Pattern) matcherExpected {
+	return func() string {
+		return p.String()
+	}
+}
+
+// expectUntil describes an operand matching the runes before a string
+func expectUntil(term string) matcherExpected {
+	return func() string {
+		return "text ending before " + strconv.Quote(term)
+	}
+}
+
+// expectUntilPattern describes an operand matching the runes before a Pattern
+func expectUntilPattern(p *Pattern) matcherExpected {
+	return func() string {
+		return "text ending before " + p.String()
+	}
+}
+
+// expectBalanced describes an operand matching a balanced region
+func expectBalanced(open rune, close rune) matcherExpected {
+	return func() string {
+		return "balanced " + string(open) + "..." + string(close)
+	}
+}
+
+// describeRanges describes a class of runes, i.e. 'a' or [^a-z]
+func describeRanges(ranges runeRanges, negate bool) string {
+	if negate {
+		ranges = ranges.negate()
+	}
+
+	if len(ranges) == 1 && ranges[0].lo == ranges[0].hi {
+		return strconv.QuoteRune(ranges[0].lo)
+	}
+
+	return newRuneSet(ranges).String()
 }
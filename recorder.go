@@ -0,0 +1,630 @@
+package matcher
+
+import (
+	"strconv"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// builder records an expression into Pattern nodes.  It is shared by the
+// recorder of rune Patterns and by SeqMatchers.  skippers holds the skipper
+// of each group being recorded, nil for none.
+type builder struct {
+	groups   []*node
+	skippers []*node
+	op       opKind
+}
+
+// newBuilder
+func newBuilder() builder {
+	return builder{groups: []*node{&node{kind: nodeGroup}}, skippers: []*node{nil}}
+}
+
+// builder::add appends a node to the current group, to be matched after the
+// skipper of the group
+func (b *builder) add(n *node) {
+	g := b.groups[len(b.groups)-1]
+
+	if skipper := b.skippers[len(b.skippers)-1]; skipper != nil {
+		n.skip = skipper
+	}
+
+	g.terms = append(g.terms, term{op: b.op, node: n})
+
+	b.op = opNone
+}
+
+// builder::operator sets the operator joining the next node to the current group
+func (b *builder) operator(op opKind) {
+	if len(b.groups[len(b.groups)-1].terms) == 0 {
+		panic("No operator executed before operand")
+	}
+	b.op = op
+}
+
+// builder::begin starts a new group, keeping the current skipper
+func (b *builder) begin() {
+	b.beginSkipping(b.skippers[len(b.skippers)-1])
+}
+
+// builder::beginSkipping starts a new group, within which the skipper, which
+// may be nil, is matched before each node
+func (b *builder) beginSkipping(skipper *node) {
+	g := &node{kind: nodeGroup}
+
+	b.add(g)
+
+	b.groups = append(b.groups, g)
+
+	b.skippers = append(b.skippers, skipper)
+}
+
+// builder::capture starts a new group, capturing its text under name
+func (b *builder) capture(name string) {
+	if name == "" {
+		panic("Capture name is empty")
+	}
+
+	b.begin()
+
+	b.groups[len(b.groups)-1].capture = name
+}
+
+// builder::backref appends a backreference to the text captured under name,
+// which must be captured earlier in the expression
+func (b *builder) backref(name string) {
+	if !hasCapture(b.groups[0], name) {
+		panic("Calling MatchBackref() without an earlier BeginCapture(" + strconv.Quote(name) + ")")
+	}
+
+	b.add(&node{kind: nodeBackref, capture: name})
+}
+
+// builder::end ends the current group, returning it
+func (b *builder) end(optional bool) *node {
+	if len(b.groups) == 1 {
+		panic("Calling End() without a matching Begin()")
+	}
+
+	g := b.groups[len(b.groups)-1]
+
+	g.optional = optional
+
+	b.groups = b.groups[:len(b.groups)-1]
+
+	b.skippers = b.skippers[:len(b.skippers)-1]
+
+	return g
+}
+
+// builder::cut commits the current group after its last term
+func (b *builder) cut() {
+	g := b.groups[len(b.groups)-1]
+
+	if len(g.terms) == 0 {
+		panic("No operator executed before operand")
+	}
+
+	g.terms[len(g.terms)-1].cut = true
+}
+
+// builder::lazy makes the last term of the current group lazy
+func (b *builder) lazy() {
+	b.quantified("Lazy").lazy = true
+}
+
+// builder::possessive makes the last term of the current group possessive.
+// An optional group is possessive by being atomic.
+func (b *builder) possessive() {
+	n := b.quantified("Possessive")
+
+	if n.kind == nodeGroup {
+		n.atomic = true
+	} else {
+		n.possessive = true
+	}
+}
+
+// builder::quantified returns the last term of the current group, which must
+// be a run or an optional group that is neither lazy nor possessive yet, for
+// the method named by call
+func (b *builder) quantified(call string) *node {
+	g := b.groups[len(b.groups)-1]
+
+	if len(g.terms) == 0 {
+		panic("No operator executed before operand")
+	}
+
+	n := g.terms[len(g.terms)-1].node
+
+	switch {
+	case n.lazy || n.possessive || (n.kind == nodeGroup && n.atomic):
+		panic("Calling " + call + "() after an operand that is already lazy or possessive")
+	case n.kind == nodeGroup && n.optional && !n.backtrack:
+	case (n.kind == nodeClass || n.kind == nodeFunc) && n.min != n.max:
+	default:
+		panic("Calling " + call + "() after an operand that is not a run or an optional grouping")
+	}
+
+	return n
+}
+
+// builder::root returns the recorded expression
+func (b *builder) root() *node {
+	if len(b.groups) != 1 {
+		panic("Pattern has a Begin() without a matching End()")
+	}
+
+	root := b.groups[0]
+
+	if len(root.terms) == 0 {
+		panic("Pattern does not match anything")
+	}
+
+	return root
+}
+
+// recorder implements the Matcher or the PatternMatcher interfaces, as given
+// by M, O and E, recording the expression into Pattern nodes instead of
+// executing it
+type recorder[M any, O any, E any] struct {
+	b builder
+}
+
+// newRecorder
+func newRecorder[M any, O any, E any]() *recorder[M, O, E] {
+	return &recorder[M, O, E]{b: newBuilder()}
+}
+
+// recorder::matcher returns the recorder as the M interface
+func (r *recorder[M, O, E]) matcher() M {
+	return any(r).(M)
+}
+
+// recorder::operand returns the recorder as the O interface
+func (r *recorder[M, O, E]) operand() O {
+	return any(r).(O)
+}
+
+// recorder::class
+func (r *recorder[M, O, E]) class(class *RuneSet, negate bool, min int, max int) O {
+	r.b.add(&node{kind: nodeClass, class: class, negate: negate, min: min, max: max})
+	return r.operand()
+}
+
+// recorder::fn
+func (r *recorder[M, O, E]) fn(fn lexer.MatchFn, negate bool, min int, max int) O {
+	r.b.add(&node{kind: nodeFunc, fn: fn, negate: negate, min: min, max: max})
+	return r.operand()
+}
+
+// recorder::operator
+func (r *recorder[M, O, E]) operator(op opKind) M {
+	r.b.operator(op)
+	return r.matcher()
+}
+
+// recorder::end
+func (r *recorder[M, O, E]) end(optional bool) O {
+	r.b.end(optional)
+	return r.operand()
+}
+
+/*****************************************************************************
+ * Matcher
+ *****************************************************************************/
+
+// Matcher::MatchZeroOrOneBytes
+func (r *recorder[M, O, E]) MatchZeroOrOneBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), false, 0, 1)
+}
+
+// Matcher::MatchZeroOrOneRunes
+func (r *recorder[M, O, E]) MatchZeroOrOneRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), false, 0, 1)
+}
+
+// Matcher::MatchZeroOrOneRune
+func (r *recorder[M, O, E]) MatchZeroOrOneRune(match rune) O {
+	return r.class(NewRuneSet(match), false, 0, 1)
+}
+
+// Matcher::MatchZeroOrOneFunc
+func (r *recorder[M, O, E]) MatchZeroOrOneFunc(match lexer.MatchFn) O {
+	return r.fn(match, false, 0, 1)
+}
+
+// Matcher::MatchZeroOrOneSet
+func (r *recorder[M, O, E]) MatchZeroOrOneSet(match *RuneSet) O {
+	return r.class(match, false, 0, 1)
+}
+
+// Matcher::MatchZeroOrMoreBytes
+func (r *recorder[M, O, E]) MatchZeroOrMoreBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), false, 0, -1)
+}
+
+// Matcher::MatchZeroOrMoreRunes
+func (r *recorder[M, O, E]) MatchZeroOrMoreRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), false, 0, -1)
+}
+
+// Matcher::MatchZeroOrMoreFunc
+func (r *recorder[M, O, E]) MatchZeroOrMoreFunc(match lexer.MatchFn) O {
+	return r.fn(match, false, 0, -1)
+}
+
+// Matcher::MatchZeroOrMoreSet
+func (r *recorder[M, O, E]) MatchZeroOrMoreSet(match *RuneSet) O {
+	return r.class(match, false, 0, -1)
+}
+
+// Matcher::MatchOneBytes
+func (r *recorder[M, O, E]) MatchOneBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), false, 1, 1)
+}
+
+// Matcher::MatchOneRunes
+func (r *recorder[M, O, E]) MatchOneRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), false, 1, 1)
+}
+
+// Matcher::MatchOneRune
+func (r *recorder[M, O, E]) MatchOneRune(match rune) O {
+	return r.class(NewRuneSet(match), false, 1, 1)
+}
+
+// Matcher::MatchOneFunc
+func (r *recorder[M, O, E]) MatchOneFunc(match lexer.MatchFn) O {
+	return r.fn(match, false, 1, 1)
+}
+
+// Matcher::MatchOneSet
+func (r *recorder[M, O, E]) MatchOneSet(match *RuneSet) O {
+	return r.class(match, false, 1, 1)
+}
+
+// Matcher::MatchOneOrMoreBytes
+func (r *recorder[M, O, E]) MatchOneOrMoreBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), false, 1, -1)
+}
+
+// Matcher::MatchOneOrMoreRunes
+func (r *recorder[M, O, E]) MatchOneOrMoreRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), false, 1, -1)
+}
+
+// Matcher::MatchOneOrMoreFunc
+func (r *recorder[M, O, E]) MatchOneOrMoreFunc(match lexer.MatchFn) O {
+	return r.fn(match, false, 1, -1)
+}
+
+// Matcher::MatchOneOrMoreSet
+func (r *recorder[M, O, E]) MatchOneOrMoreSet(match *RuneSet) O {
+	return r.class(match, false, 1, -1)
+}
+
+// Matcher::MatchMinMaxBytes
+func (r *recorder[M, O, E]) MatchMinMaxBytes(match []byte, min int, max int) O {
+	return r.class(NewRuneSetFromBytes(match), false, min, max)
+}
+
+// Matcher::MatchMinMaxRunes
+func (r *recorder[M, O, E]) MatchMinMaxRunes(match []rune, min int, max int) O {
+	return r.class(NewRuneSet(match...), false, min, max)
+}
+
+// Matcher::MatchMinMaxFunc
+func (r *recorder[M, O, E]) MatchMinMaxFunc(match lexer.MatchFn, min int, max int) O {
+	return r.fn(match, false, min, max)
+}
+
+// Matcher::MatchMinMaxSet
+func (r *recorder[M, O, E]) MatchMinMaxSet(match *RuneSet, min int, max int) O {
+	return r.class(match, false, min, max)
+}
+
+// Matcher::NonMatchZeroOrOneBytes
+func (r *recorder[M, O, E]) NonMatchZeroOrOneBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), true, 0, 1)
+}
+
+// Matcher::NonMatchZeroOrOneRunes
+func (r *recorder[M, O, E]) NonMatchZeroOrOneRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), true, 0, 1)
+}
+
+// Matcher::NonMatchZeroOrOneFunc
+func (r *recorder[M, O, E]) NonMatchZeroOrOneFunc(match lexer.MatchFn) O {
+	return r.fn(match, true, 0, 1)
+}
+
+// Matcher::NonMatchZeroOrOneSet
+func (r *recorder[M, O, E]) NonMatchZeroOrOneSet(match *RuneSet) O {
+	return r.class(match, true, 0, 1)
+}
+
+// Matcher::NonMatchZeroOrMoreBytes
+func (r *recorder[M, O, E]) NonMatchZeroOrMoreBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), true, 0, -1)
+}
+
+// Matcher::NonMatchZeroOrMoreRunes
+func (r *recorder[M, O, E]) NonMatchZeroOrMoreRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), true, 0, -1)
+}
+
+// Matcher::NonMatchZeroOrMoreFunc
+func (r *recorder[M, O, E]) NonMatchZeroOrMoreFunc(match lexer.MatchFn) O {
+	return r.fn(match, true, 0, -1)
+}
+
+// Matcher::NonMatchZeroOrMoreSet
+func (r *recorder[M, O, E]) NonMatchZeroOrMoreSet(match *RuneSet) O {
+	return r.class(match, true, 0, -1)
+}
+
+// Matcher::NonMatchOneBytes
+func (r *recorder[M, O, E]) NonMatchOneBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), true, 1, 1)
+}
+
+// Matcher::NonMatchOneRunes
+func (r *recorder[M, O, E]) NonMatchOneRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), true, 1, 1)
+}
+
+// Matcher::NonMatchOneFunc
+func (r *recorder[M, O, E]) NonMatchOneFunc(match lexer.MatchFn) O {
+	return r.fn(match, true, 1, 1)
+}
+
+// Matcher::NonMatchOneSet
+func (r *recorder[M, O, E]) NonMatchOneSet(match *RuneSet) O {
+	return r.class(match, true, 1, 1)
+}
+
+// Matcher::NonMatchOneOrMoreBytes
+func (r *recorder[M, O, E]) NonMatchOneOrMoreBytes(match []byte) O {
+	return r.class(NewRuneSetFromBytes(match), true, 1, -1)
+}
+
+// Matcher::NonMatchOneOrMoreRunes
+func (r *recorder[M, O, E]) NonMatchOneOrMoreRunes(match []rune) O {
+	return r.class(NewRuneSet(match...), true, 1, -1)
+}
+
+// Matcher::NonMatchOneOrMoreFunc
+func (r *recorder[M, O, E]) NonMatchOneOrMoreFunc(match lexer.MatchFn) O {
+	return r.fn(match, true, 1, -1)
+}
+
+// Matcher::NonMatchOneOrMoreSet
+func (r *recorder[M, O, E]) NonMatchOneOrMoreSet(match *RuneSet) O {
+	return r.class(match, true, 1, -1)
+}
+
+// Matcher::MatchEOF
+func (r *recorder[M, O, E]) MatchEOF() O {
+	r.b.add(&node{kind: nodeEOF})
+	return r.operand()
+}
+
+// Matcher::MatchBOL
+func (r *recorder[M, O, E]) MatchBOL() O {
+	r.b.add(&node{kind: nodeAnchor, anchor: anchorBOL})
+	return r.operand()
+}
+
+// Matcher::MatchEOL
+func (r *recorder[M, O, E]) MatchEOL() O {
+	r.b.add(&node{kind: nodeAnchor, anchor: anchorEOL})
+	return r.operand()
+}
+
+// Matcher::MatchWordBoundary
+func (r *recorder[M, O, E]) MatchWordBoundary() O {
+	r.b.add(&node{kind: nodeAnchor, anchor: anchorWordBoundary})
+	return r.operand()
+}
+
+// Matcher::MatchNotWordBoundary
+func (r *recorder[M, O, E]) MatchNotWordBoundary() O {
+	r.b.add(&node{kind: nodeAnchor, anchor: anchorNotWordBoundary})
+	return r.operand()
+}
+
+// Matcher::MatchBOF
+func (r *recorder[M, O, E]) MatchBOF() O {
+	r.b.add(&node{kind: nodeAnchor, anchor: anchorBOF})
+	return r.operand()
+}
+
+// Matcher::MatchPattern
+func (r *recorder[M, O, E]) MatchPattern(p *Pattern) O {
+	if p.name != "" {
+		r.b.add(p.ref())
+		return r.operand()
+	}
+
+	// The root is copied, as the node is given the skipper of the group
+	root := *p.root
+	root.embedded = true
+	r.b.add(&root)
+	return r.operand()
+}
+
+// PatternMatcher::MatchBackref
+func (r *recorder[M, O, E]) MatchBackref(name string) O {
+	r.b.backref(name)
+	return r.operand()
+}
+
+// Matcher::MatchUntilString
+func (r *recorder[M, O, E]) MatchUntilString(term string, inclusive bool) O {
+	checkUntilTerm(term)
+	r.b.add(&node{kind: nodeUntil, until: term, inclusive: inclusive, escape: noEscape})
+	return r.operand()
+}
+
+// Matcher::MatchUntilUnescaped
+func (r *recorder[M, O, E]) MatchUntilUnescaped(term string, escape rune) O {
+	checkUntilTerm(term)
+	r.b.add(&node{kind: nodeUntil, until: term, escape: escape})
+	return r.operand()
+}
+
+// Matcher::MatchUntilPattern
+func (r *recorder[M, O, E]) MatchUntilPattern(p *Pattern) O {
+	r.b.add(&node{kind: nodeUntil, stop: p.ref(), class: p.first, escape: noEscape})
+	return r.operand()
+}
+
+// Matcher::MatchBalanced
+func (r *recorder[M, O, E]) MatchBalanced(open rune, close rune, opts BalancedOptions) O {
+	checkBalanced(open, close)
+	r.b.add(&node{kind: nodeBalanced, open: open, close: close, quotes: opts.Quotes, escape: opts.balancedEscape()})
+	return r.operand()
+}
+
+// Matcher::MatchList
+func (r *recorder[M, O, E]) MatchList(item *Pattern, sep *Pattern, opts ListOptions) O {
+	checkListOptions(opts)
+	r.b.add(&node{kind: nodeList, item: item.ref(), sep: sep.ref(), min: opts.Min, max: listMax(opts), trailing: opts.AllowTrailing})
+	return r.operand()
+}
+
+// Matcher::Begin
+func (r *recorder[M, O, E]) Begin() M {
+	r.b.begin()
+	return r.matcher()
+}
+
+// PatternMatcher::BeginCapture
+func (r *recorder[M, O, E]) BeginCapture(name string) M {
+	r.b.capture(name)
+	return r.matcher()
+}
+
+// Matcher::BeginSkipping
+func (r *recorder[M, O, E]) BeginSkipping(skipper *Pattern) M {
+	r.b.beginSkipping(skipper.ref())
+	return r.matcher()
+}
+
+// Matcher::Lexeme
+func (r *recorder[M, O, E]) Lexeme() M {
+	r.b.beginSkipping(nil)
+	return r.matcher()
+}
+
+// Matcher::End
+func (r *recorder[M, O, E]) End() E {
+	return any(r).(E)
+}
+
+// Matcher::EndMatchZeroOrOne
+func (r *recorder[M, O, E]) EndMatchZeroOrOne() O {
+	return r.end(true)
+}
+
+// Matcher::EndMatchOne
+func (r *recorder[M, O, E]) EndMatchOne() O {
+	return r.end(false)
+}
+
+// Matcher::Result
+func (r *recorder[M, O, E]) Result() bool {
+	panic("Calling Result() while compiling a Pattern")
+}
+
+// Matcher::Reset
+func (r *recorder[M, O, E]) Reset() M {
+	panic("Calling Reset() while compiling a Pattern")
+}
+
+// Matcher::StartPos
+func (r *recorder[M, O, E]) StartPos() Pos {
+	panic("Calling StartPos() while compiling a Pattern")
+}
+
+// Matcher::EndPos
+func (r *recorder[M, O, E]) EndPos() Pos {
+	panic("Calling EndPos() while compiling a Pattern")
+}
+
+// Matcher::Err
+func (r *recorder[M, O, E]) Err() error {
+	panic("Calling Err() while compiling a Pattern")
+}
+
+// Matcher::ListItems
+func (r *recorder[M, O, E]) ListItems() []Span {
+	panic("Calling ListItems() while compiling a Pattern")
+}
+
+/*****************************************************************************
+ * Matcher End
+ *****************************************************************************/
+
+// MatcherEnd::MatchZeroOrOne
+func (r *recorder[M, O, E]) MatchZeroOrOne() O {
+	return r.end(true)
+}
+
+// MatcherEnd::MatchOne
+func (r *recorder[M, O, E]) MatchOne() O {
+	return r.end(false)
+}
+
+// MatcherEnd::Atomic
+func (r *recorder[M, O, E]) Atomic() O {
+	r.b.end(false).atomic = true
+	return r.operand()
+}
+
+/*****************************************************************************
+ * Matcher Operator
+ *****************************************************************************/
+
+// MatcherOperator::And
+func (r *recorder[M, O, E]) And() M {
+	return r.operator(opAnd)
+}
+
+// MatcherOperator::Or
+func (r *recorder[M, O, E]) Or() M {
+	return r.operator(opOr)
+}
+
+// MatcherOperator::AndBegin
+func (r *recorder[M, O, E]) AndBegin() M {
+	r.b.operator(opAnd)
+	r.b.begin()
+	return r.matcher()
+}
+
+// MatcherOperator::OrBegin
+func (r *recorder[M, O, E]) OrBegin() M {
+	r.b.operator(opOr)
+	r.b.begin()
+	return r.matcher()
+}
+
+// PatternOperator::Lazy
+func (r *recorder[M, O, E]) Lazy() O {
+	r.b.lazy()
+	return r.operand()
+}
+
+// MatcherOperator::Possessive
+func (r *recorder[M, O, E]) Possessive() O {
+	r.b.possessive()
+	return r.operand()
+}
+
+// MatcherOperator::Cut
+func (r *recorder[M, O, E]) Cut() O {
+	r.b.cut()
+	return r.operand()
+}
@@ -0,0 +1,172 @@
+package matcher
+
+// ReplaceAll returns a copy of src, replacing matches of the pattern with
+// the template repl.  Inside repl, $name or ${name} is replaced by the text
+// captured by the group named with BeginCapture(name), $0 by the text of the
+// whole match, and $$ by a literal $.  Groups that did not take part in the
+// match expand to the empty string.
+func (p *Pattern) ReplaceAll(src []byte, repl []byte) []byte {
+	return p.replace(src, func(dst []byte, start int, end int) []byte {
+		return p.expand(dst, repl, src, start, end)
+	})
+}
+
+// ReplaceAllFunc returns a copy of src, replacing matches of the pattern
+// with the return value of repl applied to the matched text.  The
+// replacement is used directly, without template expansion.
+func (p *Pattern) ReplaceAllFunc(src []byte, repl func([]byte) []byte) []byte {
+	return p.replace(src, func(dst []byte, start int, end int) []byte {
+		return append(dst, repl(src[start:end])...)
+	})
+}
+
+// Split slices b into the substrings separated by matches of the pattern,
+// returning at most n substrings, the last being the unsplit remainder, or
+// all of them if n < 0.  As with regexp, n == 0 returns nil, and an empty
+// match at the start or end of b does not produce an empty substring.
+func (p *Pattern) Split(b []byte, n int) [][]byte {
+	if n == 0 {
+		return nil
+	}
+
+	if len(b) == 0 {
+		return [][]byte{b}
+	}
+
+	var result [][]byte
+
+	start, end := 0, 0
+
+	p.allMatches(b, n, func(matchStart int, matchEnd int) {
+		if n > 0 && len(result) == n-1 {
+			return
+		}
+
+		end = matchStart
+
+		if matchEnd != 0 {
+			result = append(result, b[start:end:end])
+		}
+
+		start = matchEnd
+	})
+
+	if end != len(b) {
+		result = append(result, b[start:])
+	}
+
+	return result
+}
+
+// Pattern::replace copies src, appending the replacement of each match with
+// repl
+func (p *Pattern) replace(src []byte, repl func([]byte, int, int) []byte) []byte {
+	var dst []byte
+
+	last := 0
+
+	p.allMatches(src, -1, func(start int, end int) {
+		dst = append(dst, src[last:start]...)
+
+		dst = repl(dst, start, end)
+
+		last = end
+	})
+
+	return append(dst, src[last:]...)
+}
+
+// Pattern::expand appends the template, expanded for the match of src
+// between start and end, to dst
+func (p *Pattern) expand(dst []byte, template []byte, src []byte, start int, end int) []byte {
+	var caps []capture
+
+	for len(template) > 0 {
+		i := 0
+
+		for i < len(template) && template[i] != '$' {
+			i++
+		}
+
+		dst, template = append(dst, template[:i]...), template[i:]
+
+		if len(template) == 0 {
+			break
+		}
+
+		if len(template) > 1 && template[1] == '$' {
+			dst, template = append(dst, '$'), template[2:]
+			continue
+		}
+
+		name, rest, ok := expandName(template)
+
+		if !ok {
+			dst, template = append(dst, '$'), template[1:] // Malformed, kept as is
+			continue
+		}
+
+		template = rest
+
+		if name == "0" {
+			dst = append(dst, src[start:end]...)
+			continue
+		}
+
+		if caps == nil {
+			caps = p.captures(src, start)
+		}
+
+		// The last capture of a name wins
+		if j := lastCapture(caps, name); j >= 0 {
+			dst = append(dst, src[caps[j].start:caps[j].end]...)
+		}
+	}
+
+	return dst
+}
+
+// expandName parses $name or ${name} at the start of the template, returning
+// the name and the rest of the template
+func expandName(template []byte) (string, []byte, bool) {
+	braced := len(template) > 1 && template[1] == '{'
+
+	i := 1
+	if braced {
+		i = 2
+	}
+
+	j := i
+
+	for j < len(template) && isNameByte(template[j]) {
+		j++
+	}
+
+	if j == i {
+		return "", nil, false
+	}
+
+	if !braced {
+		return string(template[i:j]), template[j:], true
+	}
+
+	if j == len(template) || template[j] != '}' {
+		return "", nil, false
+	}
+
+	return string(template[i:j]), template[j+1:], true
+}
+
+// isNameByte returns true for the bytes allowed in a $name
+func isNameByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// Pattern::captures returns the captures of the match at offset start of src
+func (p *Pattern) captures(src []byte, start int) []capture {
+	e := &executor{src: src, pos: start, caps: []capture{}}
+
+	e.match(p.root)
+
+	return e.caps
+}
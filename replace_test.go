@@ -0,0 +1,90 @@
+package matcher
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+// assignment matches key=value, capturing key and value, as the regexp
+// (?P<key>[a-z]+)=(?P<value>[0-9]*)
+var assignment = Compile(func(m PatternMatcher) PatternOperator {
+	return m.BeginCapture("key").MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).EndMatchOne().
+		And().MatchOneRune('=').
+		And().BeginCapture("value").MatchZeroOrMoreSet(NewRuneSetFromRangeString("0-9")).EndMatchOne()
+})
+
+// TestReplaceAll checks template expansion against regexp
+func TestReplaceAll(t *testing.T) {
+	re := regexp.MustCompile(`(?P<key>[a-z]+)=(?P<value>[0-9]*)`)
+
+	templates := []string{"$value:$key", "${key}_x", "$key_x", "[$0]", "$$key", "$", "${key", "$missing|", "$1"}
+
+	for _, input := range []string{"a=1;bc=22", "x=", "no match", ""} {
+		for _, template := range templates {
+			got := assignment.ReplaceAll([]byte(input), []byte(template))
+
+			// $1 is the first group for regexp, but an unknown name here
+			want := re.ReplaceAll([]byte(input), bytes.ReplaceAll([]byte(template), []byte("$1"), []byte("$missing")))
+
+			if string(got) != string(want) {
+				t.Errorf("ReplaceAll(%q, %q) = %q, want %q", input, template, got, want)
+			}
+		}
+	}
+}
+
+// TestReplaceAllFunc checks that each match is replaced by the result of the
+// function, unexpanded, including empty matches
+func TestReplaceAllFunc(t *testing.T) {
+	tests := []struct {
+		pattern *Pattern
+		input   string
+		want    string
+	}{
+		{assignment, "a=1;bc=22", "<a=1>;<bc=22>"},
+		{assignment, "x=$key", "<x=>$key"},
+		{assignment, "none", "none"},
+		{Compile(func(m Matcher) MatcherOperator { return m.MatchZeroOrMoreRunes([]rune{'a'}) }), "baac", "<>b<aa>c<>"},
+	}
+
+	for _, test := range tests {
+		got := test.pattern.ReplaceAllFunc([]byte(test.input), func(b []byte) []byte {
+			return []byte("<" + string(b) + ">")
+		})
+
+		if string(got) != test.want {
+			t.Errorf("ReplaceAllFunc(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+// TestSplit checks Split against regexp, for each n, with separators and
+// empty matches
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		pattern *Pattern
+		re      string
+		inputs  []string
+	}{
+		{Compile(func(m Matcher) MatcherOperator { return m.MatchOneOrMoreRunes([]rune(", ")) }),
+			`[, ]+`, []string{"a, b,c", ",a,", "abc", "", ", ,"}},
+		{Compile(func(m Matcher) MatcherOperator { return m.MatchZeroOrMoreRunes([]rune{','}) }),
+			`,*`, []string{"abc", "a,,b", ",", "", "é,ü"}},
+	}
+
+	for _, test := range tests {
+		re := regexp.MustCompile(test.re)
+
+		for _, input := range test.inputs {
+			for _, n := range []int{-1, 0, 1, 2, 3} {
+				got, want := test.pattern.Split([]byte(input), n), re.Split(input, n)
+
+				if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) || (got == nil) != (want == nil) {
+					t.Errorf("%s: Split(%q, %d) = %q, want %q", test.re, input, n, got, want)
+				}
+			}
+		}
+	}
+}
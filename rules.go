@@ -0,0 +1,221 @@
+package matcher
+
+import (
+	"github.com/iNamik/go_lexer"
+)
+
+// Rules is a table of Patterns and the tokens they produce, from which a
+// lexer.StateFn can be generated.  At each position, every rule is tried and
+// the longest match wins (maximal munch).  Ties go to the rule with the
+// highest priority, and then to the rule that was added first.
+//
+// Rules can be organised into named modes, with rules pushing, popping or
+// switching the active mode once they match.  Only the rules of the active
+// mode are tried.
+//
+// Every Rules table has a TokenSet, shared by its modes, which names the
+// token types its rules emit.
+type Rules struct {
+	name   string
+	modes  map[string]*Rules
+	rules  []*rule
+	tokens *TokenSet
+}
+
+// ruleAction identifies the change of mode performed after a rule matches
+type ruleAction int
+
+const (
+	actionNone ruleAction = iota
+	actionPush
+	actionPop
+	actionSwitch
+)
+
+// rule is a single entry of a Rules table
+type rule struct {
+	pattern  *Pattern
+	token    lexer.TokenType
+	priority int
+	skip     bool
+	action   ruleAction
+	mode     string
+}
+
+// NewRules creates a new, empty, Rules table, with a new TokenSet.  The table
+// itself is the default mode, named "".
+func NewRules() *Rules {
+	return NewRulesWithTokenSet(NewTokenSet())
+}
+
+// NewRulesWithTokenSet creates a new, empty, Rules table, naming its token
+// types with the specified TokenSet
+func NewRulesWithTokenSet(tokens *TokenSet) *Rules {
+	r := &Rules{modes: make(map[string]*Rules), tokens: tokens}
+
+	r.modes[r.name] = r
+
+	return r
+}
+
+// Mode returns the rules of the named mode, creating the mode if needed
+func (r *Rules) Mode(name string) *Rules {
+	mode, ok := r.modes[name]
+
+	if !ok {
+		mode = &Rules{name: name, modes: r.modes, tokens: r.tokens}
+		r.modes[name] = mode
+	}
+
+	return mode
+}
+
+// Add adds a rule that emits a token, with its bytes, of the specified type
+func (r *Rules) Add(p *Pattern, t lexer.TokenType, priority int) *Rules {
+	r.rules = append(r.rules, &rule{pattern: p, token: t, priority: priority})
+
+	return r
+}
+
+// AddToken adds a rule that emits a token, with its bytes, of the named type,
+// registering the name with the TokenSet if needed
+func (r *Rules) AddToken(p *Pattern, name string, priority int) *Rules {
+	t, ok := r.tokens.Type(name)
+
+	if !ok {
+		t = r.tokens.Add(name)
+	}
+
+	return r.Add(p, t, priority)
+}
+
+// TokenSet returns the TokenSet naming the token types of the rules
+func (r *Rules) TokenSet() *TokenSet {
+	return r.tokens
+}
+
+// Skip adds a rule whose matches are ignored, i.e. whitespace or comments
+func (r *Rules) Skip(p *Pattern, priority int) *Rules {
+	r.rules = append(r.rules, &rule{pattern: p, priority: priority, skip: true})
+
+	return r
+}
+
+// Push makes the most recently added rule push the named mode once it matches
+func (r *Rules) Push(mode string) *Rules {
+	return r.setAction(actionPush, mode)
+}
+
+// Pop makes the most recently added rule return to the previous mode once it
+// matches.  Popping the starting mode leaves it active.
+func (r *Rules) Pop() *Rules {
+	return r.setAction(actionPop, "")
+}
+
+// Switch makes the most recently added rule replace the active mode with the
+// named mode once it matches
+func (r *Rules) Switch(mode string) *Rules {
+	return r.setAction(actionSwitch, mode)
+}
+
+// Rules::setAction
+func (r *Rules) setAction(action ruleAction, mode string) *Rules {
+	if len(r.rules) == 0 {
+		panic("Setting a mode action before adding a rule")
+	}
+
+	last := r.rules[len(r.rules)-1]
+
+	last.action = action
+
+	last.mode = mode
+
+	return r
+}
+
+// StateFn returns a lexer.StateFn that lexes its input using the rules,
+// starting in the receiver's mode.  Runes that no rule matches are emitted
+// one at a time as lexer.TokenTypeUnknown, and EOF is emitted once the input
+// is exhausted.  Each StateFn keeps its own mode stack, so a new StateFn
+// should be created for each lexer.
+func (r *Rules) StateFn() lexer.StateFn {
+	for _, mode := range r.modes {
+		for _, rule := range mode.rules {
+			if _, ok := r.modes[rule.mode]; !ok && rule.action != actionPop {
+				panic("Rule refers to an unknown mode: " + rule.mode)
+			}
+		}
+	}
+
+	stack := []*Rules{r}
+
+	var fn lexer.StateFn
+
+	fn = func(l lexer.Lexer) lexer.StateFn {
+		if l.PeekRune(0) == lexer.RuneEOF {
+			l.EmitEOF()
+			return nil // We're done here
+		}
+
+		best, n := stack[len(stack)-1].longest(l)
+
+		if best == nil {
+			l.NextRune() // Consume unknown rune
+			l.EmitTokenWithBytes(lexer.TokenTypeUnknown)
+			return fn
+		}
+
+		for i := 0; i < n; i++ {
+			l.NextRune()
+		}
+
+		if best.skip {
+			l.IgnoreToken()
+		} else {
+			l.EmitTokenWithBytes(best.token)
+		}
+
+		switch best.action {
+		case actionPush:
+			stack = append(stack, r.modes[best.mode])
+		case actionPop:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case actionSwitch:
+			stack[len(stack)-1] = r.modes[best.mode]
+		}
+
+		return fn
+	}
+
+	return fn
+}
+
+// Rules::longest tries every rule at the current position, returning the
+// winning rule and the number of runes it matched, leaving the lexer state
+// unchanged.  Empty matches are ignored, as they would never advance the
+// lexer.
+func (r *Rules) longest(l lexer.Lexer) (*rule, int) {
+	var best *rule
+
+	bestN := 0
+
+	for _, rule := range r.rules {
+		m := l.Marker()
+
+		n, ok := rule.pattern.match(l)
+
+		l.Reset(m)
+
+		if !ok || n == 0 || n < bestN {
+			continue
+		}
+
+		if n > bestN || rule.priority > best.priority {
+			best, bestN = rule, n
+		}
+	}
+
+	return best, bestN
+}
@@ -0,0 +1,204 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// lexRules lexes the input with the rules, returning each token as
+// "NAME:text", up to and including T_EOF
+func lexRules(r *Rules, input string) string {
+	l := lexer.NewFromBytes(r.StateFn(), []byte(input), 1)
+
+	var tokens []string
+
+	for i := 0; i <= len(input)+1; i++ {
+		token := l.NextToken()
+
+		tokens = append(tokens, r.TokenSet().Name(token.Type())+":"+string(token.Bytes()))
+
+		if token.EOF() {
+			break
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// literal compiles a Pattern matching a string
+func literal(s string) *Pattern {
+	return Compile(func(m Matcher) MatcherOperator {
+		runes := []rune(s)
+		op := m.MatchOneRune(runes[0])
+		for _, r := range runes[1:] {
+			op = op.And().MatchOneRune(r)
+		}
+		return op
+	})
+}
+
+var (
+	patternTestIdent = Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z"))
+	})
+
+	patternTestSpace = Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneOrMoreBytes([]byte(" \n"))
+	})
+)
+
+// TestRules checks maximal munch, priorities, skipping and unknown runes
+func TestRules(t *testing.T) {
+	newRules := func() *Rules {
+		return NewRules().
+			AddToken(patternTestIdent, "IDENT", 0).
+			AddToken(literal("if"), "IF", 1).
+			AddToken(literal("="), "ASSIGN", 0).
+			AddToken(literal("=="), "EQ", 0).
+			AddToken(literal("=="), "EQUALS", 0). // Never wins the tie
+			Skip(patternTestSpace, 0)
+	}
+
+	tests := map[string]string{
+		"":           "T_EOF:",
+		"if":         "IF:if T_EOF:",
+		"iffy":       "IDENT:iffy T_EOF:",
+		"if fy":      "IF:if IDENT:fy T_EOF:",
+		"a = b":      "IDENT:a ASSIGN:= IDENT:b T_EOF:",
+		"a==b":       "IDENT:a EQ:== IDENT:b T_EOF:",
+		"a===b":      "IDENT:a EQ:== ASSIGN:= IDENT:b T_EOF:",
+		"a$b":        "IDENT:a T_UNKNOWN:$ IDENT:b T_EOF:",
+		"é ":         "T_UNKNOWN:é T_EOF:",
+		"  \n  ":     "T_EOF:",
+		"\nif\nelse": "IF:if IDENT:else T_EOF:",
+	}
+
+	for input, want := range tests {
+		if got := lexRules(newRules(), input); got != want {
+			t.Errorf("%q: lexed %s, want %s", input, got, want)
+		}
+	}
+}
+
+// TestRulesPriority checks that priorities decide ties between rules
+// matching the same length, whatever order they were added in
+func TestRulesPriority(t *testing.T) {
+	low := NewRules().AddToken(patternTestIdent, "IDENT", 0).AddToken(literal("if"), "IF", -1)
+	high := NewRules().AddToken(patternTestIdent, "IDENT", 0).AddToken(literal("if"), "IF", 1)
+	first := NewRules().AddToken(literal("if"), "IF", 0).AddToken(patternTestIdent, "IDENT", 0)
+
+	for _, test := range []struct {
+		rules *Rules
+		want  string
+	}{
+		{low, "IDENT:if T_EOF:"},
+		{high, "IF:if T_EOF:"},
+		{first, "IF:if T_EOF:"},
+	} {
+		if got := lexRules(test.rules, "if"); got != test.want {
+			t.Errorf("lexed %s, want %s", got, test.want)
+		}
+	}
+}
+
+// TestRulesEmptyMatch checks that empty matches never win
+func TestRulesEmptyMatch(t *testing.T) {
+	empty := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchZeroOrMoreRunes([]rune("x"))
+	})
+
+	r := NewRules().AddToken(empty, "X", 10).AddToken(patternTestIdent, "IDENT", 0)
+
+	if got, want := lexRules(r, "ab"), "IDENT:ab T_EOF:"; got != want {
+		t.Errorf("lexed %s, want %s", got, want)
+	}
+
+	if got, want := lexRules(r, "1"), "T_UNKNOWN:1 T_EOF:"; got != want {
+		t.Errorf("lexed %s, want %s", got, want)
+	}
+}
+
+// TestRulesModes checks pushing, popping and switching modes
+func TestRulesModes(t *testing.T) {
+	r := NewRules()
+
+	r.AddToken(patternTestIdent, "IDENT", 0).
+		AddToken(literal(`"`), "QUOTE", 0).Push("string").
+		AddToken(literal("{"), "LBRACE", 0).Push("block").
+		AddToken(literal("}"), "RBRACE", 0).Pop().
+		AddToken(literal("%"), "PERCENT", 0).Switch("comment").
+		Skip(patternTestSpace, 0)
+
+	r.Mode("string").
+		AddToken(literal(`"`), "END_QUOTE", 0).Pop().
+		AddToken(Compile(func(m Matcher) MatcherOperator {
+			return m.NonMatchOneOrMoreRunes([]rune(`"{`))
+		}), "TEXT", 0).
+		AddToken(literal("{"), "INTERP", 0).Push("block")
+
+	r.Mode("block").
+		AddToken(patternTestIdent, "NAME", 0).
+		AddToken(literal("}"), "END_BLOCK", 0).Pop().
+		Skip(patternTestSpace, 0)
+
+	r.Mode("comment").
+		AddToken(Compile(func(m Matcher) MatcherOperator {
+			return m.NonMatchOneOrMoreRunes([]rune("\n"))
+		}), "COMMENT", 0).
+		Skip(literal("\n"), 0).Switch("")
+
+	tests := map[string]string{
+		`a "b c" d`:     `IDENT:a QUOTE:" TEXT:b c END_QUOTE:" IDENT:d T_EOF:`,
+		`"x{y}z" w`:     `QUOTE:" TEXT:x INTERP:{ NAME:y END_BLOCK:} TEXT:z END_QUOTE:" IDENT:w T_EOF:`,
+		"{ a } b":       "LBRACE:{ NAME:a END_BLOCK:} IDENT:b T_EOF:",
+		"} } a":         "RBRACE:} RBRACE:} IDENT:a T_EOF:",
+		"%a \"b\nc \"":  `PERCENT:% COMMENT:a "b IDENT:c QUOTE:" T_EOF:`,
+		`"unterminated`: `QUOTE:" TEXT:unterminated T_EOF:`,
+	}
+
+	for input, want := range tests {
+		if got := lexRules(r, input); got != want {
+			t.Errorf("%q: lexed %s, want %s", input, got, want)
+		}
+	}
+}
+
+// TestRulesModeStacks checks that each StateFn keeps its own mode stack
+func TestRulesModeStacks(t *testing.T) {
+	r := NewRules().AddToken(literal("("), "OPEN", 0).Push("inner")
+	r.Mode("inner").AddToken(literal("("), "INNER", 0)
+
+	if got, want := lexRules(r, "(("), "OPEN:( INNER:( T_EOF:"; got != want {
+		t.Errorf("lexed %s, want %s", got, want)
+	}
+
+	if got, want := lexRules(r, "("), "OPEN:( T_EOF:"; got != want {
+		t.Errorf("second StateFn lexed %s, want %s", got, want)
+	}
+}
+
+// TestRulesPanics checks that mode actions need a rule, and a known mode
+func TestRulesPanics(t *testing.T) {
+	tests := map[string]func(){
+		"action without rule": func() {
+			NewRules().Push("x")
+		},
+		"unknown mode": func() {
+			NewRules().AddToken(literal("a"), "A", 0).Push("missing").StateFn()
+		},
+	}
+
+	for name, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", name)
+				}
+			}()
+
+			fn()
+		}()
+	}
+}
@@ -0,0 +1,228 @@
+package matcher
+
+import (
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RuneSet is a set of runes, such as a character class, with constant-time
+// membership tests for runes below 256 and a binary search of ranges for the
+// rest.  RuneSets can be used with the *Set functions of Matcher, or passed to
+// any lexer.MatchFn-based function via their Contains method.
+type RuneSet struct {
+	bits   [4]uint64
+	ranges runeRanges
+}
+
+// NewRuneSet creates a RuneSet containing the specified runes
+func NewRuneSet(runes ...rune) *RuneSet {
+	return newRuneSet(runesToRanges(runes))
+}
+
+// NewRuneSetFromBytes creates a RuneSet containing the specified bytes
+func NewRuneSetFromBytes(match []byte) *RuneSet {
+	return newRuneSet(bytesToRanges(match))
+}
+
+// NewRuneSetFromRange creates a RuneSet containing the runes from lo to hi,
+// inclusive
+func NewRuneSetFromRange(lo rune, hi rune) *RuneSet {
+	if hi < lo {
+		return newRuneSet(nil)
+	}
+
+	return newRuneSet(runeRanges{{lo, hi}})
+}
+
+// NewRuneSetFromRangeString creates a RuneSet from a range string, using the
+// same syntax as rangeutil.RangeToBytes, i.e. "a-zA-Z_".  Unlike
+// RangeToBytes, ranges may contain any runes, e.g. "À-ɏ".
+func NewRuneSetFromRangeString(s string) *RuneSet {
+	var ranges []runeRange
+
+	for len(s) > 0 {
+		lo, w := utf8.DecodeRuneInString(s)
+		s = s[w:]
+
+		hi := lo
+
+		if len(s) > 1 && s[0] == '-' {
+			hi, w = utf8.DecodeRuneInString(s[1:])
+			s = s[1+w:]
+		}
+
+		if hi >= lo {
+			ranges = append(ranges, runeRange{lo, hi})
+		}
+	}
+
+	return newRuneSet(newRuneRanges(ranges))
+}
+
+// NewRuneSetFromTable creates a RuneSet containing the runes of a Unicode
+// range table, e.g. unicode.Letter or unicode.Greek
+func NewRuneSetFromTable(table *unicode.RangeTable) *RuneSet {
+	var ranges []runeRange
+
+	for _, r := range table.R16 {
+		ranges = appendStride(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+
+	for _, r := range table.R32 {
+		ranges = appendStride(ranges, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+
+	return newRuneSet(newRuneRanges(ranges))
+}
+
+// appendStride appends the runes from lo to hi, stepping by stride
+func appendStride(ranges []runeRange, lo rune, hi rune, stride rune) []runeRange {
+	if stride == 1 {
+		return append(ranges, runeRange{lo, hi})
+	}
+
+	for r := lo; r <= hi; r += stride {
+		ranges = append(ranges, runeRange{r, r})
+	}
+
+	return ranges
+}
+
+// posixClasses are the ASCII classes available to NewRuneSetFromClass
+var posixClasses = map[string]runeRanges{
+	"alnum":  {{'0', '9'}, {'A', 'Z'}, {'a', 'z'}},
+	"alpha":  {{'A', 'Z'}, {'a', 'z'}},
+	"ascii":  {{0x00, 0x7f}},
+	"blank":  {{'\t', '\t'}, {' ', ' '}},
+	"cntrl":  {{0x00, 0x1f}, {0x7f, 0x7f}},
+	"digit":  {{'0', '9'}},
+	"graph":  {{'!', '~'}},
+	"lower":  {{'a', 'z'}},
+	"print":  {{' ', '~'}},
+	"punct":  {{'!', '/'}, {':', '@'}, {'[', '`'}, {'{', '~'}},
+	"space":  {{'\t', '\r'}, {' ', ' '}},
+	"upper":  {{'A', 'Z'}},
+	"word":   {{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}},
+	"xdigit": {{'0', '9'}, {'A', 'F'}, {'a', 'f'}},
+}
+
+// perlClasses are the shorthand classes available to NewRuneSetFromClass
+var perlClasses = map[byte]runeRanges{
+	'd': posixClasses["digit"],
+	's': {{'\t', '\n'}, {'\f', '\r'}, {' ', ' '}},
+	'w': posixClasses["word"],
+}
+
+// NewRuneSetFromClass creates a RuneSet from a POSIX class name, such as
+// "[:alpha:]" or "[:^space:]", or from a Perl shorthand, such as `\d`, `\w`,
+// `\s` or their negations `\D`, `\W`, `\S`.  As with Go's regexp package,
+// these classes are ASCII-only.  Panics if the class is not known.
+func NewRuneSetFromClass(class string) *RuneSet {
+	if len(class) == 2 && class[0] == '\\' {
+		if ranges, ok := perlClasses[class[1]|0x20]; ok {
+			s := newRuneSet(ranges)
+			if class[1] < 'a' {
+				s = s.Complement()
+			}
+			return s
+		}
+	}
+
+	if len(class) > 4 && class[:2] == "[:" && class[len(class)-2:] == ":]" {
+		name := class[2 : len(class)-2]
+		negate := name[0] == '^'
+		if negate {
+			name = name[1:]
+		}
+		if ranges, ok := posixClasses[name]; ok {
+			s := newRuneSet(ranges)
+			if negate {
+				s = s.Complement()
+			}
+			return s
+		}
+	}
+
+	panic("Unknown character class: " + class)
+}
+
+// newRuneSet creates a RuneSet from normalized ranges
+func newRuneSet(ranges runeRanges) *RuneSet {
+	s := &RuneSet{ranges: ranges}
+
+	for _, r := range ranges {
+		for c := r.lo; c <= r.hi && c < 256; c++ {
+			s.bits[c>>6] |= 1 << uint(c&63)
+		}
+	}
+
+	return s
+}
+
+// Contains returns true if the rune is in the set.  RuneEOF is never in a set.
+func (s *RuneSet) Contains(r rune) bool {
+	if r >= 0 && r < 256 {
+		return s.bits[r>>6]&(1<<uint(r&63)) != 0
+	}
+
+	return r >= 256 && s.ranges.contains(r)
+}
+
+// Union returns a new set containing the runes in either set
+func (s *RuneSet) Union(other *RuneSet) *RuneSet {
+	return newRuneSet(newRuneRanges(append(append([]runeRange(nil), s.ranges...), other.ranges...)))
+}
+
+// Intersect returns a new set containing the runes in both sets
+func (s *RuneSet) Intersect(other *RuneSet) *RuneSet {
+	return newRuneSet(s.ranges.intersect(other.ranges))
+}
+
+// Subtract returns a new set containing the runes in s that are not in other
+func (s *RuneSet) Subtract(other *RuneSet) *RuneSet {
+	return newRuneSet(s.ranges.intersect(other.ranges.negate()))
+}
+
+// Complement returns a new set containing every rune not in s
+func (s *RuneSet) Complement() *RuneSet {
+	return newRuneSet(s.ranges.negate())
+}
+
+// String returns the set in regex class syntax, i.e. "[0-9A-Fa-f]".  Sets
+// containing unicode.MaxRune are shown negated, i.e. "[^\n]".
+func (s *RuneSet) String() string {
+	ranges, b := s.ranges, []byte("[")
+
+	if n := len(ranges); n > 0 && ranges[n-1].hi == unicode.MaxRune {
+		ranges, b = ranges.negate(), []byte("[^")
+	}
+
+	for _, r := range ranges {
+		b = appendClassRune(b, r.lo)
+
+		if r.hi > r.lo+1 {
+			b = append(b, '-')
+		}
+
+		if r.hi > r.lo {
+			b = appendClassRune(b, r.hi)
+		}
+	}
+
+	return string(append(b, ']'))
+}
+
+// appendClassRune appends a rune to a regex class, escaping it if needed
+func appendClassRune(b []byte, r rune) []byte {
+	switch {
+	case r == '\\' || r == '[' || r == ']' || r == '^' || r == '-':
+		return append(b, '\\', byte(r))
+	case r < utf8.RuneSelf && unicode.IsPrint(r):
+		return append(b, byte(r))
+	}
+
+	q := strconv.QuoteRune(r)
+
+	return append(b, q[1:len(q)-1]...)
+}
@@ -0,0 +1,110 @@
+package matcher
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// runeSetTest is a RuneSet, and runes that are and are not in it
+type runeSetTest struct {
+	name string
+	set  *RuneSet
+	in   []rune
+	out  []rune
+}
+
+// runRuneSetTests
+func runRuneSetTests(t *testing.T, tests []runeSetTest) {
+	for _, test := range tests {
+		for _, r := range test.in {
+			if !test.set.Contains(r) {
+				t.Errorf("%s %s: does not contain %q", test.name, test.set, r)
+			}
+		}
+
+		for _, r := range test.out {
+			if test.set.Contains(r) {
+				t.Errorf("%s %s: contains %q", test.name, test.set, r)
+			}
+		}
+	}
+}
+
+// TestRuneSetAlgebra checks Union, Intersect, Subtract and Complement,
+// including at the edges of ranges and either side of the 256-rune bitmap
+func TestRuneSetAlgebra(t *testing.T) {
+	lower := NewRuneSetFromRange('a', 'z')
+	vowels := NewRuneSet('a', 'e', 'i', 'o', 'u')
+	latin := NewRuneSetFromRange(0xc0, 0x24f) // Straddles the bitmap
+	greek := NewRuneSetFromTable(unicode.Greek)
+
+	runRuneSetTests(t, []runeSetTest{
+		{"union", lower.Union(latin), []rune{'a', 'z', 0xc0, 0xff, 0x100, 0x24f}, []rune{'`', '{', 0xbf, 0x250}},
+		{"union of adjacent", NewRuneSetFromRange('a', 'm').Union(NewRuneSetFromRange('n', 'z')), []rune{'a', 'm', 'n', 'z'}, []rune{'`', '{'}},
+		{"intersect", lower.Intersect(vowels), []rune{'a', 'u'}, []rune{'b', 'z'}},
+		{"intersect at edges", NewRuneSetFromRange('a', 'm').Intersect(NewRuneSetFromRange('m', 'z')), []rune{'m'}, []rune{'l', 'n'}},
+		{"intersect above bitmap", latin.Intersect(NewRuneSetFromRange(0x100, 0x300)), []rune{0x100, 0x24f}, []rune{0xff, 0x250}},
+		{"disjoint intersect", lower.Intersect(greek), nil, []rune{'a', 'α'}},
+		{"subtract", lower.Subtract(vowels), []rune{'b', 'z'}, []rune{'a', 'e', 'u', 'A'}},
+		{"subtract at edges", lower.Subtract(NewRuneSetFromRange('a', 'b')).Subtract(NewRuneSetFromRange('y', 'z')), []rune{'c', 'x'}, []rune{'a', 'b', 'y', 'z'}},
+		{"subtract above bitmap", latin.Subtract(NewRuneSetFromRange(0x100, 0x17f)), []rune{0xff, 0x180}, []rune{0x100, 0x17f}},
+		{"complement", lower.Complement(), []rune{0, '`', '{', 0xff, 0x100, unicode.MaxRune}, []rune{'a', 'z', lexer.RuneEOF}},
+		{"complement above bitmap", latin.Complement(), []rune{0xbf, 0x250, unicode.MaxRune}, []rune{0xc0, 0xff, 0x100, 0x24f}},
+		{"complement of empty", NewRuneSet().Complement(), []rune{0, 0xff, 0x100, unicode.MaxRune}, []rune{lexer.RuneEOF}},
+		{"complement of complement", greek.Complement().Complement(), []rune{'α', 'Ω'}, []rune{'a', 'z'}},
+		{"table", greek, []rune{'α', 'ω', 'Ω'}, []rune{'a', 0xff}},
+		{"range string", NewRuneSetFromRangeString("a-cÀ-ÂX"), []rune{'a', 'c', 'À', 'Â', 'X'}, []rune{'d', 'Ã', 'Y', '-'}},
+		{"empty range", NewRuneSetFromRange('z', 'a'), nil, []rune{'a', 'z'}},
+	})
+}
+
+// TestRuneSetClasses checks the POSIX and Perl classes
+func TestRuneSetClasses(t *testing.T) {
+	runRuneSetTests(t, []runeSetTest{
+		{"alnum", NewRuneSetFromClass("[:alnum:]"), []rune{'0', '9', 'A', 'z'}, []rune{'_', ' ', 'é'}},
+		{"alpha", NewRuneSetFromClass("[:alpha:]"), []rune{'A', 'Z', 'a', 'z'}, []rune{'0', '@', '[', 'é'}},
+		{"^alpha", NewRuneSetFromClass("[:^alpha:]"), []rune{'0', '@', 'é'}, []rune{'A', 'z'}},
+		{"blank", NewRuneSetFromClass("[:blank:]"), []rune{' ', '\t'}, []rune{'\n'}},
+		{"cntrl", NewRuneSetFromClass("[:cntrl:]"), []rune{0, 0x1f, 0x7f}, []rune{' ', 0x80}},
+		{"punct", NewRuneSetFromClass("[:punct:]"), []rune{'!', '/', ':', '@', '[', '`', '{', '~'}, []rune{'0', 'A', ' '}},
+		{"space", NewRuneSetFromClass("[:space:]"), []rune{'\t', '\v', '\r', ' '}, []rune{'a'}},
+		{"xdigit", NewRuneSetFromClass("[:xdigit:]"), []rune{'0', 'F', 'f'}, []rune{'g', 'G'}},
+		{`\d`, NewRuneSetFromClass(`\d`), []rune{'0', '9'}, []rune{'a', '٣'}},
+		{`\D`, NewRuneSetFromClass(`\D`), []rune{'a', '٣', unicode.MaxRune}, []rune{'0', '9'}},
+		{`\s`, NewRuneSetFromClass(`\s`), []rune{'\t', '\n', '\f', '\r', ' '}, []rune{'\v', 0xa0}},
+		{`\S`, NewRuneSetFromClass(`\S`), []rune{'\v', 'a'}, []rune{' ', '\n'}},
+		{`\w`, NewRuneSetFromClass(`\w`), []rune{'0', 'A', '_', 'z'}, []rune{'-', 'é'}},
+		{`\W`, NewRuneSetFromClass(`\W`), []rune{'-', 'é'}, []rune{'_', 'a'}},
+	})
+
+	for _, class := range []string{"[:bogus:]", `\x`, "alpha", "[::]", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewRuneSetFromClass(%q) did not panic", class)
+				}
+			}()
+
+			NewRuneSetFromClass(class)
+		}()
+	}
+}
+
+// TestRuneSetString checks the regex syntax of RuneSets
+func TestRuneSetString(t *testing.T) {
+	tests := map[string]*RuneSet{
+		"[0-9A-Fa-f]": NewRuneSetFromClass("[:xdigit:]"),
+		`[\-\]ab]`:    NewRuneSet('a', 'b', '-', ']'),
+		`[^\n]`:       NewRuneSet('\n').Complement(),
+		`[\t ]`:       NewRuneSetFromClass("[:blank:]"),
+		"[]":          NewRuneSet(),
+	}
+
+	for want, set := range tests {
+		if got := set.String(); got != want {
+			t.Errorf("String() returned %s, want %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package matcher
+
+import (
+	"unicode/utf8"
+)
+
+// Find returns the leftmost match of the pattern in b, or nil if there is
+// none.  Unlike Match, the pattern is not anchored: each position of b is
+// tried in turn, and the first one where the pattern matches wins.
+func (p *Pattern) Find(b []byte) []byte {
+	loc := p.FindIndex(b)
+
+	if loc == nil {
+		return nil
+	}
+
+	return b[loc[0]:loc[1]:loc[1]]
+}
+
+// FindIndex returns the start and end offsets of the leftmost match of the
+// pattern in b, or nil if there is none
+func (p *Pattern) FindIndex(b []byte) []int {
+	start, end, ok := p.find(b, 0)
+
+	if !ok {
+		return nil
+	}
+
+	return []int{start, end}
+}
+
+// FindAll returns up to n successive, non-overlapping matches of the pattern
+// in b, or all of them if n < 0.  As with regexp, an empty match immediately
+// following another match is ignored.  Returns nil if there are no matches.
+func (p *Pattern) FindAll(b []byte, n int) [][]byte {
+	var result [][]byte
+
+	for _, loc := range p.FindAllIndex(b, n) {
+		result = append(result, b[loc[0]:loc[1]:loc[1]])
+	}
+
+	return result
+}
+
+// FindAllIndex returns the start and end offsets of up to n successive,
+// non-overlapping matches of the pattern in b, or all of them if n < 0.
+// Returns nil if there are no matches.
+func (p *Pattern) FindAllIndex(b []byte, n int) [][]int {
+	var result [][]int
+
+	p.allMatches(b, n, func(start int, end int) {
+		result = append(result, []int{start, end})
+	})
+
+	return result
+}
+
+// Pattern::allMatches calls deliver with up to n successive, non-overlapping
+// matches, or all of them if n < 0, ignoring empty matches that immediately
+// follow another match
+func (p *Pattern) allMatches(b []byte, n int, deliver func(int, int)) {
+	if n < 0 {
+		n = len(b) + 1
+	}
+
+	for pos, prevEnd, i := 0, -1, 0; pos <= len(b) && i < n; {
+		start, end, ok := p.find(b, pos)
+
+		if !ok {
+			break
+		}
+
+		if end > start || start != prevEnd {
+			deliver(start, end)
+			i++
+		}
+
+		prevEnd = end
+
+		if end > start {
+			pos = end
+		} else if start < len(b) {
+			_, w := utf8.DecodeRune(b[start:])
+			pos = start + w
+		} else {
+			pos = start + 1
+		}
+	}
+}
+
+// Pattern::find returns the offsets of the first match at or after pos.  If
+// the pattern has a first-rune set, positions starting with any other rune are
+// skipped without trying the pattern.
+func (p *Pattern) find(b []byte, pos int) (int, int, bool) {
+	if p.root.nfa != nil {
+		// The nfa tries every position at once, from the first that can match
+		return p.root.nfa.find(b, p.skipFirst(b, pos))
+	}
+
+	for pos <= len(b) {
+		w := 1
+
+		if pos < len(b) && p.first != nil {
+			r := rune(b[pos])
+
+			if r >= utf8.RuneSelf {
+				r, w = utf8.DecodeRune(b[pos:])
+			}
+
+			if !p.first.Contains(r) {
+				pos += w
+				continue
+			}
+		} else if pos < len(b) {
+			_, w = utf8.DecodeRune(b[pos:])
+		} else if p.first != nil {
+			break // The pattern cannot match the empty string
+		}
+
+		if end, ok := p.matchBytes(b, pos); ok {
+			return pos, end, true
+		}
+
+		pos += w
+	}
+
+	return 0, 0, false
+}
+
+// Pattern::skipFirst returns the first position at or after pos at which
+// the rune is in the first-rune set of the pattern, if it has one
+func (p *Pattern) skipFirst(b []byte, pos int) int {
+	if p.first == nil {
+		return pos
+	}
+
+	for pos < len(b) {
+		r, w := utf8.DecodeRune(b[pos:])
+
+		if p.first.Contains(r) {
+			break
+		}
+
+		pos += w
+	}
+
+	return pos
+}
+
+// Pattern::matchBytes matches the pattern at offset pos of b, returning the
+// offset following the match
+func (p *Pattern) matchBytes(b []byte, pos int) (int, bool) {
+	if p.dfa != nil {
+		return p.dfa.matchBytes(b, pos)
+	}
+
+	e := &executor{src: b, pos: pos}
+
+	if e.match(p.root) {
+		return e.pos, true
+	}
+
+	return 0, false
+}
+
+// firstSet returns the runes a match of the expression can start with, or nil
+// if a match can be empty, or can start with a rune matched by a Func
+func firstSet(root *node) *RuneSet {
+	ranges, nullable, known := first(root)
+
+	if nullable || !known {
+		return nil
+	}
+
+	return newRuneSet(ranges)
+}
+
+// first returns the runes a match of the node can start with, whether the
+// match can be empty, and whether the runes are known
+func first(n *node) (runeRanges, bool, bool) {
+	if n.skip == nil {
+		return firstNode(n)
+	}
+
+	// The skipper may match before the node, or match nothing
+	ranges, nullable, known := firstNode(n)
+	skip, _, skipKnown := first(n.skip)
+
+	return newRuneRanges(append(append([]runeRange{}, skip...), ranges...)), nullable, known && skipKnown
+}
+
+// firstNode returns the first runes of the node, without skipping
+func firstNode(n *node) (runeRanges, bool, bool) {
+	switch n.kind {
+	case nodeEOF, nodeAnchor:
+		return nil, true, true
+	case nodeFunc:
+		return nil, n.min == 0, false
+	case nodeBackref, nodeUntil, nodeRef:
+		return nil, true, false
+	case nodeBalanced:
+		return runeRanges{{n.open, n.open}}, false, true
+	case nodeList:
+		// A separator can only start the list if the first item can be empty
+		ranges, nullable, known := first(n.item)
+		return ranges, nullable || n.min == 0, known && !nullable
+	case nodeClass:
+		ranges := n.class.ranges
+		if n.negate {
+			ranges = ranges.negate()
+		}
+		return ranges, n.min == 0, true
+	}
+
+	var ranges []runeRange
+
+	nullable, known := false, true
+
+	for i, t := range n.terms {
+		r, tNullable, tKnown := first(t.node)
+
+		switch {
+		case i == 0, t.op == opOr:
+			// Or() rewinds, so the alternative starts where the group does
+			ranges = append(ranges, r...)
+			nullable = (i > 0 && nullable) || tNullable
+			known = known && tKnown
+		case nullable:
+			// And() follows a term that may have matched nothing
+			ranges = append(ranges, r...)
+			nullable = tNullable
+			known = known && tKnown
+		}
+	}
+
+	return newRuneRanges(ranges), nullable || n.optional, known
+}
@@ -0,0 +1,74 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+// searchTests are Patterns, the regular expressions they are equivalent to,
+// and inputs to search
+var searchTests = []struct {
+	name    string
+	pattern *Pattern
+	re      string
+	inputs  []string
+}{
+	{"number", Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9"))
+	}), `[0-9]+`, []string{"", "abc", "42", "a1b22c333", "12 34", "é7ü"}},
+	{"empty", Compile(func(m Matcher) MatcherOperator {
+		return m.MatchZeroOrMoreRunes([]rune{'a'})
+	}), `a*`, []string{"", "b", "abaab", "aa", "éa"}},
+	{"alternatives", Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneRune('x').And().MatchOneRune('y').Or().MatchOneRune('z')
+	}), `(?:xy)|z`, []string{"xxyz", "zxy", "xzy", "x"}},
+	{"no first set", Compile(func(m Matcher) MatcherOperator {
+		return m.MatchZeroOrOneRune('-').And().MatchOneRune('1')
+	}), `-?1`, []string{"1-1--1", "-", "a-1"}},
+}
+
+// TestFind checks Find and FindIndex against the leftmost match of regexp
+func TestFind(t *testing.T) {
+	for _, test := range searchTests {
+		re := regexp.MustCompile(test.re)
+
+		for _, input := range test.inputs {
+			b := []byte(input)
+
+			if got, want := test.pattern.FindIndex(b), re.FindIndex(b); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%s: FindIndex(%q) = %v, want %v", test.name, input, got, want)
+			}
+
+			got, want := test.pattern.Find(b), re.Find(b)
+
+			if string(got) != string(want) || (got == nil) != (want == nil) {
+				t.Errorf("%s: Find(%q) = %q, want %q", test.name, input, got, want)
+			}
+		}
+	}
+}
+
+// TestFindAll checks FindAll and FindAllIndex against regexp, with and
+// without a limit
+func TestFindAll(t *testing.T) {
+	for _, test := range searchTests {
+		re := regexp.MustCompile(test.re)
+
+		for _, input := range test.inputs {
+			b := []byte(input)
+
+			for _, n := range []int{-1, 0, 1, 2} {
+				if got, want := test.pattern.FindAllIndex(b, n), re.FindAllIndex(b, n); fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("%s: FindAllIndex(%q, %d) = %v, want %v", test.name, input, n, got, want)
+				}
+
+				got, want := test.pattern.FindAll(b, n), re.FindAll(b, n)
+
+				if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) || (got == nil) != (want == nil) {
+					t.Errorf("%s: FindAll(%q, %d) = %q, want %q", test.name, input, n, got, want)
+				}
+			}
+		}
+	}
+}
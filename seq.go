@@ -0,0 +1,496 @@
+package matcher
+
+// Seq is a sequence of elements of any type, such as log records or
+// instructions, that can be matched by a SeqMatcher or a SeqPattern.  A Seq is
+// created over a slice, or over an iterator function, in which case the
+// elements it returns are buffered so that failed matches can be rewound.
+// Nothing can be rewound to before the start of a match, so the buffered
+// elements before it are dropped as the sequence is consumed.
+type Seq[T any] struct {
+	items    []T
+	next     func() (T, bool)
+	pos      int
+	base     int
+	buffered bool
+}
+
+// NewSeq creates a Seq over the elements of a slice
+func NewSeq[T any](items []T) *Seq[T] {
+	return &Seq[T]{items: items}
+}
+
+// NewSeqFromFunc creates a Seq over the elements returned by next, which
+// returns false once there are no more elements
+func NewSeqFromFunc[T any](next func() (T, bool)) *Seq[T] {
+	return &Seq[T]{next: next, buffered: true}
+}
+
+// Pos returns the number of elements consumed
+func (s *Seq[T]) Pos() int {
+	return s.pos
+}
+
+// Peek returns the element n elements after the current position, without
+// consuming it, or false if the sequence ends before it
+func (s *Seq[T]) Peek(n int) (T, bool) {
+	i := s.pos - s.base + n
+
+	for s.next != nil && len(s.items) <= i {
+		item, ok := s.next()
+
+		if !ok {
+			s.next = nil
+			break
+		}
+
+		s.items = append(s.items, item)
+	}
+
+	if i < len(s.items) {
+		return s.items[i], true
+	}
+
+	var zero T
+
+	return zero, false
+}
+
+// Next consumes and returns the next element, or false at the end of the
+// sequence
+func (s *Seq[T]) Next() (T, bool) {
+	item, ok := s.Peek(0)
+
+	if ok {
+		s.pos++
+		s.trim()
+	}
+
+	return item, ok
+}
+
+// Seq::trim drops the buffered elements before the current position, once
+// they make up half of the buffer.  They are copied to a new buffer, so the
+// slices returned by Items() are left intact.
+func (s *Seq[T]) trim() {
+	dead := s.pos - s.base
+
+	if !s.buffered || dead == 0 || dead < len(s.items)-dead {
+		return
+	}
+
+	s.items = append([]T(nil), s.items[dead:]...)
+
+	s.base = s.pos
+}
+
+// Seq::slice returns the elements between two positions
+func (s *Seq[T]) slice(start int, end int) []T {
+	return s.items[start-s.base : end-s.base : end-s.base]
+}
+
+// SeqMatcher offers the grouping, alternation and quantifiers of a Matcher
+// over a Seq, with func(T) bool predicates as operands.  The expression is
+// recorded into the same nodes as a Pattern, and matched when Result() is
+// called, so predicates should not have side effects.
+type SeqMatcher[T any] interface {
+
+	// MatchZeroOrOneFunc consumes the next element if it matches, always returning true
+	MatchZeroOrOneFunc(func(T) bool) SeqOperator[T]
+
+	// MatchZeroOrMoreFunc consumes a run of matching elements, always returning true
+	MatchZeroOrMoreFunc(func(T) bool) SeqOperator[T]
+
+	// MatchOneFunc consumes the next element if it matches
+	MatchOneFunc(func(T) bool) SeqOperator[T]
+
+	// MatchOneOrMoreFunc consumes a run of matching elements
+	MatchOneOrMoreFunc(func(T) bool) SeqOperator[T]
+
+	// MatchMinMaxFunc consumes a specified run of matching elements
+	MatchMinMaxFunc(func(T) bool, int, int) SeqOperator[T]
+
+	// NonMatchZeroOrOneFunc consumes the next element if it does not match, always returning true
+	NonMatchZeroOrOneFunc(func(T) bool) SeqOperator[T]
+
+	// NonMatchZeroOrMoreFunc consumes a run of non-matching elements, always returning true
+	NonMatchZeroOrMoreFunc(func(T) bool) SeqOperator[T]
+
+	// NonMatchOneFunc consumes the next element if it does not match
+	NonMatchOneFunc(func(T) bool) SeqOperator[T]
+
+	// NonMatchOneOrMoreFunc consumes a run of non-matching elements
+	NonMatchOneOrMoreFunc(func(T) bool) SeqOperator[T]
+
+	// MatchEnd tries to match the end of the sequence
+	MatchEnd() SeqOperator[T]
+
+	// MatchPattern consumes the elements matched by a SeqPattern
+	MatchPattern(*SeqPattern[T]) SeqOperator[T]
+
+	// Begin begins a new grouping that is expected to match (i.e required)
+	Begin() SeqMatcher[T]
+
+	// End ends a grouping. NOTE You are expected to call one of the SeqEnd
+	// functions in order to apply the result of the grouping to your current result.
+	End() SeqEnd[T]
+
+	// EndMatchOne performs End(), followed by MatchOne()
+	EndMatchOne() SeqOperator[T]
+
+	// EndMatchZeroOrOne performs End(), followed by MatchZeroOrOne
+	EndMatchZeroOrOne() SeqOperator[T]
+
+	// Result matches the expression against the sequence, returning the
+	// result and resetting the matcher.  The sequence is rewound if the
+	// result is false.
+	Result() bool
+
+	// Reset resets the state of the matcher
+	Reset() SeqMatcher[T]
+
+	// Items returns the elements consumed by the last successful Result()
+	Items() []T
+}
+
+type SeqEnd[T any] interface {
+	// MatchOne
+	MatchOne() SeqOperator[T]
+
+	// MatchZeroOrOne
+	MatchZeroOrOne() SeqOperator[T]
+
+	// Atomic performs MatchOne(), as SeqMatchers never backtrack into a grouping
+	Atomic() SeqOperator[T]
+}
+
+type SeqOperator[T any] interface {
+
+	// And Performs a logical 'and' between the current matcher state and the
+	// next operand, short-circuiting as Matcher does
+	And() SeqMatcher[T]
+
+	// Or Performs a logical 'or' between the current matcher result and the
+	// next operand, short-circuiting as Matcher does.  Elements consumed
+	// within the current grouping are rewound before the next operand is tried.
+	Or() SeqMatcher[T]
+
+	// AndBegin performs an And(), followed by a Begin()
+	AndBegin() SeqMatcher[T]
+
+	// OrBegin performs an Or(), followed by a Begin()
+	OrBegin() SeqMatcher[T]
+
+	// Cut commits the current grouping to the operands matched so far, as
+	// Matcher does
+	Cut() SeqOperator[T]
+
+	// End ends a grouping. NOTE You are expected to call one of the SeqEnd
+	// functions in order to apply the result of the grouping to your current result.
+	End() SeqEnd[T]
+
+	// EndMatchOne performs End(), followed by MatchOne()
+	EndMatchOne() SeqOperator[T]
+
+	// EndMatchZeroOrOne performs End(), followed by MatchZeroOrOne
+	EndMatchZeroOrOne() SeqOperator[T]
+
+	// Result matches the expression against the sequence, returning the
+	// result and resetting the matcher.  The sequence is rewound if the
+	// result is false.
+	Result() bool
+}
+
+// NewSeqMatcher creates a new SeqMatcher against the specified Seq
+func NewSeqMatcher[T any](s *Seq[T]) SeqMatcher[T] {
+	return &seqMatcher[T]{seq: s, b: newBuilder()}
+}
+
+// SeqPattern is a compiled SeqMatcher expression that can be matched against
+// any number of sequences.  SeqPatterns share their representation with
+// Patterns, and are matched by walking the expression, as a Matcher would.
+type SeqPattern[T any] struct {
+	root *node
+}
+
+// CompileSeq records the SeqMatcher expression built by fn into a SeqPattern.
+// The SeqMatcher passed to fn only records calls, so fn should simply return
+// the end of its expression, without calling Result() or Reset().
+func CompileSeq[T any](fn func(SeqMatcher[T]) SeqOperator[T]) *SeqPattern[T] {
+	m := &seqMatcher[T]{b: newBuilder()}
+
+	fn(m)
+
+	return &SeqPattern[T]{root: m.b.root()}
+}
+
+// Match tries to match the pattern at the current position of the sequence,
+// consuming the matched elements.  The sequence is left unchanged if the
+// pattern does not match.
+func (p *SeqPattern[T]) Match(s *Seq[T]) bool {
+	s.trim()
+
+	return (&seqExecutor[T]{seq: s}).match(p.root)
+}
+
+// String returns the pattern in regex syntax, for diagnostics, with
+// predicates shown as <func>
+func (p *SeqPattern[T]) String() string {
+	return p.root.termsString()
+}
+
+/*****************************************************************************
+ * Seq Matcher
+ *****************************************************************************/
+
+// seqMatcher records its expression with a builder, and walks it against the
+// sequence on Result().  A seqMatcher without a sequence is compiling a
+// SeqPattern.
+type seqMatcher[T any] struct {
+	seq   *Seq[T]
+	b     builder
+	items []T
+}
+
+// seqMatcher::fn
+func (m *seqMatcher[T]) fn(fn func(T) bool, negate bool, min int, max int) SeqOperator[T] {
+	m.b.add(&node{kind: nodeFunc, pred: fn, negate: negate, min: min, max: max})
+	return m
+}
+
+// SeqMatcher::MatchZeroOrOneFunc
+func (m *seqMatcher[T]) MatchZeroOrOneFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, false, 0, 1)
+}
+
+// SeqMatcher::MatchZeroOrMoreFunc
+func (m *seqMatcher[T]) MatchZeroOrMoreFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, false, 0, -1)
+}
+
+// SeqMatcher::MatchOneFunc
+func (m *seqMatcher[T]) MatchOneFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, false, 1, 1)
+}
+
+// SeqMatcher::MatchOneOrMoreFunc
+func (m *seqMatcher[T]) MatchOneOrMoreFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, false, 1, -1)
+}
+
+// SeqMatcher::MatchMinMaxFunc
+func (m *seqMatcher[T]) MatchMinMaxFunc(fn func(T) bool, min int, max int) SeqOperator[T] {
+	return m.fn(fn, false, min, max)
+}
+
+// SeqMatcher::NonMatchZeroOrOneFunc
+func (m *seqMatcher[T]) NonMatchZeroOrOneFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, true, 0, 1)
+}
+
+// SeqMatcher::NonMatchZeroOrMoreFunc
+func (m *seqMatcher[T]) NonMatchZeroOrMoreFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, true, 0, -1)
+}
+
+// SeqMatcher::NonMatchOneFunc
+func (m *seqMatcher[T]) NonMatchOneFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, true, 1, 1)
+}
+
+// SeqMatcher::NonMatchOneOrMoreFunc
+func (m *seqMatcher[T]) NonMatchOneOrMoreFunc(fn func(T) bool) SeqOperator[T] {
+	return m.fn(fn, true, 1, -1)
+}
+
+// SeqMatcher::MatchEnd
+func (m *seqMatcher[T]) MatchEnd() SeqOperator[T] {
+	m.b.add(&node{kind: nodeEOF})
+	return m
+}
+
+// SeqMatcher::MatchPattern
+func (m *seqMatcher[T]) MatchPattern(p *SeqPattern[T]) SeqOperator[T] {
+	// The root is copied, as the builder may modify the nodes it is given
+	root := *p.root
+	root.embedded = true
+	m.b.add(&root)
+	return m
+}
+
+// SeqMatcher::Begin
+func (m *seqMatcher[T]) Begin() SeqMatcher[T] {
+	m.b.begin()
+	return m
+}
+
+// SeqMatcher::End
+func (m *seqMatcher[T]) End() SeqEnd[T] {
+	return m
+}
+
+// SeqMatcher::EndMatchZeroOrOne
+func (m *seqMatcher[T]) EndMatchZeroOrOne() SeqOperator[T] {
+	return m.End().MatchZeroOrOne()
+}
+
+// SeqMatcher::EndMatchOne
+func (m *seqMatcher[T]) EndMatchOne() SeqOperator[T] {
+	return m.End().MatchOne()
+}
+
+// SeqMatcher::Result
+func (m *seqMatcher[T]) Result() bool {
+	if m.seq == nil {
+		panic("Calling Result() while compiling a SeqPattern")
+	}
+
+	if len(m.b.groups[0].terms) == 0 {
+		panic("Calling Result() without trying to match anything")
+	}
+
+	m.seq.trim()
+
+	start := m.seq.pos
+
+	result := (&seqExecutor[T]{seq: m.seq}).match(m.b.root())
+
+	if result {
+		m.items = m.seq.slice(start, m.seq.pos)
+	} else {
+		m.items = nil
+	}
+
+	m.Reset()
+
+	return result
+}
+
+// SeqMatcher::Reset
+func (m *seqMatcher[T]) Reset() SeqMatcher[T] {
+	if m.seq == nil {
+		panic("Calling Reset() while compiling a SeqPattern")
+	}
+
+	m.b = newBuilder()
+
+	return m
+}
+
+// SeqMatcher::Items
+func (m *seqMatcher[T]) Items() []T {
+	return m.items
+}
+
+// SeqEnd::MatchZeroOrOne
+func (m *seqMatcher[T]) MatchZeroOrOne() SeqOperator[T] {
+	m.b.end(true)
+	return m
+}
+
+// SeqEnd::MatchOne
+func (m *seqMatcher[T]) MatchOne() SeqOperator[T] {
+	m.b.end(false)
+	return m
+}
+
+// SeqEnd::Atomic
+func (m *seqMatcher[T]) Atomic() SeqOperator[T] {
+	m.b.end(false).atomic = true
+	return m
+}
+
+// SeqOperator::And
+func (m *seqMatcher[T]) And() SeqMatcher[T] {
+	m.b.operator(opAnd)
+	return m
+}
+
+// SeqOperator::Or
+func (m *seqMatcher[T]) Or() SeqMatcher[T] {
+	m.b.operator(opOr)
+	return m
+}
+
+// SeqOperator::AndBegin
+func (m *seqMatcher[T]) AndBegin() SeqMatcher[T] {
+	return m.And().Begin()
+}
+
+// SeqOperator::OrBegin
+func (m *seqMatcher[T]) OrBegin() SeqMatcher[T] {
+	return m.Or().Begin()
+}
+
+// SeqOperator::Cut
+func (m *seqMatcher[T]) Cut() SeqOperator[T] {
+	m.b.cut()
+	return m
+}
+
+/*****************************************************************************
+ * Seq Executor
+ *****************************************************************************/
+
+// seqExecutor walks a Pattern's nodes against a Seq, using the same semantics
+// as executor
+type seqExecutor[T any] struct {
+	seq *Seq[T]
+}
+
+// seqExecutor::accepts
+func (e *seqExecutor[T]) accepts(n *node) bool {
+	item, ok := e.seq.Peek(0)
+
+	return ok && n.pred.(func(T) bool)(item) != n.negate
+}
+
+// seqExecutor::match
+func (e *seqExecutor[T]) match(n *node) bool {
+	switch n.kind {
+
+	case nodeEOF:
+		_, ok := e.seq.Peek(0)
+		return !ok
+
+	case nodeGroup:
+		pos := e.seq.pos
+
+		result, cut := false, false
+
+		for i, t := range n.terms {
+			switch {
+			case i == 0:
+				result = e.match(t.node)
+			case t.op == opAnd && result:
+				result = e.match(t.node)
+			case t.op == opOr && !result && !cut:
+				e.seq.pos = pos
+				result = e.match(t.node)
+			}
+
+			cut = cut || (t.cut && result)
+		}
+
+		if !result {
+			e.seq.pos = pos
+		}
+
+		return result || n.optional
+	}
+
+	pos := e.seq.pos
+
+	count := 0
+
+	for (n.max < 0 || count < n.max) && e.accepts(n) {
+		e.seq.pos++
+		count++
+	}
+
+	if count < n.min {
+		e.seq.pos = pos
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,170 @@
+package matcher
+
+import (
+	"fmt"
+	"testing"
+)
+
+// isEven and isOdd are SeqMatcher predicates
+func isEven(i int) bool { return i%2 == 0 }
+func isOdd(i int) bool  { return i%2 != 0 }
+
+// counter returns the integers from 0 to n-1, then false
+func counter(n int) func() (int, bool) {
+	i := 0
+
+	return func() (int, bool) {
+		if i == n {
+			return 0, false
+		}
+		i++
+		return i - 1, true
+	}
+}
+
+// TestSeqMatcher checks grouping, alternation and rewinding over a Seq
+func TestSeqMatcher(t *testing.T) {
+	s := NewSeq([]int{1, 3, 4, 5, 6})
+
+	m := NewSeqMatcher(s)
+
+	if m.MatchOneOrMoreFunc(isOdd).And().MatchOneFunc(isOdd).Result() {
+		t.Errorf("runs are possessive, but odd+ odd matched")
+	}
+
+	if s.Pos() != 0 {
+		t.Errorf("failed match left the Seq at %d", s.Pos())
+	}
+
+	if !m.MatchOneOrMoreFunc(isOdd).And().MatchOneFunc(isOdd).Or().MatchOneOrMoreFunc(isOdd).And().MatchOneFunc(isEven).Result() {
+		t.Fatalf("odd+ even did not match")
+	}
+
+	if items := fmt.Sprint(m.Items()); items != "[1 3 4]" || s.Pos() != 3 {
+		t.Errorf("Items() = %s at %d, want [1 3 4] at 3", items, s.Pos())
+	}
+
+	if !m.NonMatchOneFunc(isEven).And().MatchOneFunc(isEven).And().MatchEnd().Result() {
+		t.Errorf("odd even end did not match")
+	}
+}
+
+// TestSeqTrim checks that a Seq over a func drops the elements before the
+// start of a match, without changing the elements returned by Items()
+func TestSeqTrim(t *testing.T) {
+	s := NewSeqFromFunc(counter(1000))
+
+	m := NewSeqMatcher(s)
+
+	pair := CompileSeq(func(m SeqMatcher[int]) SeqOperator[int] {
+		return m.MatchOneFunc(isEven).And().MatchOneFunc(isOdd)
+	})
+
+	first := []int(nil)
+
+	for i := 0; i < 500; i++ {
+		if !m.MatchPattern(pair).Result() {
+			t.Fatalf("pair %d did not match", i)
+		}
+
+		if i == 0 {
+			first = m.Items()
+		}
+
+		if len(s.items) > 4 {
+			t.Fatalf("%d elements buffered after %d pairs", len(s.items), i+1)
+		}
+	}
+
+	if fmt.Sprint(first) != "[0 1]" {
+		t.Errorf("Items() of the first match changed to %v", first)
+	}
+
+	if s.Pos() != 1000 || pair.Match(s) {
+		t.Errorf("Seq at %d, want the end at 1000", s.Pos())
+	}
+
+	// A failed match is rewound within the buffer
+	s = NewSeqFromFunc(counter(10))
+
+	if m := NewSeqMatcher(s); m.MatchMinMaxFunc(func(int) bool { return true }, 5, 5).And().MatchEnd().Result() {
+		t.Errorf("matched 5 of 10 elements and the end")
+	}
+
+	if v, ok := s.Next(); !ok || v != 0 {
+		t.Errorf("Next() = %d, %v after a failed match, want 0", v, ok)
+	}
+
+	for i := 1; i < 8; i++ {
+		s.Next()
+	}
+
+	if len(s.items) > 4 {
+		t.Errorf("%d elements buffered after Next() consumed 8 of 10", len(s.items))
+	}
+
+	if v, _ := s.Peek(1); v != 9 {
+		t.Errorf("Peek(1) = %d, want 9", v)
+	}
+}
+
+// TestSeqMatchPattern checks that a SeqPattern can be matched by several
+// SeqMatchers, and is not modified by them
+func TestSeqMatchPattern(t *testing.T) {
+	odd := CompileSeq(func(m SeqMatcher[int]) SeqOperator[int] {
+		return m.MatchOneOrMoreFunc(isOdd)
+	})
+
+	for _, input := range [][]int{{1, 3, 2}, {5, 2}} {
+		m := NewSeqMatcher(NewSeq(input))
+
+		if !m.MatchPattern(odd).And().MatchOneFunc(isEven).And().MatchEnd().Result() {
+			t.Errorf("%v did not match", input)
+		}
+	}
+
+	if odd.root.embedded {
+		t.Errorf("MatchPattern modified the root of the SeqPattern")
+	}
+
+	if s := odd.String(); s != "<func>+" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+// TestSeqMatcherPanics checks the panics of misused SeqMatchers
+func TestSeqMatcherPanics(t *testing.T) {
+	tests := []struct {
+		fn   func()
+		want string
+	}{
+		{func() {
+			CompileSeq(func(m SeqMatcher[int]) SeqOperator[int] {
+				op := m.MatchOneFunc(isOdd)
+				op.Result()
+				return op
+			})
+		}, "Calling Result() while compiling a SeqPattern"},
+		{func() {
+			CompileSeq(func(m SeqMatcher[int]) SeqOperator[int] {
+				m.Reset()
+				return m.MatchOneFunc(isOdd)
+			})
+		}, "Calling Reset() while compiling a SeqPattern"},
+		{func() {
+			NewSeqMatcher(NewSeq([]int{1})).Result()
+		}, "Calling Result() without trying to match anything"},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != test.want {
+					t.Errorf("panicked with %v, want %q", r, test.want)
+				}
+			}()
+
+			test.fn()
+		}()
+	}
+}
@@ -0,0 +1,32 @@
+package matcher
+
+import (
+	"github.com/iNamik/go_lexer"
+)
+
+// skipPattern consumes matches of the skipper from the lexer, until it fails
+// or matches nothing
+func skipPattern(l lexer.Lexer, skipper *Pattern) {
+	for {
+		if n, ok := skipper.match(l); !ok || n == 0 {
+			return
+		}
+	}
+}
+
+// executor::skip consumes matches of the skipper node of a node, until it
+// fails or matches nothing
+func (e *executor) skip(skipper *node) {
+	for {
+		n := e.n
+
+		if !e.match(skipper) || e.n == n {
+			return
+		}
+	}
+}
+
+// skipString returns a skipper node in regex syntax, i.e. (?:\s|#.*)*
+func skipString(skipper *node) string {
+	return "(?:" + skipper.termsString() + ")*"
+}
@@ -0,0 +1,66 @@
+package matcher
+
+import (
+	"regexp"
+	"testing"
+)
+
+// skipSpace skips spaces and # comments, for skipping tests
+var skipSpace = Compile(func(m Matcher) MatcherOperator {
+	return m.MatchOneOrMoreRunes([]rune(" \n")).
+		OrBegin().MatchOneRune('#').And().NonMatchZeroOrMoreRunes([]rune{'\n'}).EndMatchOne()
+})
+
+// TestBeginSkipping checks that the skipper is applied before each operand,
+// nested grouping and list item, and not within a Lexeme
+func TestBeginSkipping(t *testing.T) {
+	ident := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z"))
+	})
+
+	runOperandTests(t, []operandTest{
+		{"between terms", func(m Matcher) MatcherOperator {
+			return m.BeginSkipping(skipSpace).MatchOneRune('a').And().MatchOneRune('=').And().MatchOneRune('b').EndMatchOne()
+		}, map[string]string{"a=b": "a=b", " a = b ": " a = b", "a #x\n= b": "a #x\n= b", "a = c": "!"}},
+
+		{"nested grouping", func(m Matcher) MatcherOperator {
+			return m.BeginSkipping(skipSpace).MatchOneRune('(').And().Begin().MatchOneRune('a').And().MatchOneRune('b').EndMatchOne().EndMatchOne()
+		}, map[string]string{"( a b": "( a b", "(ab": "(ab"}},
+
+		{"Lexeme", func(m Matcher) MatcherOperator {
+			return m.BeginSkipping(skipSpace).MatchOneRune('x').
+				And().Lexeme().MatchOneRune('"').And().MatchOneRune('a').And().MatchOneRune('"').EndMatchOne().
+				EndMatchOne()
+		}, map[string]string{`x "a"`: `x "a"`, `x" a"`: "!", `x"a "`: "!"}},
+
+		{"list", func(m Matcher) MatcherOperator {
+			return m.BeginSkipping(skipSpace).MatchList(ident, listSep, ListOptions{AllowTrailing: true}).And().MatchOneRune(';').EndMatchOne()
+		}, map[string]string{"a , b ,c , ;": "a , b ,c , ;", "a b;": "!"}},
+
+		{"not after the grouping", func(m Matcher) MatcherOperator {
+			return m.BeginSkipping(skipSpace).MatchOneRune('a').EndMatchOne().And().MatchOneRune('b')
+		}, map[string]string{" ab": " ab", "a b": "!"}},
+	})
+}
+
+// TestBeginSkippingString checks that String() shows where the skipper is
+// applied
+func TestBeginSkippingString(t *testing.T) {
+	p := Compile(func(m Matcher) MatcherOperator {
+		return m.BeginSkipping(skipSpace).MatchOneRune('a').And().Lexeme().MatchOneRune('b').And().MatchOneRune('c').EndMatchOne().EndMatchOne()
+	})
+
+	want := `(?:(?:[\n ]+|(?:#[^\n]*))*a(?:[\n ]+|(?:#[^\n]*))*(?:bc))`
+
+	if s := p.String(); s != want {
+		t.Errorf("String() = %q, want %q", s, want)
+	}
+
+	re := regexp.MustCompile(`^` + want)
+
+	for input, want := range map[string]bool{" a #x\nbc": true, "a b c": false} {
+		if got := re.MatchString(input); got != want {
+			t.Errorf("String() matched %q: %v, want %v", input, got, want)
+		}
+	}
+}
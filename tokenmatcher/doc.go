@@ -0,0 +1,46 @@
+/*
+Package tokenmatcher provides the fluent Matcher interface of go_lexer_matcher
+over the token stream of an iNamik/go_parser Parser, rather than the runes of a
+lexer.
+
+Operands match token types instead of runes, with the same Begin/End grouping,
+And/Or operators and quantifiers as matcher.Matcher.  A failed expression
+rewinds the parser to where it started, using the parser's Marker/Reset
+functionality, and the tokens consumed by a successful expression are
+available from Tokens():
+
+	// Regex: T_UNQUOTED_STRING T_COLON
+	if m := tokenmatcher.New(p); m.MatchOneToken(T_UNQUOTED_STRING).And().MatchOneToken(T_COLON).Result() {
+		name := string(m.Tokens()[0].Bytes())
+	}
+
+As with matcher.Matcher, nothing is ever backtracked: alternatives are tried in
+order and runs consume as many tokens as they can.  A matched grouping is never
+backtracked into either, so Atomic() is exactly MatchOne().  NonMatch*
+operands never consume T_EOF.
+
+A Matcher created with NewWithTokenSet names token types with the TokenSet in
+the *MatchError returned by Err(), i.e. "expected T_COLON, found T_COMMA".
+Parsers do not track positions, so the Pos of the error is unset, but the
+error holds the token it failed at, and the number of tokens consumed before
+it, from which a caller can find the position:
+
+	if !m.MatchOneToken(T_COLON).Result() {
+		e := m.Err().(*tokenmatcher.MatchError)
+		e.Pos = positions[e.Token] // Recorded by the caller as tokens are lexed
+		fmt.Println(matcher.FormatError(src, &e.MatchError))
+	}
+
+MatchList matches a list of items separated by tokens, where each item is
+matched by a func(parser.Parser) bool, such as a parse function.  The tokens
+the item consumes from the parser it is given are tokens of the Matcher, and
+ListItems() returns those of each item, while ListSpans() returns the indexes
+of each item within Tokens():
+
+	// Matches T_NUMBER (T_COMMA T_NUMBER)*
+	item := func(p parser.Parser) bool { return p.NextToken().Type() == T_NUMBER }
+	if m := tokenmatcher.New(p); m.MatchList(item, []lexer.TokenType{T_COMMA}, matcher.ListOptions{}).Result() {
+		numbers := m.ListItems()
+	}
+*/
+package tokenmatcher
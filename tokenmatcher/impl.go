@@ -0,0 +1,282 @@
+package tokenmatcher
+
+import (
+	"github.com/iNamik/go_lexer"
+	"github.com/iNamik/go_lexer_matcher"
+	"github.com/iNamik/go_parser"
+)
+
+/*****************************************************************************
+ * Matcher
+ *****************************************************************************/
+
+// Matcher::Reset
+func (m *tokenMatcher) Reset() Matcher {
+	m.stack.Clear()
+
+	m.tokens = nil
+
+	m.items = nil
+
+	m.clearState()
+
+	m.hasResult = false
+
+	m.failN = 0
+
+	m.expected = nil
+
+	m.found = nil
+
+	return m
+}
+
+// Matcher::MatchZeroOrOneToken
+func (m *tokenMatcher) MatchZeroOrOneToken(t lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run([]lexer.TokenType{t}, false, 0, 1) }, m.expectTypes([]lexer.TokenType{t}, false))
+	return m
+}
+
+// Matcher::MatchZeroOrOneTokens
+func (m *tokenMatcher) MatchZeroOrOneTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, false, 0, 1) }, m.expectTypes(types, false))
+	return m
+}
+
+// Matcher::MatchZeroOrMoreTokens
+func (m *tokenMatcher) MatchZeroOrMoreTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, false, 0, -1) }, m.expectTypes(types, false))
+	return m
+}
+
+// Matcher::MatchOneToken
+func (m *tokenMatcher) MatchOneToken(t lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run([]lexer.TokenType{t}, false, 1, 1) }, m.expectTypes([]lexer.TokenType{t}, false))
+	return m
+}
+
+// Matcher::MatchOneTokens
+func (m *tokenMatcher) MatchOneTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, false, 1, 1) }, m.expectTypes(types, false))
+	return m
+}
+
+// Matcher::MatchOneOrMoreTokens
+func (m *tokenMatcher) MatchOneOrMoreTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, false, 1, -1) }, m.expectTypes(types, false))
+	return m
+}
+
+// Matcher::MatchMinMaxTokens
+func (m *tokenMatcher) MatchMinMaxTokens(types []lexer.TokenType, min int, max int) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, false, min, max) }, m.expectTypes(types, false))
+	return m
+}
+
+// Matcher::NonMatchZeroOrOneTokens
+func (m *tokenMatcher) NonMatchZeroOrOneTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, true, 0, 1) }, m.expectTypes(types, true))
+	return m
+}
+
+// Matcher::NonMatchZeroOrMoreTokens
+func (m *tokenMatcher) NonMatchZeroOrMoreTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, true, 0, -1) }, m.expectTypes(types, true))
+	return m
+}
+
+// Matcher::NonMatchOneTokens
+func (m *tokenMatcher) NonMatchOneTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, true, 1, 1) }, m.expectTypes(types, true))
+	return m
+}
+
+// Matcher::NonMatchOneOrMoreTokens
+func (m *tokenMatcher) NonMatchOneOrMoreTokens(types []lexer.TokenType) MatcherOperator {
+	m.doMatch(func() bool { return m.run(types, true, 1, -1) }, m.expectTypes(types, true))
+	return m
+}
+
+// Matcher::MatchEOF
+func (m *tokenMatcher) MatchEOF() MatcherOperator {
+	m.doMatch(func() bool { return m.parser.PeekTokenType(0) == lexer.TokenTypeEOF }, m.expectTypes([]lexer.TokenType{lexer.TokenTypeEOF}, false))
+	return m
+}
+
+// Matcher::MatchList
+func (m *tokenMatcher) MatchList(item func(parser.Parser) bool, sep []lexer.TokenType, opts matcher.ListOptions) MatcherOperator {
+	checkListOptions(opts)
+	m.doMatch(func() bool { return m.list(item, sep, opts) }, expectItem)
+	return m
+}
+
+// Matcher::Begin
+func (m *tokenMatcher) Begin() Matcher {
+	return m.begin()
+}
+
+// Matcher::End
+func (m *tokenMatcher) End() MatcherEnd {
+	return m
+}
+
+// Matcher::EndMatchZeroOrOne
+func (m *tokenMatcher) EndMatchZeroOrOne() MatcherOperator {
+
+	return m.End().MatchZeroOrOne()
+}
+
+// Matcher::EndMatchOne
+func (m *tokenMatcher) EndMatchOne() MatcherOperator {
+
+	return m.End().MatchOne()
+}
+
+// Matcher::Result
+func (m *tokenMatcher) Result() bool {
+	if !m.hasResult {
+		panic("Calling Result() without trying to match anything")
+	}
+
+	result := m.state.result
+
+	if result == false {
+		m.reset(m.state.marker)
+
+		m.last = nil
+
+		m.listItems = nil
+
+		m.listSpans = nil
+
+		m.err = m.matchError()
+	} else {
+		m.last = m.tokens
+
+		m.listItems = make([][]*lexer.Token, len(m.items))
+
+		for i, s := range m.items {
+			m.listItems[i] = m.tokens[s.Start:s.End]
+		}
+
+		m.listSpans = m.items
+
+		m.err = nil
+	}
+
+	m.Reset()
+
+	return result
+}
+
+// Matcher::Tokens
+func (m *tokenMatcher) Tokens() []*lexer.Token {
+	return m.last
+}
+
+// Matcher::Err
+func (m *tokenMatcher) Err() error {
+	return m.err
+}
+
+// Matcher::ListItems
+func (m *tokenMatcher) ListItems() [][]*lexer.Token {
+	return m.listItems
+}
+
+// Matcher::ListSpans
+func (m *tokenMatcher) ListSpans() []Span {
+	return m.listSpans
+}
+
+// tokenMatcher::matchError builds the error for a failed Result()
+func (m *tokenMatcher) matchError() *MatchError {
+	e := &MatchError{}
+
+	if len(m.expected) == 0 {
+		return e
+	}
+
+	e.Expected = m.expected
+
+	e.Found = m.names.Name(m.found.Type())
+
+	e.Token, e.N = m.found, m.failN
+
+	return e
+}
+
+/*****************************************************************************
+ * Matcher End
+ *****************************************************************************/
+
+// MatcherEnd::MatchZeroOrOne
+func (m *tokenMatcher) MatchZeroOrOne() MatcherOperator {
+	m.end(endMatchZeroOrOne)
+	return m
+}
+
+// MatcherEnd::MatchOne
+func (m *tokenMatcher) MatchOne() MatcherOperator {
+	m.end(endMatchOne)
+	return m
+}
+
+// MatcherEnd::Atomic is MatchOne, as a Matcher never backtracks
+func (m *tokenMatcher) Atomic() MatcherOperator {
+	m.end(endMatchOne)
+	return m
+}
+
+/*****************************************************************************
+ * Matcher Operator
+ *****************************************************************************/
+
+// MatcherOperator::And
+func (m *tokenMatcher) And() Matcher {
+	if m.hasResult == false {
+		panic("No operator executed before operand")
+	}
+	m.state.skipNext = m.state.skipAll == true || m.state.result == false
+	m.state.fn = matcherAnd
+	return m
+}
+
+// MatcherOperator::Or
+func (m *tokenMatcher) Or() Matcher {
+	if m.hasResult == false {
+		panic("No operator executed before operand")
+	}
+	m.state.skipNext = m.state.skipAll == true || m.state.result == true || m.state.cut == true
+	if m.state.skipNext == false {
+		// Rewind any tokens consumed by the failed alternative
+		m.reset(m.state.marker)
+	}
+	m.state.fn = matcherOr
+	return m
+}
+
+// MatcherOperator::AndBegin
+func (m *tokenMatcher) AndBegin() Matcher {
+	m.And()
+	m.Begin()
+	return m
+}
+
+// MatcherOperator::OrBegin
+func (m *tokenMatcher) OrBegin() Matcher {
+	m.Or()
+	m.Begin()
+	return m
+}
+
+// MatcherOperator::Cut
+func (m *tokenMatcher) Cut() MatcherOperator {
+	if m.hasResult == false {
+		panic("No operator executed before operand")
+	}
+	if m.state.skipAll == false && m.state.result == true {
+		m.state.cut = true
+	}
+	return m
+}
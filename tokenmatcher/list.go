@@ -0,0 +1,104 @@
+package tokenmatcher
+
+import (
+	"github.com/iNamik/go_lexer"
+	"github.com/iNamik/go_lexer_matcher"
+	"github.com/iNamik/go_parser"
+)
+
+// Span is the extent of an item matched by MatchList, as indexes into the
+// tokens consumed by the Matcher, returned by Tokens()
+type Span struct {
+	Start int // The index of the first token of the item
+	End   int // The index following the last token of the item
+}
+
+// listParser is a parser.Parser adapter, passed to the items of a list, that
+// records the tokens they consume as tokens of the matcher
+type listParser struct {
+	parser.Parser
+	m       *tokenMatcher
+	markers map[*parser.Marker]int
+}
+
+// listParser::NextToken
+func (p *listParser) NextToken() *lexer.Token {
+	t := p.Parser.NextToken()
+
+	p.m.tokens = append(p.m.tokens, t)
+
+	return t
+}
+
+// listParser::Marker
+func (p *listParser) Marker() *parser.Marker {
+	mk := p.Parser.Marker()
+
+	p.markers[mk] = len(p.m.tokens)
+
+	return mk
+}
+
+// listParser::Reset
+func (p *listParser) Reset(mk *parser.Marker) {
+	p.Parser.Reset(mk)
+
+	if n, ok := p.markers[mk]; ok {
+		p.m.tokens = p.m.tokens[:n]
+	}
+}
+
+// checkListOptions panics if the options of a MatchList are invalid
+func checkListOptions(opts matcher.ListOptions) {
+	switch {
+	case opts.Min < 0 || opts.Max < 0:
+		panic("ListOptions has a negative Min or Max")
+	case opts.Max > 0 && opts.Max < opts.Min:
+		panic("ListOptions has a Max less than its Min")
+	}
+}
+
+// tokenMatcher::list matches a list of items, each consumed by item, separated
+// by tokens of the sep types.  Each item after the first is preceded by a
+// separator, which is given back if the item does not match, unless trailing
+// separators are allowed.  Nothing is consumed if there are fewer than
+// opts.Min items.
+func (m *tokenMatcher) list(item func(parser.Parser) bool, sep []lexer.TokenType, opts matcher.ListOptions) bool {
+	start := m.mark()
+
+	count := 0
+
+	for opts.Max == 0 || count < opts.Max {
+		back := m.mark()
+
+		// Failures are recorded, so that Err() lists a missing separator or
+		// item along with whatever fails after the list
+		if count > 0 && !m.run(sep, false, 1, 1) {
+			m.fail(m.expectTypes(sep, false))
+			break
+		}
+
+		first := len(m.tokens)
+
+		if !item(&listParser{m.parser, m, make(map[*parser.Marker]int)}) {
+			m.fail(expectItem)
+			m.reset(back)
+			break
+		}
+
+		m.items = append(m.items, Span{first, len(m.tokens)})
+
+		count++
+	}
+
+	if count < opts.Min {
+		m.reset(start)
+		return false
+	}
+
+	if count > 0 && opts.AllowTrailing {
+		m.run(sep, false, 0, 1)
+	}
+
+	return true
+}
@@ -0,0 +1,147 @@
+package tokenmatcher
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+	"github.com/iNamik/go_lexer_matcher"
+	"github.com/iNamik/go_parser"
+)
+
+// testItem matches an item of T_A tokens, optionally followed by a T_B,
+// rewinding the parser itself if there is no T_A
+func testItem(p parser.Parser) bool {
+	mk := p.Marker()
+
+	n := 0
+
+	for p.PeekTokenType(0) == T_A {
+		p.NextToken()
+		n++
+	}
+
+	if n == 0 {
+		p.Reset(mk)
+		return false
+	}
+
+	if p.PeekTokenType(0) == T_B {
+		p.NextToken()
+	}
+
+	return true
+}
+
+// TestMatchList checks the items, separators and options of MatchList
+func TestMatchList(t *testing.T) {
+	comma := []lexer.TokenType{T_COMMA}
+
+	tests := []struct {
+		name  string
+		opts  matcher.ListOptions
+		input string
+		want  string // The tokens of each item, separated by '|', or "!"
+		next  lexer.TokenType
+	}{
+		{"empty", matcher.ListOptions{}, "c", "", T_C},
+		{"one", matcher.ListOptions{}, "aab", "T_A T_A T_B", lexer.TokenTypeEOF},
+		{"several", matcher.ListOptions{}, "a,ab,aa", "T_A|T_A T_B|T_A T_A", lexer.TokenTypeEOF},
+		{"separator given back", matcher.ListOptions{}, "a,c", "T_A", T_COMMA},
+		{"trailing", matcher.ListOptions{AllowTrailing: true}, "a,a,c", "T_A|T_A", T_C},
+		{"no trailing without items", matcher.ListOptions{AllowTrailing: true}, ",", "", T_COMMA},
+		{"min", matcher.ListOptions{Min: 2}, "a,a", "T_A|T_A", lexer.TokenTypeEOF},
+		{"too few", matcher.ListOptions{Min: 2}, "a,c", "!", T_A},
+		{"max", matcher.ListOptions{Max: 2}, "a,a,a", "T_A|T_A", T_COMMA},
+	}
+
+	for _, test := range tests {
+		p := newTestParser(test.input)
+
+		m := NewWithTokenSet(p, testTokens)
+
+		got := "!"
+
+		if m.MatchList(testItem, comma, test.opts).Result() {
+			var items []string
+
+			for _, item := range m.ListItems() {
+				items = append(items, typesOf(item))
+			}
+
+			got = strings.Join(items, "|")
+		}
+
+		if got != test.want {
+			t.Errorf("%s: items %q, want %q", test.name, got, test.want)
+		}
+
+		if next := p.PeekTokenType(0); next != test.next {
+			t.Errorf("%s: next token %s, want %s", test.name, testTokens.Name(next), testTokens.Name(test.next))
+		}
+	}
+}
+
+// TestMatchListTokens checks that the tokens of the items and separators are
+// tokens of the Matcher, and are rewound with it
+func TestMatchListTokens(t *testing.T) {
+	p := newTestParser("a,ab;")
+
+	m := NewWithTokenSet(p, testTokens)
+
+	if !m.MatchOneToken(T_C).Or().MatchList(testItem, []lexer.TokenType{T_COMMA}, matcher.ListOptions{}).Result() {
+		t.Fatalf("no match")
+	}
+
+	if got := typesOf(m.Tokens()); got != "T_A T_COMMA T_A T_B" {
+		t.Errorf("Tokens() = %q", got)
+	}
+
+	if got := fmt.Sprint(m.ListSpans()); got != "[{0 1} {2 4}]" {
+		t.Errorf("ListSpans() = %s, want [{0 1} {2 4}]", got)
+	}
+
+	p = newTestParser("a,ab")
+
+	m = NewWithTokenSet(p, testTokens)
+
+	if m.MatchList(testItem, []lexer.TokenType{T_COMMA}, matcher.ListOptions{}).And().MatchOneToken(T_C).Result() {
+		t.Fatalf("matched")
+	}
+
+	if m.ListItems() != nil || m.ListSpans() != nil {
+		t.Errorf("ListItems() or ListSpans() is not nil after a failed Result()")
+	}
+
+	if next := p.PeekTokenType(0); next != T_A {
+		t.Errorf("parser not rewound, next token %s", testTokens.Name(next))
+	}
+
+	want := "expected T_COMMA or T_C, found T_EOF"
+
+	if err := m.Err(); err == nil || err.Error() != want {
+		t.Errorf("Err() = %v, want %q", err, want)
+	}
+}
+
+// TestMatchListPanics checks that invalid options panic
+func TestMatchListPanics(t *testing.T) {
+	tests := map[string]matcher.ListOptions{
+		"negative Min": {Min: -1},
+		"negative Max": {Max: -1},
+		"Max < Min":    {Min: 3, Max: 2},
+	}
+
+	for name, opts := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", name)
+				}
+			}()
+
+			New(newTestParser("a")).MatchList(testItem, nil, opts)
+		}()
+	}
+}
@@ -0,0 +1,178 @@
+package tokenmatcher
+
+import (
+	"github.com/iNamik/go_container/stack"
+	"github.com/iNamik/go_lexer"
+	"github.com/iNamik/go_lexer_matcher"
+	"github.com/iNamik/go_parser"
+)
+
+type Matcher interface {
+
+	// MatchZeroOrOneToken consumes the next token if it matches, always returning true
+	MatchZeroOrOneToken(lexer.TokenType) MatcherOperator
+
+	// MatchZeroOrOneTokens consumes the next token if its in the list of types, always returning true
+	MatchZeroOrOneTokens([]lexer.TokenType) MatcherOperator
+
+	// MatchZeroOrMoreTokens consumes a run of matching tokens, always returning true
+	MatchZeroOrMoreTokens([]lexer.TokenType) MatcherOperator
+
+	// MatchOneToken consumes the next token if it matches
+	MatchOneToken(lexer.TokenType) MatcherOperator
+
+	// MatchOneTokens consumes the next token if its in the list of types
+	MatchOneTokens([]lexer.TokenType) MatcherOperator
+
+	// MatchOneOrMoreTokens consumes a run of matching tokens
+	MatchOneOrMoreTokens([]lexer.TokenType) MatcherOperator
+
+	// MatchMinMaxTokens consumes a specified run of matching tokens
+	MatchMinMaxTokens([]lexer.TokenType, int, int) MatcherOperator
+
+	// NonMatchZeroOrOneTokens consumes the next token if it does not match, always returning true
+	NonMatchZeroOrOneTokens([]lexer.TokenType) MatcherOperator
+
+	// NonMatchZeroOrMoreTokens consumes a run of non-matching tokens, always returning true
+	NonMatchZeroOrMoreTokens([]lexer.TokenType) MatcherOperator
+
+	// NonMatchOneTokens consumes the next token if it does not match
+	NonMatchOneTokens([]lexer.TokenType) MatcherOperator
+
+	// NonMatchOneOrMoreTokens consumes a run of non-matching tokens
+	NonMatchOneOrMoreTokens([]lexer.TokenType) MatcherOperator
+
+	// MatchEOF tries to match the next token against lexer.TokenTypeEOF
+	MatchEOF() MatcherOperator
+
+	// MatchList tries to match a list of items separated by tokens of the
+	// separator types, as configured by the options.  Each item is consumed by
+	// calling the item function, which returns false if there is none, and
+	// whose parser records the tokens it consumes as tokens of the Matcher.
+	MatchList(func(parser.Parser) bool, []lexer.TokenType, matcher.ListOptions) MatcherOperator
+
+	// Begin begins a new grouping that is expected to match (i.e required)
+	Begin() Matcher
+
+	// End ends a grouping. NOTE You are expected to call one of the MatcherEnd
+	// functions in order to apply the result of the grouping to your current result.
+	End() MatcherEnd
+
+	// EndMatchOne performs End(), followed by MatchOne()
+	EndMatchOne() MatcherOperator
+
+	// EndMatchZeroOrOne performs End(), followed by MatchZeroOrOne
+	EndMatchZeroOrOne() MatcherOperator
+
+	// Result returns the final result of the matcher, resetting the
+	// matcher state if the result is false.
+	Result() bool
+
+	// Reset resets the state of the matcher
+	Reset() Matcher
+
+	// Tokens returns the tokens consumed by the last successful Result()
+	Tokens() []*lexer.Token
+
+	// Err returns a *MatchError, listing the token types expected after the
+	// farthest token reached, along with the token found there, if the last
+	// Result() was false, or nil otherwise
+	Err() error
+
+	// ListItems returns the tokens of each item matched by the MatchList
+	// operands of the last successful Result(), in order
+	ListItems() [][]*lexer.Token
+
+	// ListSpans returns the Span of each item returned by ListItems(), as
+	// indexes into Tokens()
+	ListSpans() []Span
+}
+
+type MatcherEnd interface {
+	// MatchOne
+	MatchOne() MatcherOperator
+
+	// MatchZeroOrOne
+	MatchZeroOrOne() MatcherOperator
+
+	// Atomic performs MatchOne().  It is provided so that expressions read
+	// the same as those of a matcher.Matcher, where Atomic only differs from
+	// MatchOne in Patterns compiled with matcher.CompileBacktracking.  A
+	// Matcher over tokens is never compiled, and never backtracks into a
+	// grouping, so its Atomic is exactly MatchOne.
+	Atomic() MatcherOperator
+}
+
+type MatcherOperator interface {
+
+	// And Performs a logical 'and' between the current matcher state and the
+	// next operand.  Short-circuit logic is performed, whereby the next operand
+	// will not actually be executed if the current matcher state is already
+	// false
+	And() Matcher
+
+	// Or Performs a logical 'or' between the current matcher result and the
+	// next operand.  Short-circuit logic is performed, whereby the next operand
+	// will not actually be executed if the current matcher state is already
+	// true
+	// If the current matcher state is false, any tokens consumed within the
+	// current grouping are rewound before the next operand is executed
+	Or() Matcher
+
+	// AndBegin performs an And(), followed by a Begin()
+	AndBegin() Matcher
+
+	// OrBegin performs an Or(), followed by a Begin()
+	OrBegin() Matcher
+
+	// Cut commits the current grouping to the operands matched so far: if a
+	// later operand fails, the remaining alternatives of the grouping are not
+	// tried, and the grouping fails.  Cut has no effect if the current matcher
+	// state is false.
+	Cut() MatcherOperator
+
+	// End ends a grouping. NOTE You are expected to call one of the MatcherEnd
+	// functions in order to apply the result of the grouping to your current result.
+	End() MatcherEnd
+
+	// EndMatchOne performs End(), followed by MatchOne()
+	EndMatchOne() MatcherOperator
+
+	// EndMatchZeroOrOne performs End(), followed by MatchZeroOrOne
+	EndMatchZeroOrOne() MatcherOperator
+
+	// Result returns the final result of the matcher, resetting the
+	// matcher state if the result is false.
+	Result() bool
+}
+
+// MatchError is the error of a failed Result().  Parsers do not track
+// positions, so the Pos of the matcher.MatchError is unset.  Instead, Token is
+// the token found at the farthest failure, and N the number of tokens the
+// Matcher had consumed before it, with which a caller that knows the positions
+// of its tokens can set Pos.
+type MatchError struct {
+	matcher.MatchError
+	Token *lexer.Token // The token found at the farthest failure, if any
+	N     int          // The number of tokens consumed before Token
+}
+
+// New creates a new Matcher against the specified Parser
+func New(p parser.Parser) Matcher {
+	return NewWithTokenSet(p, matcher.NewTokenSet())
+}
+
+// NewWithTokenSet creates a new Matcher against the specified Parser, naming
+// token types in diagnostics with the specified TokenSet
+func NewWithTokenSet(p parser.Parser, tokens *matcher.TokenSet) Matcher {
+	m := &tokenMatcher{
+		parser: p,
+		names:  tokens,
+		stack:  stack.New(4), // 4 is just a nice number that seems appropriate
+		state:  &matcherState{},
+	}
+
+	m.Reset()
+
+	return m
+}
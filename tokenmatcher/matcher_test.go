@@ -0,0 +1,265 @@
+package tokenmatcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+	"github.com/iNamik/go_lexer_matcher"
+	"github.com/iNamik/go_parser"
+)
+
+// Test tokens, lexed from the runes 'a', 'b', 'c' and ','
+var (
+	testTokens = matcher.NewTokenSet()
+	T_A        = testTokens.Add("T_A")
+	T_B        = testTokens.Add("T_B")
+	T_C        = testTokens.Add("T_C")
+	T_COMMA    = testTokens.Add("T_COMMA")
+)
+
+// newTestParser creates a parser over the tokens of the runes of s
+func newTestParser(s string) parser.Parser {
+	types := map[rune]lexer.TokenType{'a': T_A, 'b': T_B, 'c': T_C, ',': T_COMMA}
+
+	var lex lexer.StateFn
+
+	lex = func(l lexer.Lexer) lexer.StateFn {
+		r := l.NextRune()
+
+		if r == lexer.RuneEOF {
+			l.EmitEOF()
+			return nil
+		}
+
+		l.EmitTokenWithBytes(types[r])
+
+		return lex
+	}
+
+	return parser.New(nil, lexer.NewFromBytes(lex, []byte(s), 1), 1)
+}
+
+// typesOf returns the names of the types of tokens
+func typesOf(tokens []*lexer.Token) string {
+	names := make([]string, len(tokens))
+
+	for i, t := range tokens {
+		names[i] = testTokens.Name(t.Type())
+	}
+
+	return strings.Join(names, " ")
+}
+
+// matcherTest is an expression, and the tokens it consumes from each input,
+// "!" meaning no match
+type matcherTest struct {
+	name   string
+	fn     func(Matcher) MatcherOperator
+	inputs map[string]string
+}
+
+// runMatcherTests checks the tokens each expression consumes, and that a
+// failed expression leaves the parser where it started
+func runMatcherTests(t *testing.T, tests []matcherTest) {
+	for _, test := range tests {
+		for input, want := range test.inputs {
+			p := newTestParser(input)
+
+			m := NewWithTokenSet(p, testTokens)
+
+			got := "!"
+
+			if test.fn(m).Result() {
+				got = typesOf(m.Tokens())
+			} else if m.Tokens() != nil {
+				t.Errorf("%s: %q: Tokens() is not nil after a failed Result()", test.name, input)
+			}
+
+			if got != want {
+				t.Errorf("%s: %q: got %q, want %q", test.name, input, got, want)
+			}
+
+			if got == "!" && input != "" {
+				if first := testTokens.Name(newTestParser(input).PeekTokenType(0)); testTokens.Name(p.PeekTokenType(0)) != first {
+					t.Errorf("%s: %q: parser not rewound to %s", test.name, input, first)
+				}
+			}
+		}
+	}
+}
+
+// TestMatcherOperands checks the single-token and run operands
+func TestMatcherOperands(t *testing.T) {
+	ab := []lexer.TokenType{T_A, T_B}
+
+	runMatcherTests(t, []matcherTest{
+		{"MatchOneToken", func(m Matcher) MatcherOperator { return m.MatchOneToken(T_A) },
+			map[string]string{"a": "T_A", "aa": "T_A", "b": "!", "": "!"}},
+		{"MatchZeroOrOneToken", func(m Matcher) MatcherOperator { return m.MatchZeroOrOneToken(T_A) },
+			map[string]string{"a": "T_A", "b": "", "": ""}},
+		{"MatchOneTokens", func(m Matcher) MatcherOperator { return m.MatchOneTokens(ab) },
+			map[string]string{"b": "T_B", "c": "!"}},
+		{"MatchZeroOrMoreTokens", func(m Matcher) MatcherOperator { return m.MatchZeroOrMoreTokens(ab) },
+			map[string]string{"abac": "T_A T_B T_A", "c": ""}},
+		{"MatchOneOrMoreTokens", func(m Matcher) MatcherOperator { return m.MatchOneOrMoreTokens(ab) },
+			map[string]string{"bba": "T_B T_B T_A", "c": "!"}},
+		{"MatchMinMaxTokens", func(m Matcher) MatcherOperator { return m.MatchMinMaxTokens(ab, 2, 3) },
+			map[string]string{"a": "!", "ab": "T_A T_B", "abab": "T_A T_B T_A"}},
+		{"NonMatchOneTokens", func(m Matcher) MatcherOperator { return m.NonMatchOneTokens(ab) },
+			map[string]string{"c": "T_C", "a": "!", "": "!"}},
+		{"NonMatchZeroOrOneTokens", func(m Matcher) MatcherOperator { return m.NonMatchZeroOrOneTokens(ab) },
+			map[string]string{"cc": "T_C", "a": "", "": ""}},
+		{"NonMatchZeroOrMoreTokens", func(m Matcher) MatcherOperator { return m.NonMatchZeroOrMoreTokens(ab) },
+			map[string]string{"c,ca": "T_C T_COMMA T_C", "": ""}},
+		{"NonMatchOneOrMoreTokens", func(m Matcher) MatcherOperator { return m.NonMatchOneOrMoreTokens(ab) },
+			map[string]string{"cc": "T_C T_C", "b": "!", "": "!"}},
+		{"MatchEOF", func(m Matcher) MatcherOperator { return m.MatchOneToken(T_A).And().MatchEOF() },
+			map[string]string{"a": "T_A", "aa": "!"}},
+	})
+}
+
+// TestMatcherOperators checks that And() and Or() rewind the tokens of the
+// operands that failed, and that groupings apply their quantifiers
+func TestMatcherOperators(t *testing.T) {
+	runMatcherTests(t, []matcherTest{
+		{"And", func(m Matcher) MatcherOperator {
+			return m.MatchOneToken(T_A).And().MatchOneToken(T_B)
+		}, map[string]string{"ab": "T_A T_B", "ac": "!", "b": "!"}},
+
+		// ((a b) | a) c, as And() and Or() are evaluated from left to right
+		{"Or", func(m Matcher) MatcherOperator {
+			return m.MatchOneToken(T_A).And().MatchOneToken(T_B).Or().MatchOneToken(T_A).And().MatchOneToken(T_C)
+		}, map[string]string{"abc": "T_A T_B T_C", "ac": "T_A T_C", "ab": "!", "bc": "!"}},
+
+		{"OrBegin", func(m Matcher) MatcherOperator {
+			return m.MatchOneToken(T_A).OrBegin().MatchOneToken(T_B).And().MatchOneToken(T_C).EndMatchOne()
+		}, map[string]string{"a": "T_A", "bc": "T_B T_C", "b": "!"}},
+
+		{"EndMatchZeroOrOne", func(m Matcher) MatcherOperator {
+			return m.MatchOneToken(T_A).AndBegin().MatchOneToken(T_B).And().MatchOneToken(T_C).EndMatchZeroOrOne().And().MatchOneToken(T_B)
+		}, map[string]string{"abcb": "T_A T_B T_C T_B", "ab": "T_A T_B", "abb": "T_A T_B"}},
+	})
+}
+
+// TestMatcherCut checks that a Cut() commits a grouping to its first
+// alternative
+func TestMatcherCut(t *testing.T) {
+	runMatcherTests(t, []matcherTest{
+		{"without Cut", func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneToken(T_A).And().MatchOneToken(T_B).Or().MatchOneToken(T_A).EndMatchOne()
+		}, map[string]string{"ab": "T_A T_B", "ac": "T_A"}},
+
+		{"Cut", func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneToken(T_A).Cut().And().MatchOneToken(T_B).Or().MatchOneToken(T_A).EndMatchOne()
+		}, map[string]string{"ab": "T_A T_B", "ac": "!"}},
+
+		{"Cut after a failure", func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneToken(T_B).Cut().Or().MatchOneToken(T_A).EndMatchOne()
+		}, map[string]string{"a": "T_A"}},
+
+		// The cut only applies to the grouping it is in
+		{"nested Cut", func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneToken(T_A).Cut().And().MatchOneToken(T_B).EndMatchOne().Or().MatchOneToken(T_A)
+		}, map[string]string{"ac": "T_A"}},
+	})
+}
+
+// TestMatcherAtomic checks that Atomic() matches exactly as MatchOne()
+func TestMatcherAtomic(t *testing.T) {
+	inputs := map[string]string{"b": "T_B", "aab": "T_A T_A T_B", "aa": "!", "c": "!"}
+
+	runMatcherTests(t, []matcherTest{
+		{"Atomic", func(m Matcher) MatcherOperator {
+			return m.Begin().MatchZeroOrMoreTokens([]lexer.TokenType{T_A}).End().Atomic().And().MatchOneToken(T_B)
+		}, inputs},
+
+		{"MatchOne", func(m Matcher) MatcherOperator {
+			return m.Begin().MatchZeroOrMoreTokens([]lexer.TokenType{T_A}).End().MatchOne().And().MatchOneToken(T_B)
+		}, inputs},
+	})
+}
+
+// TestMatcherErr checks the expected types, found token and count of the
+// farthest failure
+func TestMatcherErr(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		fn    func(Matcher) MatcherOperator
+		err   string
+		token string
+		n     int
+	}{
+		{"single", "ab", func(m Matcher) MatcherOperator {
+			return m.MatchOneToken(T_A).And().MatchOneTokens([]lexer.TokenType{T_C, T_COMMA})
+		}, "expected T_C or T_COMMA, found T_B", "b", 1},
+
+		// The first alternative got farther than the second
+		{"farthest", "ab,", func(m Matcher) MatcherOperator {
+			return m.Begin().MatchOneToken(T_A).And().MatchOneToken(T_B).And().MatchOneToken(T_C).
+				OrBegin().MatchOneToken(T_A).And().MatchOneToken(T_COMMA).EndMatchOne().EndMatchOne()
+		}, "expected T_C, found T_COMMA", ",", 2},
+
+		// Both alternatives failed at the same token
+		{"merged", "c", func(m Matcher) MatcherOperator {
+			return m.MatchOneToken(T_A).Or().MatchOneToken(T_B).Or().MatchOneToken(T_A)
+		}, "expected T_A or T_B, found T_C", "c", 0},
+
+		{"negated", "a", func(m Matcher) MatcherOperator {
+			return m.NonMatchOneTokens([]lexer.TokenType{T_A, T_B})
+		}, "expected any token but T_A, T_B, found T_A", "a", 0},
+
+		{"EOF", "a", func(m Matcher) MatcherOperator {
+			return m.MatchOneToken(T_A).And().MatchOneToken(T_B)
+		}, "expected T_B, found T_EOF", "", 1},
+	}
+
+	for _, test := range tests {
+		m := NewWithTokenSet(newTestParser(test.input), testTokens)
+
+		if test.fn(m).Result() {
+			t.Errorf("%s: matched", test.name)
+			continue
+		}
+
+		e, ok := m.Err().(*MatchError)
+
+		switch {
+		case !ok:
+			t.Errorf("%s: Err() = %#v, want a *MatchError", test.name, m.Err())
+		case e.Error() != test.err:
+			t.Errorf("%s: Err() = %q, want %q", test.name, e.Error(), test.err)
+		case e.Pos.IsValid():
+			t.Errorf("%s: Err() has a position", test.name)
+		case e.Token == nil || string(e.Token.Bytes()) != test.token || e.N != test.n:
+			t.Errorf("%s: Err() failed at token %v after %d tokens, want %q after %d", test.name, e.Token, e.N, test.token, test.n)
+		}
+
+		if !m.MatchZeroOrOneToken(T_A).Result() || m.Err() != nil {
+			t.Errorf("%s: Err() is not nil after a successful Result()", test.name)
+		}
+	}
+}
+
+// TestMatcherPanics checks that operators without operands panic
+func TestMatcherPanics(t *testing.T) {
+	tests := map[string]func(Matcher){
+		"And":    func(m Matcher) { m.(MatcherOperator).And() },
+		"Or":     func(m Matcher) { m.(MatcherOperator).Or() },
+		"Cut":    func(m Matcher) { m.(MatcherOperator).Cut() },
+		"Result": func(m Matcher) { m.(MatcherOperator).Result() },
+	}
+
+	for name, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", name)
+				}
+			}()
+
+			fn(New(newTestParser("a")))
+		}()
+	}
+}
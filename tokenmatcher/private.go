@@ -0,0 +1,256 @@
+package tokenmatcher
+
+import (
+	"strings"
+
+	"github.com/iNamik/go_container/queue"
+	"github.com/iNamik/go_lexer"
+	"github.com/iNamik/go_lexer_matcher"
+	"github.com/iNamik/go_parser"
+)
+
+type matcherCallback func() bool
+
+// matcherExpected describes an operand, for diagnostics
+type matcherExpected func() []string
+
+type matcherFn func(bool, matcherCallback) bool
+
+type matcherEndFn func(bool) bool
+
+// matcherMarker is a parser marker, along with the number of tokens consumed,
+// and of list items matched, when it was created
+type matcherMarker struct {
+	marker *parser.Marker
+	n      int
+	items  int
+}
+
+type matcherState struct {
+	skipAll  bool
+	skipNext bool
+	result   bool
+	cut      bool
+	fn       matcherFn
+	marker   matcherMarker
+}
+
+type tokenMatcher struct {
+	parser    parser.Parser
+	names     *matcher.TokenSet
+	stack     queue.Interface
+	hasResult bool
+	state     *matcherState
+	tokens    []*lexer.Token
+	failN     int
+	expected  []string
+	found     *lexer.Token
+	last      []*lexer.Token
+	err       error
+	items     []Span
+	listItems [][]*lexer.Token
+	listSpans []Span
+}
+
+// (matcherFn) matcherNil
+func matcherNil(b1 bool, f matcherCallback) bool {
+	return f()
+}
+
+// matcherAnd
+func matcherAnd(b1 bool, f matcherCallback) bool {
+	return b1 && f()
+}
+
+// matcherOr
+func matcherOr(b1 bool, f matcherCallback) bool {
+	return b1 || f()
+}
+
+// endMatchZeroOrOne
+func endMatchZeroOrOne(b bool) bool {
+	return true
+}
+
+// endMatchOne
+func endMatchOne(b bool) bool {
+	return b
+}
+
+// tokenMatcher::doMatch
+func (m *tokenMatcher) doMatch(f matcherCallback, expected matcherExpected) {
+	if m.state.skipNext == false {
+		m.state.result = m.state.fn(m.state.result, f)
+
+		m.hasResult = true
+
+		if m.state.result == false {
+			m.fail(expected)
+		}
+	}
+
+	m.state.skipNext = m.state.skipAll
+
+	m.state.fn = matcherNil
+}
+
+// tokenMatcher::fail records the token types expected by a failed operand,
+// keeping those that failed after the most tokens.  Group results are not
+// recorded, as the operands within the group already have been.
+func (m *tokenMatcher) fail(expected matcherExpected) {
+	if expected == nil {
+		return
+	}
+
+	n := len(m.tokens)
+
+	if len(m.expected) == 0 || n > m.failN {
+		m.failN = n
+		m.expected = nil
+		m.found = m.parser.PeekToken(0)
+	}
+
+	if n == m.failN {
+	next:
+		for _, t := range expected() {
+			for _, x := range m.expected {
+				if x == t {
+					continue next
+				}
+			}
+			m.expected = append(m.expected, t)
+		}
+	}
+}
+
+// tokenMatcher::expectTypes describes an operand matching, or not matching,
+// a list of token types
+func (m *tokenMatcher) expectTypes(types []lexer.TokenType, negate bool) matcherExpected {
+	return func() []string {
+		names := make([]string, len(types))
+
+		for i, t := range types {
+			names[i] = m.names.Name(t)
+		}
+
+		if negate {
+			return []string{"any token but " + strings.Join(names, ", ")}
+		}
+
+		return names
+	}
+}
+
+// expectItem describes a list item, for diagnostics
+func expectItem() []string {
+	return []string{"a list item"}
+}
+
+// tokenMatcher::mark
+func (m *tokenMatcher) mark() matcherMarker {
+	return matcherMarker{m.parser.Marker(), len(m.tokens), len(m.items)}
+}
+
+// tokenMatcher::reset
+func (m *tokenMatcher) reset(mk matcherMarker) {
+	m.parser.Reset(mk.marker)
+
+	m.tokens = m.tokens[:mk.n]
+
+	m.items = m.items[:mk.items]
+}
+
+// tokenMatcher::run consumes a run of at least min, and at most max, tokens
+// whose membership in types is the opposite of negate.  max < 0 means no
+// limit.  Consumed tokens are rewound if the run is too short.
+func (m *tokenMatcher) run(types []lexer.TokenType, negate bool, min int, max int) bool {
+	mk := m.mark()
+
+	n := 0
+
+	for max < 0 || n < max {
+		t := m.parser.PeekTokenType(0)
+
+		if t == lexer.TokenTypeEOF || containsType(types, t) == negate {
+			break
+		}
+
+		m.tokens = append(m.tokens, m.parser.NextToken())
+		n++
+	}
+
+	if n < min {
+		m.reset(mk)
+		return false
+	}
+
+	return true
+}
+
+// containsType
+func containsType(types []lexer.TokenType, t lexer.TokenType) bool {
+	for _, x := range types {
+		if x == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tokenMatcher::clearState
+func (m *tokenMatcher) clearState() {
+	m.state.result = false
+
+	m.state.skipAll = false
+
+	m.state.skipNext = false
+
+	m.state.cut = false
+
+	m.state.fn = matcherNil
+
+	m.state.marker = m.mark()
+}
+
+// tokenMatcher::pushState
+func (m *tokenMatcher) pushState() {
+	m.stack.Add(m.state)
+
+	m.state = &matcherState{}
+
+	m.clearState()
+}
+
+// tokenMatcher::popState
+func (m *tokenMatcher) popState() {
+	i := m.stack.Remove()
+
+	m.state = i.(*matcherState)
+}
+
+// tokenMatcher::begin
+func (m *tokenMatcher) begin() Matcher {
+	tmpSkipAll := m.state.skipAll || m.state.skipNext
+
+	m.pushState()
+
+	m.state.skipAll = tmpSkipAll
+
+	m.state.skipNext = tmpSkipAll
+
+	return m
+}
+
+// tokenMatcher::end provides the cleanup and call-back for the End* functions
+func (m *tokenMatcher) end(endFn matcherEndFn) {
+	if m.state.result == false {
+		m.reset(m.state.marker)
+	}
+
+	b := endFn(m.state.result)
+
+	m.popState()
+
+	m.doMatch(func() bool { return b }, nil)
+}
@@ -0,0 +1,78 @@
+package matcher
+
+import (
+	"strconv"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// TokenSet is a registry of named token types.  Types are assigned in the
+// order they are added, starting after lexer.TokenTypeEOF, so a TokenSet can
+// replace a const block of token types and the switch statement that names
+// them.
+type TokenSet struct {
+	names []string
+	types map[string]lexer.TokenType
+}
+
+// NewTokenSet creates a TokenSet, adding the specified names in order
+func NewTokenSet(names ...string) *TokenSet {
+	s := &TokenSet{types: make(map[string]lexer.TokenType)}
+
+	for _, name := range names {
+		s.Add(name)
+	}
+
+	return s
+}
+
+// Add registers a name, returning its newly assigned token type.  Panics if
+// the name is already registered.
+func (s *TokenSet) Add(name string) lexer.TokenType {
+	if _, ok := s.types[name]; ok {
+		panic("Token type already registered: " + name)
+	}
+
+	t := lexer.TokenTypeEOF + 1 + lexer.TokenType(len(s.names))
+
+	s.names = append(s.names, name)
+
+	s.types[name] = t
+
+	return t
+}
+
+// Type returns the token type registered with the specified name
+func (s *TokenSet) Type(name string) (lexer.TokenType, bool) {
+	t, ok := s.types[name]
+
+	return t, ok
+}
+
+// Types returns the registered token types, in the order they were added
+func (s *TokenSet) Types() []lexer.TokenType {
+	types := make([]lexer.TokenType, len(s.names))
+
+	for i := range s.names {
+		types[i] = lexer.TokenTypeEOF + 1 + lexer.TokenType(i)
+	}
+
+	return types
+}
+
+// Name returns the name of a token type.  The pre-defined types are named
+// T_UNKNOWN and T_EOF, and unregistered types are named by their number.
+func (s *TokenSet) Name(t lexer.TokenType) string {
+	switch t {
+	case lexer.TokenTypeUnknown:
+		return "T_UNKNOWN"
+	case lexer.TokenTypeEOF:
+		return "T_EOF"
+	}
+
+	if i := int(t - lexer.TokenTypeEOF - 1); i >= 0 && i < len(s.names) {
+		return s.names[i]
+	}
+
+	return strconv.Itoa(int(t))
+}
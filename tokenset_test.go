@@ -0,0 +1,88 @@
+package matcher
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// TestTokenSet checks that types are assigned in order, after T_EOF
+func TestTokenSet(t *testing.T) {
+	s := NewTokenSet("T_A", "T_B")
+
+	if c := s.Add("T_C"); c != lexer.TokenTypeEOF+3 {
+		t.Errorf("Add(T_C) = %d, want %d", c, lexer.TokenTypeEOF+3)
+	}
+
+	types := s.Types()
+
+	if len(types) != 3 {
+		t.Fatalf("Types() has %d types, want 3", len(types))
+	}
+
+	for i, name := range []string{"T_A", "T_B", "T_C"} {
+		if types[i] != lexer.TokenTypeEOF+1+lexer.TokenType(i) {
+			t.Errorf("Types()[%d] = %d, want %d", i, types[i], lexer.TokenTypeEOF+1+lexer.TokenType(i))
+		}
+
+		if typ, ok := s.Type(name); !ok || typ != types[i] {
+			t.Errorf("Type(%s) = %d, %v, want %d, true", name, typ, ok, types[i])
+		}
+
+		if got := s.Name(types[i]); got != name {
+			t.Errorf("Name(%d) = %q, want %q", types[i], got, name)
+		}
+	}
+
+	if types := NewTokenSet().Types(); len(types) != 0 {
+		t.Errorf("empty TokenSet has types %v", types)
+	}
+}
+
+// TestTokenSetUnknown checks the names of pre-defined and unregistered types
+func TestTokenSetUnknown(t *testing.T) {
+	s := NewTokenSet("T_A")
+
+	tests := map[lexer.TokenType]string{
+		lexer.TokenTypeUnknown:  "T_UNKNOWN",
+		lexer.TokenTypeEOF:      "T_EOF",
+		lexer.TokenTypeEOF + 1:  "T_A",
+		lexer.TokenTypeEOF + 2:  strconv.Itoa(int(lexer.TokenTypeEOF + 2)),
+		lexer.TokenTypeEOF + 99: strconv.Itoa(int(lexer.TokenTypeEOF + 99)),
+	}
+
+	for typ, want := range tests {
+		if got := s.Name(typ); got != want {
+			t.Errorf("Name(%d) = %q, want %q", typ, got, want)
+		}
+	}
+
+	if typ, ok := s.Type("T_B"); ok {
+		t.Errorf("Type(T_B) = %d, true, want false", typ)
+	}
+
+	if _, ok := s.Type("T_EOF"); ok {
+		t.Errorf("Type(T_EOF) found a pre-defined type")
+	}
+}
+
+// TestTokenSetDuplicate checks that registering a name twice panics
+func TestTokenSetDuplicate(t *testing.T) {
+	tests := map[string]func(){
+		"NewTokenSet": func() { NewTokenSet("T_A", "T_A") },
+		"Add":         func() { NewTokenSet("T_A").Add("T_A") },
+	}
+
+	for name, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", name)
+				}
+			}()
+
+			fn()
+		}()
+	}
+}
@@ -0,0 +1,328 @@
+package matcher
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// CaptureError describes captured text that could not be converted to the
+// type of the field it is unmarshalled into
+type CaptureError struct {
+	Name string       // The name of the capture
+	Text string       // The captured text
+	Type reflect.Type // The type of the field
+	Err  error        // The error of the conversion
+}
+
+// CaptureError::Error
+func (e *CaptureError) Error() string {
+	return "capture " + strconv.Quote(e.Name) + ": cannot convert " + strconv.Quote(e.Text) + " to " + e.Type.String() + ": " + e.Err.Error()
+}
+
+// CaptureError::Unwrap
+func (e *CaptureError) Unwrap() error {
+	return e.Err
+}
+
+// converter converts captured text to a value
+type converter func(text string) (reflect.Value, error)
+
+var (
+	typeTime            = reflect.TypeOf(time.Time{})
+	typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// converters are the registered converters, by type.  time.Duration is
+// registered, as its kind would otherwise convert it as an integer.
+var converters = struct {
+	sync.RWMutex
+	m map[reflect.Type]converter
+}{m: make(map[reflect.Type]converter)}
+
+func init() {
+	RegisterConverter(time.ParseDuration)
+}
+
+// RegisterConverter registers a func converting captured text to values of
+// type T, for Unmarshal and Converter, replacing any converter of T
+func RegisterConverter[T any](fn func(string) (T, error)) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	converters.Lock()
+	defer converters.Unlock()
+
+	converters.m[t] = func(text string) (reflect.Value, error) {
+		v, err := fn(text)
+		return reflect.ValueOf(&v).Elem(), err
+	}
+}
+
+// Pattern::searchError describes a search for the pattern in b that failed,
+// as the failure of the pattern at the start of b
+func (p *Pattern) searchError(b []byte) *MatchError {
+	r, _ := utf8.DecodeRune(b)
+
+	if len(b) == 0 {
+		r = lexer.RuneEOF
+	}
+
+	return &MatchError{Pos: Pos{Line: 1, Column: 1}, Expected: []string{p.String()}, Found: describeRune(r)}
+}
+
+// captureField is a field of a struct that a capture is unmarshalled into.
+// elem is the type each capture is converted to, which is appended to the
+// field if it is a slice, and assigned through a new pointer if ptr is true.
+type captureField struct {
+	index   int
+	name    string
+	elem    reflect.Type
+	slice   bool
+	ptr     bool
+	convert converter
+}
+
+// Unmarshal unmarshals the named captures of the leftmost match of the pattern
+// in b into the fields of the struct v points to, by their tags, i.e.
+// `capture:"year"`.  The last capture of a name is converted to the type of
+// its field, or each capture if the field is a slice, pointers being
+// allocated as needed.  Fields whose capture did not match are left as they
+// are.
+//
+// Captures are converted with the converter registered for the type, if any,
+// then with encoding.TextUnmarshaler, then by kind, for strings, bools,
+// integers and floats, as strconv would.  time.Duration is converted by
+// time.ParseDuration, and time.Time is RFC 3339, unless the tag has a layout,
+// i.e. `capture:"when,layout=2006-01-02 15:04"`.
+//
+// Returns a *MatchError if there is no match, reporting the pattern as
+// expected at the start of b, as a Matcher matching the pattern there would,
+// and a *CaptureError if a
+// capture cannot be converted, leaving the struct unchanged in either case.
+// Panics if v is not a pointer to a struct, or if a tag names a capture the
+// pattern does not have, or a type that cannot be converted.
+func (p *Pattern) Unmarshal(b []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic("Calling Unmarshal() with a value that is not a pointer to a struct")
+	}
+
+	fields := p.captureFields(rv.Elem().Type())
+
+	start, _, ok := p.find(b, 0)
+
+	if !ok {
+		return p.searchError(b)
+	}
+
+	caps := p.captures(b, start)
+
+	// Fields are set on a copy, so that a CaptureError leaves v as it was
+	tmp := reflect.New(rv.Elem().Type()).Elem()
+	tmp.Set(rv.Elem())
+
+	for _, f := range fields {
+		fv := tmp.Field(f.index)
+
+		last := lastCapture(caps, f.name)
+
+		for i, c := range caps {
+			if c.name != f.name || (!f.slice && i != last) {
+				continue
+			}
+
+			text := string(b[c.start:c.end])
+
+			x, err := f.convert(text)
+			if err != nil {
+				return &CaptureError{Name: f.name, Text: text, Type: f.elem, Err: err}
+			}
+
+			if f.ptr {
+				ptr := reflect.New(f.elem)
+				ptr.Elem().Set(x)
+				x = ptr
+			}
+
+			if f.slice {
+				fv.Set(reflect.Append(fv, x))
+			} else {
+				fv.Set(x)
+			}
+		}
+	}
+
+	rv.Elem().Set(tmp)
+
+	return nil
+}
+
+// lastCapture returns the index of the last capture of a name, or -1
+func lastCapture(caps []capture, name string) int {
+	for i := len(caps) - 1; i >= 0; i-- {
+		if caps[i].name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Pattern::captureFields returns the tagged fields of a struct type
+func (p *Pattern) captureFields(t reflect.Type) []*captureField {
+	var fields []*captureField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag, ok := sf.Tag.Lookup("capture")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		name, layout := tag, ""
+
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			name, layout = tag[:j], tag[j+1:]
+
+			if !strings.HasPrefix(layout, "layout=") {
+				panic("Unmarshal() field " + sf.Name + " has an unknown tag option: " + layout)
+			}
+
+			layout = layout[len("layout="):]
+		}
+
+		if !hasCapture(p.root, name) {
+			panic("Unmarshal() field " + sf.Name + " refers to an unknown capture: " + name)
+		}
+
+		if !sf.IsExported() {
+			panic("Unmarshal() field " + sf.Name + " is unexported")
+		}
+
+		f := &captureField{index: i, name: name, elem: sf.Type}
+
+		// Byte slices are converted as a whole
+		if f.elem.Kind() == reflect.Slice && f.elem.Elem().Kind() != reflect.Uint8 {
+			f.elem, f.slice = f.elem.Elem(), true
+		}
+
+		if f.elem.Kind() == reflect.Pointer {
+			f.elem, f.ptr = f.elem.Elem(), true
+		}
+
+		if layout != "" && f.elem != typeTime {
+			panic("Unmarshal() field " + sf.Name + " has a layout, but is not a time.Time")
+		}
+
+		if f.convert = newConverter(f.elem, layout); f.convert == nil {
+			panic("Unmarshal() field " + sf.Name + " has no converter for " + f.elem.String())
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// Converter returns the func converting captured text to values of type t,
+// as Unmarshal does, or nil if t cannot be converted.  Packages decoding
+// captures into values of their own, such as grammar, convert them with it,
+// so that RegisterConverter applies to them too.
+func Converter(t reflect.Type) func(string) (reflect.Value, error) {
+	return newConverter(t, "")
+}
+
+// newConverter returns the converter of a type, or nil if there is none.  A
+// layout is only given for time.Time.
+func newConverter(t reflect.Type, layout string) converter {
+	if layout != "" {
+		return func(text string) (reflect.Value, error) {
+			tm, err := time.Parse(layout, text)
+			return reflect.ValueOf(tm), err
+		}
+	}
+
+	converters.RLock()
+	fn := converters.m[t]
+	converters.RUnlock()
+
+	if fn != nil {
+		return fn
+	}
+
+	if reflect.PointerTo(t).Implements(typeTextUnmarshaler) {
+		return func(text string) (reflect.Value, error) {
+			v := reflect.New(t)
+			err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+			return v.Elem(), err
+		}
+	}
+
+	return kindConverter(t)
+}
+
+// kindConverter returns the converter of a string, []byte, bool, integer or
+// float type, or nil for any other type
+func kindConverter(t reflect.Type) converter {
+	v := func() reflect.Value { return reflect.New(t).Elem() }
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(text string) (reflect.Value, error) {
+			x := v()
+			x.SetString(text)
+			return x, nil
+		}
+
+	case reflect.Bool:
+		return func(text string) (reflect.Value, error) {
+			b, err := strconv.ParseBool(text)
+			x := v()
+			x.SetBool(b)
+			return x, err
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(text string) (reflect.Value, error) {
+			n, err := strconv.ParseInt(text, 10, t.Bits())
+			x := v()
+			x.SetInt(n)
+			return x, err
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(text string) (reflect.Value, error) {
+			n, err := strconv.ParseUint(text, 10, t.Bits())
+			x := v()
+			x.SetUint(n)
+			return x, err
+		}
+
+	case reflect.Float32, reflect.Float64:
+		return func(text string) (reflect.Value, error) {
+			n, err := strconv.ParseFloat(text, t.Bits())
+			x := v()
+			x.SetFloat(n)
+			return x, err
+		}
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return func(text string) (reflect.Value, error) {
+				x := v()
+				x.SetBytes([]byte(text))
+				return x, nil
+			}
+		}
+	}
+
+	return nil
+}
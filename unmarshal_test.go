@@ -0,0 +1,304 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wordsPattern matches words separated by single spaces, capturing each word
+// under the next name
+func wordsPattern(names ...string) *Pattern {
+	return Compile(func(m PatternMatcher) PatternOperator {
+		op := m.BeginCapture(names[0]).NonMatchOneOrMoreRunes([]rune{' '}).EndMatchOne()
+
+		for _, name := range names[1:] {
+			op = op.And().MatchOneRune(' ').And().BeginCapture(name).NonMatchOneOrMoreRunes([]rune{' '}).EndMatchOne()
+		}
+
+		return op
+	})
+}
+
+// level is an encoding.TextUnmarshaler
+type level int
+
+// UnmarshalText accepts low and high
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+
+	return nil
+}
+
+// TestUnmarshal checks the conversion of captures to each type of field
+func TestUnmarshal(t *testing.T) {
+	type Record struct {
+		Int      int           `capture:"int"`
+		Int8     int8          `capture:"int8"`
+		Uint     uint16        `capture:"uint"`
+		Float    float32       `capture:"float"`
+		Bool     bool          `capture:"bool"`
+		String   string        `capture:"string"`
+		Bytes    []byte        `capture:"bytes"`
+		Duration time.Duration `capture:"duration"`
+		Time     time.Time     `capture:"time"`
+		Date     time.Time     `capture:"date,layout=2006-01-02"`
+		Level    level         `capture:"level"`
+		Ptr      *int          `capture:"ptr"`
+		Untagged string
+		Ignored  string `capture:"-"`
+	}
+
+	p := wordsPattern("int", "int8", "uint", "float", "bool", "string", "bytes", "duration", "time", "date", "level", "ptr")
+
+	var r Record
+
+	src := "-12 127 65535 2.5 true text bytes 1h30m 2024-02-29T12:30:00Z 2024-03-01 high 7"
+
+	if err := p.Unmarshal([]byte(src), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fmt.Sprintf("%d %d %d %g %v %s %s %v %s %s %d %d",
+		r.Int, r.Int8, r.Uint, r.Float, r.Bool, r.String, r.Bytes, r.Duration,
+		r.Time.Format(time.RFC3339), r.Date.Format(time.DateOnly), r.Level, *r.Ptr)
+
+	if want := "-12 127 65535 2.5 true text bytes 1h30m0s 2024-02-29T12:30:00Z 2024-03-01 2 7"; got != want {
+		t.Errorf("unmarshalled %s, want %s", got, want)
+	}
+}
+
+// TestUnmarshalSlices checks that each capture of a name is appended to a
+// slice, while the last is assigned to any other field
+func TestUnmarshalSlices(t *testing.T) {
+	type Numbers struct {
+		All  []int    `capture:"n"`
+		Ptrs []*int   `capture:"n"`
+		Last int      `capture:"n"`
+		Text []string `capture:"n"`
+	}
+
+	item := Compile(func(m PatternMatcher) PatternOperator {
+		return m.BeginCapture("n").MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).EndMatchOne()
+	})
+
+	p := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchList(item, listSep, ListOptions{Min: 1})
+	})
+
+	n := Numbers{Text: []string{"0"}}
+
+	if err := p.Unmarshal([]byte("x 1,22,3"), &n); err != nil {
+		t.Fatal(err)
+	}
+
+	ptrs := make([]int, len(n.Ptrs))
+	for i, ptr := range n.Ptrs {
+		ptrs[i] = *ptr
+	}
+
+	if got := fmt.Sprint(n.All, ptrs, n.Last, n.Text); got != "[1 22 3] [1 22 3] 3 [0 1 22 3]" {
+		t.Errorf("unmarshalled %s", got)
+	}
+}
+
+// TestUnmarshalUnmatched checks that the fields of captures that did not
+// match are left as they are
+func TestUnmarshalUnmatched(t *testing.T) {
+	type Pair struct {
+		Key   string `capture:"key"`
+		Value string `capture:"value"`
+	}
+
+	p := Compile(func(m PatternMatcher) PatternOperator {
+		return m.BeginCapture("key").MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).EndMatchOne().
+			AndBegin().MatchOneRune('=').And().BeginCapture("value").MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).EndMatchOne().
+			EndMatchZeroOrOne()
+	})
+
+	pair := Pair{Key: "old", Value: "old"}
+
+	if err := p.Unmarshal([]byte("1 abc"), &pair); err != nil || pair != (Pair{Key: "abc", Value: "old"}) {
+		t.Errorf("unmarshalled %+v, %v", pair, err)
+	}
+}
+
+// TestUnmarshalErrors checks that a failed Unmarshal reports why, and leaves
+// the struct unchanged
+func TestUnmarshalErrors(t *testing.T) {
+	type Pair struct {
+		Key   string `capture:"key"`
+		Value int8   `capture:"value"`
+	}
+
+	p := wordsPattern("key", "value")
+
+	pair := Pair{Key: "old", Value: 1}
+
+	err := p.Unmarshal([]byte("new 128"), &pair)
+
+	var capErr *CaptureError
+
+	switch {
+	case !errors.As(err, &capErr):
+		t.Fatalf("Unmarshal() = %v, want a *CaptureError", err)
+	case capErr.Name != "value" || capErr.Text != "128" || capErr.Type != reflect.TypeOf(int8(0)):
+		t.Errorf("CaptureError = %+v", capErr)
+	case !errors.Is(err, strconv.ErrRange):
+		t.Errorf("CaptureError does not unwrap to strconv.ErrRange: %v", err)
+	}
+
+	if want := `capture "value": cannot convert "128" to int8: strconv.ParseInt: parsing "128": value out of range`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	if pair != (Pair{Key: "old", Value: 1}) {
+		t.Errorf("a CaptureError changed the struct to %+v", pair)
+	}
+
+	err = p.Unmarshal([]byte("new"), &pair)
+
+	var matchErr *MatchError
+
+	switch {
+	case !errors.As(err, &matchErr) || pair.Key != "old":
+		t.Errorf("Unmarshal() without a match = %v, %+v", err, pair)
+	case matchErr.Pos.String() != "1:1" || len(matchErr.Expected) != 1 || matchErr.Expected[0] != p.String() || matchErr.Found != "'n'":
+		t.Errorf("MatchError = %+v", matchErr)
+	}
+
+	if got, want := FormatError([]byte("new"), err), "1:1: expected "+p.String()+", found 'n'\nnew\n^"; got != want {
+		t.Errorf("FormatError() = %q, want %q", got, want)
+	}
+
+	if err := p.Unmarshal(nil, &pair); err == nil || err.Error() != "1:1: expected "+p.String()+", found EOF" {
+		t.Errorf("Unmarshal() of nothing = %v", err)
+	}
+}
+
+// upper is a string converted to upper case by its registered converter
+type upper string
+
+// TestRegisterConverter checks that a registered converter replaces the
+// conversion by kind, and any converter registered before it
+func TestRegisterConverter(t *testing.T) {
+	type Words struct {
+		Upper upper  `capture:"upper"`
+		Plain string `capture:"plain"`
+	}
+
+	p := wordsPattern("upper", "plain")
+
+	RegisterConverter(func(s string) (upper, error) {
+		return "", errors.New("replaced")
+	})
+
+	RegisterConverter(func(s string) (upper, error) {
+		if s[0] == '!' {
+			return "", errors.New("not a word")
+		}
+		return upper(strings.ToUpper(s)), nil
+	})
+
+	var w Words
+
+	if err := p.Unmarshal([]byte("abc def"), &w); err != nil || w != (Words{"ABC", "def"}) {
+		t.Errorf("unmarshalled %+v, %v", w, err)
+	}
+
+	if err := p.Unmarshal([]byte("!x y"), &w); err == nil || err.Error() != `capture "upper": cannot convert "!x" to matcher.upper: not a word` {
+		t.Errorf("unmarshalled %+v, %v, want an error", w, err)
+	}
+}
+
+// TestUnmarshalNestedCaptures checks that captures within list items and
+// separators, and within skippers, can be unmarshalled
+func TestUnmarshalNestedCaptures(t *testing.T) {
+	type List struct {
+		Items    []string `capture:"item"`
+		Seps     []string `capture:"sep"`
+		Comments []string `capture:"comment"`
+	}
+
+	item := Compile(func(m PatternMatcher) PatternOperator {
+		return m.BeginCapture("item").MatchOneOrMoreSet(NewRuneSetFromRangeString("a-z")).EndMatchOne()
+	})
+
+	sep := Compile(func(m PatternMatcher) PatternOperator {
+		return m.BeginCapture("sep").MatchOneRunes([]rune(",;")).EndMatchOne()
+	})
+
+	comment := Compile(func(m PatternMatcher) PatternOperator {
+		return m.MatchOneRune(' ').
+			OrBegin().MatchOneRune('#').And().BeginCapture("comment").MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).EndMatchOne().EndMatchOne()
+	})
+
+	p := Compile(func(m Matcher) MatcherOperator {
+		return m.BeginSkipping(comment).MatchList(item, sep, ListOptions{}).EndMatchOne()
+	})
+
+	var l List
+
+	if err := p.Unmarshal([]byte("a, b #1; c#2,d"), &l); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fmt.Sprint(l.Items, l.Seps, l.Comments); got != "[a b c d] [, ; ,] [1 2]" {
+		t.Errorf("unmarshalled %s", got)
+	}
+}
+
+// TestUnmarshalPanics checks the panics of invalid values and tags
+func TestUnmarshalPanics(t *testing.T) {
+	p := wordsPattern("a")
+
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{struct{}{}, "Calling Unmarshal() with a value that is not a pointer to a struct"},
+		{(*struct{})(nil), "Calling Unmarshal() with a value that is not a pointer to a struct"},
+		{new(int), "Calling Unmarshal() with a value that is not a pointer to a struct"},
+		{&struct {
+			A string `capture:"a,format=x"`
+		}{}, "Unmarshal() field A has an unknown tag option: format=x"},
+		{&struct {
+			B string `capture:"b"`
+		}{}, "Unmarshal() field B refers to an unknown capture: b"},
+		{&struct {
+			a string `capture:"a"`
+		}{}, "Unmarshal() field a is unexported"},
+		{&struct {
+			A string `capture:"a,layout=2006"`
+		}{}, "Unmarshal() field A has a layout, but is not a time.Time"},
+		{&struct {
+			A map[string]int `capture:"a"`
+		}{}, "Unmarshal() field A has no converter for map[string]int"},
+		{&struct {
+			A []chan int `capture:"a"`
+		}{}, "Unmarshal() field A has no converter for chan int"},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r != test.want {
+					t.Errorf("%T: panicked with %v, want %q", test.v, r, test.want)
+				}
+			}()
+
+			p.Unmarshal([]byte("x"), test.v)
+		}()
+	}
+}
@@ -0,0 +1,255 @@
+package matcher
+
+import (
+	"bytes"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/iNamik/go_lexer"
+)
+
+// noEscape is the escape rune of an until node without one.  It is the same as
+// lexer.RuneEOF, which is always tested first.
+const noEscape rune = -1
+
+// checkUntilTerm panics if the terminator of a MatchUntil* operand is empty
+func checkUntilTerm(term string) {
+	if term == "" {
+		panic("Until term is empty")
+	}
+}
+
+// scanUntil consumes the runes before the first occurrence of term, and the
+// term too if inclusive, skipping any rune following an escape rune.  It
+// returns the number of runes consumed, or -1 if term does not occur before
+// EOF, in which case the caller rewinds.  Runes are consumed as they are
+// looked at, peeking only to compare term, so that the time taken is linear
+// in the number of runes skipped.
+func scanUntil(peek func(int) rune, next func(), term []rune, inclusive bool, escape rune) int {
+	for n := 0; ; n++ {
+		r := peek(0)
+
+		switch {
+		case r == lexer.RuneEOF:
+			return -1
+
+		case r == escape:
+			next()
+			if peek(0) == lexer.RuneEOF {
+				return -1
+			}
+			n++
+
+		case r == term[0] && hasRunesAt(peek, 0, term):
+			if inclusive {
+				for range term {
+					next()
+				}
+				n += len(term)
+			}
+			return n
+		}
+
+		next()
+	}
+}
+
+// hasRunesAt returns true if the runes ahead, starting i runes ahead, begin
+// with term
+func hasRunesAt(peek func(int) rune, i int, term []rune) bool {
+	for j, r := range term {
+		if peek(i+j) != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+// indexUntilBytes returns the offset of the first occurrence of term in src,
+// skipping any rune following an escape rune, or -1 if there is none
+func indexUntilBytes(src []byte, term []byte, escape rune) int {
+	if escape == noEscape {
+		return bytes.Index(src, term)
+	}
+
+	t := bytes.Index(src, term)
+
+	for i := 0; t >= 0; {
+		// Look for an escape before the term, or escaping its first rune
+		k := bytes.IndexRune(src[i:t+len(term)], escape)
+		if k < 0 || i+k > t {
+			return t
+		}
+
+		// Skip the escape and the rune it escapes
+		i += k + utf8.RuneLen(escape)
+		if i >= len(src) {
+			return -1
+		}
+
+		_, w := utf8.DecodeRune(src[i:])
+
+		i += w
+
+		if i > t {
+			if t = bytes.Index(src[i:], term); t >= 0 {
+				t += i
+			}
+		}
+	}
+
+	return -1
+}
+
+// searchUntil finds the first occurrence of term in the input following the
+// position of an Input with indexUntilBytes, then consumes the runes before
+// it, and the term too if inclusive, with next.  It returns the number of
+// runes consumed, or -1 if term does not occur, in which case nothing is
+// consumed.
+func searchUntil(in *Input, next func(), term string, inclusive bool, escape rune) int {
+	b := in.buffered()
+
+	i := indexUntilBytes(b, []byte(term), escape)
+	if i < 0 {
+		return -1
+	}
+
+	if inclusive {
+		i += len(term)
+	}
+
+	n := 0
+
+	for j := 0; j < i; n++ {
+		_, w := utf8.DecodeRune(b[j:])
+		j += w
+		next()
+	}
+
+	return n
+}
+
+// matchUntil consumes the runes before the first occurrence of term, and the
+// term too if inclusive, leaving the lexer unchanged if there is none.  The
+// buffered input of an Input is searched, and other lexers scanned.
+func matchUntil(l lexer.Lexer, term string, inclusive bool, escape rune) bool {
+	if in, ok := l.(*Input); ok {
+		return searchUntil(in, func() { in.NextRune() }, term, inclusive, escape) >= 0
+	}
+
+	start := l.Marker()
+
+	if scanUntil(l.PeekRune, func() { l.NextRune() }, []rune(term), inclusive, escape) < 0 {
+		l.Reset(start)
+		return false
+	}
+
+	return true
+}
+
+// matchUntilPattern consumes the runes before the first position at which the
+// Pattern matches, without consuming the match, leaving the lexer unchanged if
+// there is no such position
+func matchUntilPattern(l lexer.Lexer, p *Pattern) bool {
+	start := l.Marker()
+
+	for {
+		r := l.PeekRune(0)
+
+		if p.first == nil || p.first.Contains(r) {
+			m := l.Marker()
+
+			if p.Match(l) {
+				l.Reset(m)
+				return true
+			}
+		}
+
+		if r == lexer.RuneEOF {
+			l.Reset(start)
+			return false
+		}
+
+		l.NextRune()
+	}
+}
+
+// executor::until matches an until node
+func (e *executor) until(n *node) bool {
+	start := e.mark()
+
+	if n.stop != nil {
+		for {
+			r := e.peek()
+
+			if n.class == nil || n.class.Contains(r) {
+				m := e.mark()
+
+				if e.match(n.stop) {
+					e.reset(m)
+					return true
+				}
+			}
+
+			if r == lexer.RuneEOF {
+				e.reset(start)
+				return false
+			}
+
+			e.next()
+			e.n++
+		}
+	}
+
+	if e.lexer == nil {
+		i := indexUntilBytes(e.src[e.pos:], []byte(n.until), n.escape)
+		if i < 0 {
+			return false
+		}
+
+		if n.inclusive {
+			i += len(n.until)
+		}
+
+		e.n += utf8.RuneCount(e.src[e.pos : e.pos+i])
+		e.pos += i
+
+		return true
+	}
+
+	var i int
+
+	if in, ok := e.lexer.(*Input); ok {
+		i = searchUntil(in, e.next, n.until, n.inclusive, n.escape)
+	} else {
+		i = scanUntil(e.lexer.PeekRune, e.next, []rune(n.until), n.inclusive, n.escape)
+	}
+
+	if i < 0 {
+		e.reset(start)
+		return false
+	}
+
+	e.n += i
+
+	return true
+}
+
+// untilString returns an until node in regex syntax, i.e. (?s:.*?)(?=\*/)
+func untilString(n *node) string {
+	s := `(?s:.*?)`
+	if n.escape != noEscape {
+		esc := regexp.QuoteMeta(string(n.escape))
+		s = `(?s:` + esc + `.|[^` + esc + `])*?`
+	}
+
+	switch {
+	case n.stop != nil:
+		return s + `(?=` + n.stop.termsString() + `)`
+	case n.inclusive:
+		return s + regexp.QuoteMeta(n.until)
+	}
+
+	return s + `(?=` + regexp.QuoteMeta(n.until) + `)`
+}
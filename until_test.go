@@ -0,0 +1,142 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMatchUntilString checks that the term is found, and consumed only if
+// inclusive
+func TestMatchUntilString(t *testing.T) {
+	runOperandTests(t, []operandTest{
+		{"exclusive", func(m Matcher) MatcherOperator { return m.MatchUntilString("*/", false) },
+			map[string]string{"ab*/c": "ab", "*/": "", "a*b*/": "a*b", "a**/": "a*", "ab*": "!", "": "!"}},
+		{"inclusive", func(m Matcher) MatcherOperator { return m.MatchUntilString("*/", true) },
+			map[string]string{"ab*/c*/": "ab*/", "*/": "*/", "a*b*/": "a*b*/", "ab": "!"}},
+		{"multibyte", func(m Matcher) MatcherOperator { return m.MatchUntilString("»", true) },
+			map[string]string{"«é»x": "«é»", "«é": "!"}},
+		{"followed", func(m Matcher) MatcherOperator {
+			return m.MatchOneRune('"').And().MatchUntilString(`"`, true).And().MatchOneRune(';')
+		}, map[string]string{`"ab";`: `"ab";`, `"ab"x";`: "!"}},
+	})
+}
+
+// TestMatchUntilUnescaped checks that an escaped rune never starts the term,
+// and that an escape at EOF fails
+func TestMatchUntilUnescaped(t *testing.T) {
+	runOperandTests(t, []operandTest{
+		{"quote", func(m Matcher) MatcherOperator { return m.MatchUntilUnescaped(`"`, '\\') },
+			map[string]string{
+				`ab"`:      "ab",
+				`a\"b"`:    `a\"b`,
+				`\\"`:      `\\`,
+				`\\\"x"`:   `\\\"x`,
+				`a\"`:      "!",
+				`a\`:       "!",
+				`"`:        "",
+				`é\ü"`:     `é\ü`,
+				`\"\"\"""`: `\"\"\"`,
+			}},
+		{"escaping the first rune of the term", func(m Matcher) MatcherOperator { return m.MatchUntilUnescaped("*/", '\\') },
+			map[string]string{`a\*/b*/`: `a\*/b`, `\*/`: "!", `*\*/`: "!"}},
+		{"multibyte escape", func(m Matcher) MatcherOperator { return m.MatchUntilUnescaped("'", '¬') },
+			map[string]string{"a¬'b'": "a¬'b", "a¬": "!"}},
+	})
+}
+
+// TestMatchUntilPattern checks that the stop Pattern is tried where its
+// first rune can start a match, including at EOF, and is not consumed
+func TestMatchUntilPattern(t *testing.T) {
+	digits := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneOrMoreSet(NewRuneSetFromRangeString("0-9")).And().MatchOneRune(';')
+	})
+
+	// The first rune of a match is unknown, so it is tried everywhere
+	optional := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchZeroOrOneRune('x').And().MatchOneRune(';')
+	})
+
+	end := Compile(func(m Matcher) MatcherOperator {
+		return m.MatchOneRune('.').Or().MatchEOF()
+	})
+
+	runOperandTests(t, []operandTest{
+		{"stop set", func(m Matcher) MatcherOperator { return m.MatchUntilPattern(digits) },
+			map[string]string{"ab12;": "ab", "1a2;": "1a", "12": "!", "": "!", "12;": ""}},
+		{"no stop set", func(m Matcher) MatcherOperator { return m.MatchUntilPattern(optional) },
+			map[string]string{"abx;": "ab", "ab;": "ab", "ax": "!"}},
+		{"EOF", func(m Matcher) MatcherOperator { return m.MatchUntilPattern(end) },
+			map[string]string{"ab.c": "ab", "abc": "abc", "": ""}},
+		{"followed", func(m Matcher) MatcherOperator {
+			return m.MatchUntilPattern(digits).And().MatchOneRune('9')
+		}, map[string]string{"ab9;": "ab9", "ab8;": "!"}},
+	})
+}
+
+// TestMatchUntilLong checks that a long run before the term is matched, as
+// each rune is only looked at a bounded number of times
+func TestMatchUntilLong(t *testing.T) {
+	input := strings.Repeat(`ab\"`, 1<<14) + `"`
+
+	runOperandTests(t, []operandTest{
+		{"long", func(m Matcher) MatcherOperator { return m.MatchUntilUnescaped(`"`, '\\') },
+			map[string]string{input: input[:len(input)-1]}},
+	})
+}
+
+// TestMatchUntilLexer checks that a lexer that is not an Input, whose input is
+// scanned rather than searched, matches the same, with a Matcher and a Pattern
+func TestMatchUntilLexer(t *testing.T) {
+	tests := []operandTest{
+		{"exclusive", func(m Matcher) MatcherOperator { return m.MatchUntilString("*/", false) },
+			map[string]string{"a**/": "a*", "ab*": "!"}},
+		{"inclusive", func(m Matcher) MatcherOperator { return m.MatchUntilString("»", true) },
+			map[string]string{"«é»x": "«é»", "«é": "!"}},
+		{"unescaped", func(m Matcher) MatcherOperator { return m.MatchUntilUnescaped(`"`, '\\') },
+			map[string]string{`a\"b"`: `a\"b`, `\\"`: `\\`, `a\`: "!"}},
+	}
+
+	for _, test := range tests {
+		p := Compile(test.fn)
+
+		for input, want := range test.inputs {
+			ok, got := matchLexer(test.fn, input)
+
+			if !ok {
+				got = "!"
+			}
+
+			if got != want {
+				t.Errorf("%s: Matcher on %q: matched %q, want %q", test.name, input, got, want)
+			}
+
+			ok, got = matchLexer(func(m Matcher) MatcherOperator { return m.MatchPattern(p) }, input)
+
+			if !ok {
+				got = "!"
+			}
+
+			if got != want {
+				t.Errorf("%s: Pattern on %q: matched %q, want %q", test.name, input, got, want)
+			}
+		}
+	}
+}
+
+// TestMatchUntilPanics checks that an empty term panics
+func TestMatchUntilPanics(t *testing.T) {
+	for name, fn := range map[string]func(Matcher) MatcherOperator{
+		"MatchUntilString":    func(m Matcher) MatcherOperator { return m.MatchUntilString("", false) },
+		"MatchUntilUnescaped": func(m Matcher) MatcherOperator { return m.MatchUntilUnescaped("", '\\') },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != "Until term is empty" {
+					t.Errorf("%s: panicked with %v", name, r)
+				}
+			}()
+
+			matchMatcher(fn, "a")
+		}()
+	}
+}